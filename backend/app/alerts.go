@@ -0,0 +1,190 @@
+// Provides the deferred and escalating notification about freed timeslots that remain empty
+
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// AlertDispatcher sends the PendingAlert whose grace period has passed, and escalates them along the stages of the
+// configured escalation policy for as long as the timeslot stays empty. As the alerts are persisted in the database,
+// none are lost if the application is restarted in the meantime. It is supposed to be run periodically as a job of the
+// scheduler.
+type AlertDispatcher struct {
+	db        *DBHandler
+	config    *Config
+	notifiers map[string]Notifier
+}
+
+// NewAlertDispatcher is the constructor for AlertDispatcher.
+func NewAlertDispatcher(db *DBHandler, config *Config) *AlertDispatcher {
+	return &AlertDispatcher{db: db, config: config, notifiers: createNotifiers(config)}
+}
+
+// Run dispatches the due alerts and conforms to scheduler.JobFunc.
+func (d *AlertDispatcher) Run(_ context.Context, _ string) error {
+	return d.dispatch(time.Now())
+}
+
+// dispatch processes all due alerts. Alerts whose timeslot was booked again in the meantime are silently discarded,
+// which stops any further escalation.
+func (d *AlertDispatcher) dispatch(now time.Time) error {
+	alerts, err := d.db.GetDuePendingAlerts(now)
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range alerts {
+		// On failure before anyone was notified, the alert is kept for another attempt on the next run
+		if err := d.dispatchAlert(alert, now); err != nil {
+			log.Println("[alerts] Failed to dispatch alert:", err)
+		}
+	}
+
+	return nil
+}
+
+// dispatchAlert notifies the audiences of all stages that are due for the parts of the alert's timeslot that are
+// still free and not yet over. The stage the alert is at is always due, while later stages are due, if their time
+// already passed, e.g. because the timeslot was freed on very short notice. Afterward, the alert advances to the next
+// stage, or is removed, if there is none.
+func (d *AlertDispatcher) dispatchAlert(alert PendingAlert, now time.Time) error {
+	stages := d.config.EscalationStages
+
+	entries, err := d.db.GetAllEntriesForRange(alert.Start, alert.End)
+	if err != nil {
+		return err
+	}
+	types, err := loadEventTypes(d.db)
+	if err != nil {
+		return err
+	}
+	entries = types.coveringEntries(entries)
+
+	free := make([]Interval, 0)
+	for _, interval := range uncoveredIntervals(alert.Start, alert.End, entries) {
+		// A timeslot that is already over doesn't need anyone anymore
+		if interval.End.After(now) {
+			free = append(free, interval)
+		}
+	}
+	if len(free) == 0 || alert.Stage >= len(stages) {
+		return d.db.DeletePendingAlert(alert.Id)
+	}
+
+	// The volunteers are only alerted once the waitlist had its chance, so the alert is postponed until then
+	offers, err := d.db.GetActiveWaitlistOffers(alert.Start, alert.End, now)
+	if err != nil {
+		return err
+	}
+	if len(offers) > 0 {
+		return nil
+	}
+
+	next := alert.Stage + 1
+	for next < len(stages) && !alert.Start.Add(-stages[next].Before).After(now) {
+		next++
+	}
+
+	// The recipients are gathered upfront, so a failure can't interrupt the notifications halfway
+	recipients := make(map[string][]Recipient)
+	for _, stage := range stages[alert.Stage:next] {
+		if _, ok := recipients[stage.Audience]; ok {
+			continue
+		}
+		recipients[stage.Audience], err = d.getRecipients(stage.Audience)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The same audience is notified only once per channel, even if several of its stages are due at once. A failing
+	// notification is only logged, as retrying the whole stage would repeat the notifications that were already sent.
+	notified := make(map[[2]string]bool)
+	for _, stage := range stages[alert.Stage:next] {
+		for _, channel := range stage.Channels {
+			key := [2]string{stage.Audience, channel}
+			if notified[key] {
+				continue
+			}
+			notified[key] = true
+
+			notifier, ok := d.notifiers[channel]
+			if !ok {
+				log.Printf("[alerts] Escalation channel %s is not configured", channel)
+				continue
+			}
+			for _, interval := range free {
+				if err := notifier.Notify(stage.Audience, recipients[stage.Audience], interval); err != nil {
+					log.Printf("[alerts] Failed to notify %s via %s: %s", stage.Audience, channel, err)
+				}
+			}
+		}
+	}
+
+	if next >= len(stages) {
+		return d.db.DeletePendingAlert(alert.Id)
+	}
+	return d.db.UpdatePendingAlertStage(alert.Id, next, alert.Start.Add(-stages[next].Before))
+}
+
+// getRecipients gathers the recipients of an audience, i.e., either the confirmed volunteers or the coordinators.
+func (d *AlertDispatcher) getRecipients(audience string) ([]Recipient, error) {
+	recipients := make([]Recipient, 0)
+
+	if audience == "coordinators" {
+		for _, email := range d.config.CoordinatorEmails {
+			recipients = append(recipients, Recipient{Email: email})
+		}
+		for _, phone := range d.config.CoordinatorPhones {
+			recipients = append(recipients, Recipient{Phone: phone})
+		}
+		return recipients, nil
+	}
+
+	emails, err := d.db.GetVolunteerEmails()
+	if err != nil {
+		return nil, err
+	}
+	for _, email := range emails {
+		recipients = append(recipients, Recipient{Email: email})
+	}
+
+	return recipients, nil
+}
+
+// queueCancellationAlerts queues a PendingAlert for each of the given deleted entries that didn't start yet, which is
+// due at the first stage of the escalation policy. Thereby, each stage fires at its configured time before the start,
+// no matter how early the entry was deleted. The volunteers are only informed once the grace period has passed and the
+// timeslot is still free.
+func (h *ApiHandler) queueCancellationAlerts(entries ...CalendarEntry) error {
+	stages := h.config.EscalationStages
+	if len(stages) == 0 {
+		return nil
+	}
+	now := time.Now()
+
+	for _, entry := range entries {
+		if !entry.Start.After(now) {
+			continue
+		}
+
+		// The grace period applies even if the first stage is already due, but must not delay the alert beyond the
+		// start of the timeslot itself
+		dueAt := entry.Start.Add(-stages[0].Before)
+		if grace := now.Add(h.config.AlertGracePeriod); dueAt.Before(grace) {
+			dueAt = grace
+		}
+		if dueAt.After(entry.Start) {
+			dueAt = entry.Start
+		}
+
+		if err := h.db.InsertPendingAlert(entry.Start, entry.End, dueAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -3,8 +3,10 @@
 package app
 
 import (
+	"database/sql"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,6 +16,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/Sakrafux/pray-calendar/backend/scheduler"
 	"github.com/Sakrafux/pray-calendar/backend/security"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/httplog/v2"
@@ -21,88 +24,150 @@ import (
 
 // ApiHandler serves as a service class handling the underlying database layer and providing all the API layer methods.
 type ApiHandler struct {
-	db    *DBHandler
-	admin *security.AdminData
+	db     *DBHandler
+	admin  *security.AdminData
+	config *Config
+	jobs   *scheduler.Scheduler
+	events *EventBroker
 }
 
 // NewApiHandler is the constructor for ApiHandler.
-func NewApiHandler(db *DBHandler, admin *security.AdminData) *ApiHandler {
-	return &ApiHandler{db: db, admin: admin}
+func NewApiHandler(db *DBHandler, admin *security.AdminData, config *Config, jobs *scheduler.Scheduler, events *EventBroker) *ApiHandler {
+	return &ApiHandler{db: db, admin: admin, config: config, jobs: jobs, events: events}
 }
 
-// GetAllEntries provides all CalendarEntry for a week starting at a date given via query parameter "start".
-// It provides CalendarEntryFull instead, if admin permissions are available.
+// GetAllEntries provides all CalendarEntry for the date range given via the query parameters "from" and "to" (both
+// inclusive), or for a week starting at a date given via query parameter "start". It provides CalendarEntryFull
+// instead, if admin permissions are available. The entries may be filtered via the query parameters "eventType" and
+// "series", see parseEntryFilter. With the query parameter "aggregate=day", a DaySummary per day is provided instead,
+// which contains no personal information.
 func (h *ApiHandler) GetAllEntries(w http.ResponseWriter, r *http.Request) {
-	start := r.URL.Query().Get("start")
-	// Parse only for date
-	startTime, err := time.Parse("2006-01-02", start)
+	start, end, err := parseEntryRange(r)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusBadRequest)
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	filter, err := parseEntryFilter(r)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
 	// Check admin permissions from the request context
-	if r.Context().Value("admin").(bool) {
-		entries, err := h.db.GetAllFullEntriesForWeek(startTime)
+	if r.Context().Value("admin").(bool) && r.URL.Query().Get("aggregate") == "" {
+		entries, err := h.db.GetAllFullEntriesForRange(start, end)
 		if err != nil {
-			httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+			httpProblemWithLog(r, w, err)
 			return
 		}
 
-		writeJson(w, entries)
+		writeJson(w, slices.DeleteFunc(entries, func(entry CalendarEntryFull) bool {
+			return !filter.matches(entry.CalendarEntry)
+		}))
 		return
 	}
 
-	entries, err := h.db.GetAllEntriesForWeek(startTime)
+	entries, err := h.db.GetAllEntriesForRange(start, end)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
-	writeJson(w, entries)
+	switch r.URL.Query().Get("aggregate") {
+	case "":
+		writeJson(w, slices.DeleteFunc(entries, func(entry CalendarEntry) bool {
+			return !filter.matches(entry)
+		}))
+	case "day":
+		types, err := loadEventTypes(h.db)
+		if err != nil {
+			httpProblemWithLog(r, w, err)
+			return
+		}
+
+		// The coverage depends on all entries, so the filter only applies to the counted entries
+		writeJson(w, summarizeDays(entries, filter, types, start, end))
+	default:
+		httpProblemWithLog(r, w, ErrInvalidAggregate)
+	}
 }
 
 // PostEntry creates a new CalendarEntryFull, which is provided via the request body. It also validates the input.
+// For admin events, the query parameter "override" may be set to "preview" or "confirm" to displace the entries of
+// participants, see postOverride. If the timeslot is occupied, the ConflictResponse names the colliding entries.
 func (h *ApiHandler) PostEntry(w http.ResponseWriter, r *http.Request) {
 	var entry CalendarEntryFull
 	err := json.NewDecoder(r.Body).Decode(&entry)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusBadRequest)
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
 		return
 	}
 
 	// Validate "business rules" for an entry
-	if entry.Start.Before(time.Now()) {
-		httpErrorWithLog(r, w, "Start time must be in the future", http.StatusBadRequest)
-		return
-	}
-
-	if !entry.Start.Before(entry.End) {
-		httpErrorWithLog(r, w, "Start must be before End", http.StatusBadRequest)
+	if err := validateEntry(entry, h.config, r.Context().Value("admin").(bool)); err != nil {
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
-	if entry.End.Sub(entry.Start).Hours() > 24 {
-		httpErrorWithLog(r, w, "Duration may not be too long", http.StatusBadRequest)
-		return
+	// The admin books on behalf of others, thus only the participants are bound to the quotas
+	if !r.Context().Value("admin").(bool) {
+		if err := checkBookingQuotas(h.db, h.config, []CalendarEntryFull{entry}); err != nil {
+			httpProblemWithLog(r, w, err)
+			return
+		}
 	}
 
 	// An admin event does necessarily contain no personal information
-	if entry.AdminEvent != nil {
+	if entry.EventTypeId != nil {
+		if _, err := h.db.GetEventType(*entry.EventTypeId); errors.Is(err, sql.ErrNoRows) {
+			httpProblemWithLog(r, w, ErrUnknownEventType)
+			return
+		} else if err != nil {
+			httpProblemWithLog(r, w, err)
+			return
+		}
+
 		entry.FirstName = ""
 		entry.LastName = ""
 		entry.Email = ""
+		entry.Reminders = false
 	}
 
 	entry.SeriesId = nil
+
+	// The admin may displace the entries of participants instead of conflicting with them
+	if mode := r.URL.Query().Get("override"); mode != "" {
+		if insertedEntries := h.postOverride(w, r, mode, []CalendarEntryFull{entry}, nil); insertedEntries != nil {
+			writeJsonStatus(w, http.StatusCreated, insertedEntries[0])
+		}
+		return
+	}
+
+	// A timeslot offered to the waitlist is reserved for the user it was offered to
+	if offered, err := h.offeredEntries([]CalendarEntryFull{entry}); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	} else if len(offered) > 0 {
+		h.httpConflictWithLog(r, w, ErrTimeslotOffered, offered)
+		return
+	}
+
 	insertEntry, err := h.db.InsertEntry(entry)
 	if err != nil {
 		// This issue can only reasonably occur, if the timeslot is already occupied
-		if err.Error() == "no entry inserted" {
-			httpErrorWithLog(r, w, err.Error(), http.StatusConflict)
+		if errors.Is(err, ErrEntryNotInserted) {
+			h.httpConflictWithLog(r, w, err, []CalendarEntryFull{entry})
 			return
 		}
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
+		return
+	}
+	h.publishEntries(eventCreated, *insertEntry)
+
+	// If the timeslot was freed on short notice before, the volunteers don't need to be alerted anymore
+	if err := h.db.DeleteCoveredPendingAlerts(entry.Start, entry.End); err != nil {
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
@@ -129,71 +194,116 @@ func (h *ApiHandler) PostEntry(w http.ResponseWriter, r *http.Request) {
 
 	// While we theoretically expose more information than a non-admin would be allowed to receive,
 	// they would only receive it for their own POST input, which they know anyway
-	writeJson(w, insertEntry)
-	w.WriteHeader(http.StatusCreated)
+	writeJsonStatus(w, http.StatusCreated, insertEntry)
 }
 
 // PostSeries posts an entire Series, which implies a number of CalendarEntryFull. Therefore, it adheres to the same
-// rules as PostEntry.
+// rules as PostEntry. Usually, the Series is only inserted as a whole, but with the query parameter "skipConflicts" set
+// to "true", the conflicting occurrences are skipped instead and the SeriesResponse reports both.
 func (h *ApiHandler) PostSeries(w http.ResponseWriter, r *http.Request) {
 	var seriesReq SeriesRequest
 	err := json.NewDecoder(r.Body).Decode(&seriesReq)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusBadRequest)
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
 		return
 	}
 
-	if seriesReq.Entry.Start.Before(time.Now()) {
-		httpErrorWithLog(r, w, "Start time must be in the future", http.StatusBadRequest)
+	if err := validateSeriesRequest(seriesReq, h.config, r.Context().Value("admin").(bool)); err != nil {
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
-	if !seriesReq.Entry.Start.Before(seriesReq.Entry.End) {
-		httpErrorWithLog(r, w, "Start must be before End", http.StatusBadRequest)
-		return
-	}
-
-	if seriesReq.Entry.End.Sub(seriesReq.Entry.Start).Hours() > 24 {
-		httpErrorWithLog(r, w, "Duration may not be too long", http.StatusBadRequest)
-		return
-	}
+	if seriesReq.Entry.EventTypeId != nil {
+		if _, err := h.db.GetEventType(*seriesReq.Entry.EventTypeId); errors.Is(err, sql.ErrNoRows) {
+			httpProblemWithLog(r, w, ErrUnknownEventType)
+			return
+		} else if err != nil {
+			httpProblemWithLog(r, w, err)
+			return
+		}
 
-	if seriesReq.Entry.AdminEvent != nil {
 		seriesReq.Entry.FirstName = ""
 		seriesReq.Entry.LastName = ""
 		seriesReq.Entry.Email = ""
+		seriesReq.Entry.Reminders = false
 	}
 
 	// Repeat the given entry according to the series parameters
-	entries := []CalendarEntryFull{seriesReq.Entry}
-	for range seriesReq.Series.Repetitions - 1 {
-		nextEntry := entries[len(entries)-1]
-		if seriesReq.Series.Interval == "weekly" {
-			nextEntry.Start = nextEntry.Start.AddDate(0, 0, 7)
-			nextEntry.End = nextEntry.End.AddDate(0, 0, 7)
-		} else if seriesReq.Series.Interval == "monthly" {
-			nextEntry.Start = nextEntry.Start.AddDate(0, 1, 0)
-			nextEntry.End = nextEntry.End.AddDate(0, 1, 0)
-		} else if seriesReq.Series.Interval == "daily" {
-			nextEntry.Start = nextEntry.Start.AddDate(0, 0, 1)
-			nextEntry.End = nextEntry.End.AddDate(0, 0, 1)
-		} else {
-			httpErrorWithLog(r, w, "Invalid interval", http.StatusBadRequest)
+	entries := repeatEntry(seriesReq.Entry, seriesReq.Series)
+	skipConflicts := r.URL.Query().Get("skipConflicts") == "true"
+
+	// The admin books on behalf of others, thus only the participants are bound to the quotas
+	if !r.Context().Value("admin").(bool) {
+		// When skipping the conflicts, only the occurrences that are actually booked count
+		booked := entries
+		if skipConflicts {
+			if booked, err = h.freeEntries(entries); err != nil {
+				httpProblemWithLog(r, w, err)
+				return
+			}
+		}
+		if err := checkBookingQuotas(h.db, h.config, booked); err != nil {
+			httpProblemWithLog(r, w, err)
 			return
 		}
-		entries = append(entries, nextEntry)
+	}
+
+	// The admin may displace the entries of participants instead of conflicting with them
+	if mode := r.URL.Query().Get("override"); mode != "" {
+		if insertedEntries := h.postOverride(w, r, mode, entries, &seriesReq.Series); insertedEntries != nil {
+			writeJsonStatus(w, http.StatusCreated, insertedEntries)
+		}
+		return
+	}
+
+	// A timeslot offered to the waitlist is reserved for the user it was offered to
+	offered, err := h.offeredEntries(entries)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	// Regulars may rather book only the free occurrences than none at all
+	if skipConflicts {
+		insertedEntries, skippedEntries, err := h.db.InsertSeriesSkippingConflicts(seriesReq.Series, entries, offered)
+		if err != nil {
+			if errors.Is(err, ErrEntryNotInserted) {
+				h.httpConflictWithLog(r, w, err, entries)
+				return
+			}
+			httpProblemWithLog(r, w, err)
+			return
+		}
+		h.publishEntries(eventCreated, insertedEntries...)
+
+		skipped, err := h.findConflicts(skippedEntries)
+		if err != nil {
+			httpProblemWithLog(r, w, err)
+			return
+		}
+
+		writeJsonStatus(w, http.StatusCreated, SeriesResponse{Booked: insertedEntries, Skipped: skipped})
+		return
 	}
 
 	// All the newly created/repeated entries must be free of timeslot conflicts...
+	if len(offered) > 0 {
+		h.httpConflictWithLog(r, w, ErrTimeslotOffered, offered)
+		return
+	}
 	if err := h.db.CheckMultipleTimeslots(entries); err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusConflict)
+		if errors.Is(err, ErrTimeslotOverlap) {
+			h.httpConflictWithLog(r, w, err, entries)
+			return
+		}
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
 	// ...only then can we insert the series...
 	series, err := h.db.InsertSeries(seriesReq.Series)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
@@ -205,115 +315,139 @@ func (h *ApiHandler) PostSeries(w http.ResponseWriter, r *http.Request) {
 		// this is not an issue
 		insertedEntries[i], err = h.db.InsertEntry(entry)
 		if err != nil {
-			httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+			httpProblemWithLog(r, w, err)
 			return
 		}
+		h.publishEntries(eventCreated, *insertedEntries[i])
 	}
 
 	// While we theoretically expose more information than a non-admin would be allowed to receive,
 	// they would only receive it for their own POST input, which they know anyway
-	writeJson(w, insertedEntries)
-	w.WriteHeader(http.StatusCreated)
+	writeJsonStatus(w, http.StatusCreated, insertedEntries)
 }
 
 // DeleteEntry deletes a CalendarEntry, given that the user is either admin or provided the correct email address.
+// Participants may neither cancel past entries nor those starting within the configured cancellation cutoff, and
+// have to give a reason via query parameter "reason" for cancelling within the alert window.
 //
-// Additionally, if this entry is on short notice (<3 days), volunteers will be informed via an automated message.
-// However, this feature must be activated.
+// The freed timeslot is first handed to its waitlist according to the configured waitlist policy. Additionally, the
+// volunteers will be informed via an automated message at the first stage of the configured escalation policy, given
+// that the timeslot is still free after the configured grace period and not offered to the waitlist. Should it stay
+// free, the alert is escalated along the further stages.
 func (h *ApiHandler) DeleteEntry(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusBadRequest)
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
 		return
 	}
 
 	entry, err := h.db.GetEntry(id)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusNotFound)
+		httpProblemWithLog(r, w, err)
+		return
 	}
 
+	// Participants have to explain cancellations on short notice, which are recorded for the coordinators
+	var late []CalendarEntryFull
+	reason := r.URL.Query().Get("reason")
 	if r.Context().Value("admin").(bool) {
 		err = h.db.DeleteEntryAdmin(id)
-	} else {
-		email := r.URL.Query().Get("email")
-		err = h.db.DeleteEntry(id, email)
+	} else if err = h.checkCancellation(entry.Start); err == nil {
+		if late, err = h.lateEntries(*entry); err == nil {
+			if err = checkCancellationReason(late, reason); err == nil {
+				email := r.URL.Query().Get("email")
+				err = h.db.DeleteEntry(id, email)
+			}
+		}
 	}
 
 	if err != nil {
-		if err.Error() == "no entry deleted" {
-			httpErrorWithLog(r, w, err.Error(), http.StatusNotFound)
-			return
-		}
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
-	emails, err := h.db.GetVolunteerEmails()
-	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+	h.publishEntries(eventDeleted, CalendarEntryFull{CalendarEntry: *entry})
+
+	if err := h.recordLateCancellations(r, late, reason); err != nil {
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
-	now := time.Now()
-	threeDaysFromNow := now.AddDate(0, 0, 3)
+	if err := h.offerToWaitlist(*entry); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
 
-	if entry.Start.After(now) && entry.Start.Before(threeDaysFromNow) {
-		err := sendNotificationEmail(emails, entry.Start, entry.End)
-		if err != nil {
-			httpErrorWithLog(r, w, err.Error(), http.StatusServiceUnavailable)
-			return
-		}
+	if err := h.queueCancellationAlerts(*entry); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// DeleteSeries deletes both a Series and its associated CalendarEntry, working otherwise the same as DeleteEntry.
+// DeleteSeries deletes both a Series and its associated CalendarEntry, working otherwise the same as DeleteEntry. For
+// participants, only the occurrences after the cancellation cutoff are deleted, while the earlier ones remain.
 func (h *ApiHandler) DeleteSeries(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusBadRequest)
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
 		return
 	}
 
 	entries, err := h.db.GetSeriesEntries(id)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
+		return
 	}
 
+	var late []CalendarEntryFull
+	reason := r.URL.Query().Get("reason")
 	if r.Context().Value("admin").(bool) {
 		err = h.db.DeleteSeriesAdmin(id)
 	} else {
-		email := r.URL.Query().Get("email")
-		err = h.db.DeleteSeries(id, email)
+		// Participants may only cancel the occurrences that are neither past nor within the cancellation cutoff
+		deadline := h.cancellationDeadline(time.Now())
+		cancellable := make([]CalendarEntry, 0, len(entries))
+		for _, entry := range entries {
+			if entry.Start.After(deadline) {
+				cancellable = append(cancellable, entry)
+			}
+		}
+
+		if len(cancellable) == 0 && len(entries) > 0 {
+			err = h.checkCancellation(entries[len(entries)-1].Start)
+		} else if late, err = h.lateEntries(cancellable...); err == nil {
+			if err = checkCancellationReason(late, reason); err == nil {
+				email := r.URL.Query().Get("email")
+				err = h.db.DeleteSeries(id, email, deadline)
+				entries = cancellable
+			}
+		}
 	}
 
 	if err != nil {
-		if err.Error() == "no entry deleted" {
-			httpErrorWithLog(r, w, err.Error(), http.StatusNotFound)
-			return
-		}
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
-	emails, err := h.db.GetVolunteerEmails()
-	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+	for _, entry := range entries {
+		h.publishEntries(eventDeleted, CalendarEntryFull{CalendarEntry: entry})
+	}
+
+	if err := h.recordLateCancellations(r, late, reason); err != nil {
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
-	now := time.Now()
-	threeDaysFromNow := now.AddDate(0, 0, 3)
+	if err := h.offerToWaitlist(entries...); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
 
-	for _, entry := range entries {
-		if entry.Start.After(now) && entry.Start.Before(threeDaysFromNow) {
-			err := sendNotificationEmail(emails, entry.Start, entry.End)
-			if err != nil {
-				httpErrorWithLog(r, w, err.Error(), http.StatusServiceUnavailable)
-				return
-			}
-		}
+	if err := h.queueCancellationAlerts(entries...); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
@@ -322,7 +456,7 @@ func (h *ApiHandler) DeleteSeries(w http.ResponseWriter, r *http.Request) {
 // DeleteUserData deletes all CalendarEntry associated with the given user data.
 func (h *ApiHandler) DeleteUserData(w http.ResponseWriter, r *http.Request) {
 	if !r.Context().Value("admin").(bool) {
-		httpErrorWithLog(r, w, "Forbidden", http.StatusForbidden)
+		httpProblemWithLog(r, w, ErrAdminRequired)
 		return
 	}
 
@@ -330,11 +464,29 @@ func (h *ApiHandler) DeleteUserData(w http.ResponseWriter, r *http.Request) {
 	lastname := r.URL.Query().Get("lastname")
 	email := r.URL.Query().Get("email")
 
-	err := h.db.DeleteUserInformation(firstname, lastname, email)
+	entries, err := h.db.GetUserEntries(firstname, lastname, email)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	err = h.db.DeleteUserInformation(firstname, lastname, email)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
+
+	// Mirror DeleteUserInformation, i.e., the future entries are gone, while the past ones are anonymized
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.Start.After(now) {
+			h.publishEntries(eventDeleted, entry)
+			continue
+		}
+		entry.FirstName, entry.LastName, entry.Email = "---", "---", "---"
+		h.publishEntries(eventUpdated, entry)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -344,23 +496,23 @@ func (h *ApiHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var login security.AdminData
 	err := json.NewDecoder(r.Body).Decode(&login)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusBadRequest)
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
 		return
 	}
 
 	if login.Username != h.admin.Username || login.Password != h.admin.Password {
-		httpErrorWithLog(r, w, "Invalid login", http.StatusUnauthorized)
+		httpProblemWithLog(r, w, ErrInvalidLogin)
 		return
 	}
 
 	refreshToken, err := security.CreateRefreshToken()
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 	accessToken, err := security.CreateAccessToken()
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
@@ -384,25 +536,25 @@ func (h *ApiHandler) Login(w http.ResponseWriter, r *http.Request) {
 func (h *ApiHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("pray_calendar-refresh_token")
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusBadRequest)
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
 		return
 	}
 	token := cookie.Value
 
 	_, err = security.ValidateRefreshToken(token)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusUnauthorized)
+		httpProblemWithLog(r, w, ErrInvalidToken.Wrap(err))
 		return
 	}
 
 	refreshToken, err := security.CreateRefreshToken()
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 	accessToken, err := security.CreateAccessToken()
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
@@ -435,13 +587,13 @@ func (h *ApiHandler) DownloadEmails(w http.ResponseWriter, r *http.Request) {
 
 	rows, err := h.db.GetEmails(interval)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
 	headers := []string{"Email", "Vorname", "Nachname", "Letztes Datum", "Anzahl an Einträgen"}
 	if err := writer.Write(headers); err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
@@ -458,21 +610,22 @@ func (h *ApiHandler) DownloadEmails(w http.ResponseWriter, r *http.Request) {
 // the email address' validity, simply by someone providing it, we send a confirmation email.
 func (h *ApiHandler) PostVolunteerRegistration(w http.ResponseWriter, r *http.Request) {
 	email := r.URL.Query().Get("email")
-	if !isValidEmail(email) {
-		httpErrorWithLog(r, w, "Email is not well formed", http.StatusBadRequest)
+	if err := validateVolunteerEmail(email); err != nil {
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
 	volunteer, err := h.db.CreateVolunteer(email)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
 	confirmationLink := fmt.Sprintf("%s/api/volunteer/confirmation?email=%s&token=%s", os.Getenv("HOST_BE"), email, volunteer.ConfirmationToken)
 
 	if err = sendConfirmationEmail(email, confirmationLink); err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusServiceUnavailable)
+		httpProblemWithLog(r, w, ErrEmailNotSent.Wrap(err))
+		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
@@ -482,8 +635,8 @@ func (h *ApiHandler) PostVolunteerRegistration(w http.ResponseWriter, r *http.Re
 // messages. This method is supposed to be directly accessed via a link in an email, thus it contains some simple feedback.
 func (h *ApiHandler) GetVolunteerConfirmation(w http.ResponseWriter, r *http.Request) {
 	email := r.URL.Query().Get("email")
-	if !isValidEmail(email) {
-		httpErrorWithLog(r, w, "Email is not well formed", http.StatusBadRequest)
+	if err := validateVolunteerEmail(email); err != nil {
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
@@ -491,7 +644,7 @@ func (h *ApiHandler) GetVolunteerConfirmation(w http.ResponseWriter, r *http.Req
 
 	err := h.db.ConfirmVolunteer(email, token)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
@@ -505,14 +658,14 @@ func (h *ApiHandler) GetVolunteerConfirmation(w http.ResponseWriter, r *http.Req
 // DeleteVolunteer removes a volunteer's email address and prevents automated messages.
 func (h *ApiHandler) DeleteVolunteer(w http.ResponseWriter, r *http.Request) {
 	email := r.URL.Query().Get("email")
-	if !isValidEmail(email) {
-		httpErrorWithLog(r, w, "Email is not well formed", http.StatusBadRequest)
+	if err := validateVolunteerEmail(email); err != nil {
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
 	err := h.db.DeleteVolunteer(email)
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
@@ -530,13 +683,13 @@ func (h *ApiHandler) DownloadVolunteerEmails(w http.ResponseWriter, r *http.Requ
 
 	emails, err := h.db.GetVolunteerEmails()
 	if err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
 	headers := []string{"Email"}
 	if err := writer.Write(headers); err != nil {
-		httpErrorWithLog(r, w, err.Error(), http.StatusInternalServerError)
+		httpProblemWithLog(r, w, err)
 		return
 	}
 
@@ -555,11 +708,19 @@ func (h *ApiHandler) DownloadVolunteerEmails(w http.ResponseWriter, r *http.Requ
 
 // writeJson is a utility method to simply return any struct as a JSON string
 func writeJson(w http.ResponseWriter, data any) {
+	writeJsonStatus(w, http.StatusOK, data)
+}
+
+// writeJsonStatus is the same as writeJson, but with the given status code. As the status can't be changed anymore
+// once the body is written, it has to be used instead of calling WriteHeader after writeJson.
+func writeJsonStatus(w http.ResponseWriter, status int, data any) {
 	b, err := json.Marshal(data)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	_, err = w.Write(b)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -572,9 +733,24 @@ func isValidEmail(email string) bool {
 	return emailRegex.MatchString(email)
 }
 
-// httpErrorWithLog is a utility method to automatically log an error before returning it to the caller
-func httpErrorWithLog(r *http.Request, w http.ResponseWriter, error string, code int) {
-	logger := httplog.LogEntry(r.Context())
-	logger.Error(error)
-	http.Error(w, error, code)
+// seriesSteps maps each valid interval of a Series to the years, months and days between its occurrences
+var seriesSteps = map[string][3]int{
+	"daily":   {0, 0, 1},
+	"weekly":  {0, 0, 7},
+	"monthly": {0, 1, 0},
+}
+
+// repeatEntry is a utility method to expand the first CalendarEntryFull of a Series into all its occurrences. The
+// interval of the Series must be valid.
+func repeatEntry(entry CalendarEntryFull, series Series) []CalendarEntryFull {
+	step := seriesSteps[series.Interval]
+	entries := []CalendarEntryFull{entry}
+	for range series.Repetitions - 1 {
+		nextEntry := entries[len(entries)-1]
+		nextEntry.Start = nextEntry.Start.AddDate(step[0], step[1], step[2])
+		nextEntry.End = nextEntry.End.AddDate(step[0], step[1], step[2])
+		entries = append(entries, nextEntry)
+	}
+
+	return entries
 }
@@ -0,0 +1,104 @@
+// Provides the late cancellations, i.e., entries cancelled by participants on short notice, which require a reason
+// and are recorded for the coordinators
+
+package app
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/httplog/v2"
+)
+
+// lateEntries fetches the CalendarEntryFull of the given entries that would be cancelled on short notice, i.e., that
+// start within the alert window, as their owners are recorded.
+func (h *ApiHandler) lateEntries(entries ...CalendarEntry) ([]CalendarEntryFull, error) {
+	now := time.Now()
+	windowEnd := now.Add(h.config.AlertWindow)
+
+	late := make([]CalendarEntryFull, 0)
+	for _, entry := range entries {
+		if !entry.Start.After(now) || !entry.Start.Before(windowEnd) {
+			continue
+		}
+
+		fullEntry, err := h.db.GetFullEntry(entry.Id)
+		if err != nil {
+			return nil, err
+		}
+		late = append(late, *fullEntry)
+	}
+
+	return late, nil
+}
+
+// checkCancellationReason ensures that a reason is given, if any of the entries is cancelled on short notice.
+func checkCancellationReason(late []CalendarEntryFull, reason string) error {
+	if len(late) > 0 && strings.TrimSpace(reason) == "" {
+		return ErrReasonRequired
+	}
+
+	return nil
+}
+
+// recordLateCancellations records the given entries cancelled on short notice and informs the coordinators about them.
+// As the entries are already deleted, a failing email is only logged.
+func (h *ApiHandler) recordLateCancellations(r *http.Request, late []CalendarEntryFull, reason string) error {
+	if len(late) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	cancellations := make([]LateCancellation, len(late))
+	for i, entry := range late {
+		cancellations[i] = LateCancellation{
+			EntryId:     entry.Id,
+			FirstName:   entry.FirstName,
+			LastName:    entry.LastName,
+			Email:       entry.Email,
+			Start:       entry.Start,
+			End:         entry.End,
+			Reason:      strings.TrimSpace(reason),
+			CancelledAt: now,
+		}
+	}
+
+	if err := h.db.InsertLateCancellations(cancellations); err != nil {
+		return err
+	}
+
+	if len(h.config.CoordinatorEmails) > 0 {
+		if err := sendLateCancellationEmail(h.config.CoordinatorEmails, cancellations); err != nil {
+			httplog.LogEntry(r.Context()).Error(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// GetLateCancellations provides the LateCancellationHistory of each participant, or only of the one given via the
+// query parameter "email".
+func (h *ApiHandler) GetLateCancellations(w http.ResponseWriter, r *http.Request) {
+	cancellations, err := h.db.GetLateCancellations(r.URL.Query().Get("email"))
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	// The cancellations are sorted by participant, so each history is a contiguous run
+	histories := make([]LateCancellationHistory, 0)
+	for _, c := range cancellations {
+		if len(histories) == 0 || histories[len(histories)-1].Email != c.Email {
+			histories = append(histories, LateCancellationHistory{Email: c.Email, Cancellations: make([]LateCancellation, 0)})
+		}
+		history := &histories[len(histories)-1]
+		// The latest name is the most accurate one
+		history.FirstName = c.FirstName
+		history.LastName = c.LastName
+		history.Count++
+		history.Cancellations = append(history.Cancellations, c)
+	}
+
+	writeJson(w, histories)
+}
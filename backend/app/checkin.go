@@ -0,0 +1,273 @@
+// Provides the check-in of participants at the start of their timeslot and the alerts for missed check-ins
+
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/Sakrafux/pray-calendar/backend/security"
+	"github.com/go-chi/httplog/v2"
+)
+
+// checkInEarly is how long before the start of an entry the participant may already check in
+const checkInEarly = 15 * time.Minute
+
+// createCheckInLink creates the personal check-in link of an entry, which is valid until the entry ends.
+func createCheckInLink(entryId int, end time.Time) (string, error) {
+	token, err := security.CreateCheckInToken(entryId, end)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/api/checkin?token=%s", os.Getenv("HOST_BE"), url.QueryEscape(token)), nil
+}
+
+// createChapelCheckInLink creates the link for the QR code displayed in the chapel.
+func createChapelCheckInLink(key string) string {
+	return fmt.Sprintf("%s/api/checkin/chapel?key=%s", os.Getenv("HOST_BE"), url.QueryEscape(key))
+}
+
+// checkInLinkEntry fetches the entry of the given token of a personal check-in link. It returns nil, if the response
+// was already written.
+func (h *ApiHandler) checkInLinkEntry(w http.ResponseWriter, r *http.Request, token string) *CalendarEntryFull {
+	logger := httplog.LogEntry(r.Context())
+
+	// An expired token implies that the entry is already over
+	entryId, err := security.ValidateCheckInToken(token)
+	if err != nil {
+		logger.Warn(err.Error())
+		writeHtmlPage(w, http.StatusGone, "Link abgelaufen", "Dieser Link ist ungültig oder der Timeslot ist bereits vorbei.")
+		return nil
+	}
+
+	entry, err := h.db.GetFullEntry(entryId)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeHtmlPage(w, http.StatusNotFound, "Eintrag nicht gefunden", "Dieser Eintrag existiert nicht mehr.")
+		return nil
+	} else if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return nil
+	}
+
+	return entry
+}
+
+// GetCheckIn provides the page for the participant to confirm the check-in via their personal check-in link. The
+// attendance is only recorded via PostCheckIn, as mail scanners and link prefetchers open links on their own. This
+// method is supposed to be directly accessed via a link, thus it responds with simple HTML pages.
+func (h *ApiHandler) GetCheckIn(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	entry := h.checkInLinkEntry(w, r, token)
+	if entry == nil {
+		return
+	}
+
+	writeConfirmPage(w, "Check-in",
+		fmt.Sprintf("Möchtest du für den Timeslot am %s für %s bis %s einchecken?", entry.Start.Format("02.01.2006"),
+			entry.Start.Format("15:04"), entry.End.Format("15:04")),
+		os.Getenv("PATH_PREFIX")+"/api/checkin", map[string]string{"token": token}, "Einchecken")
+}
+
+// PostCheckIn checks in the participant of the entry, whose personal check-in link was confirmed via GetCheckIn.
+func (h *ApiHandler) PostCheckIn(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeHtmlPage(w, http.StatusBadRequest, "Fehler", "Die Eingabe konnte nicht verarbeitet werden.")
+		return
+	}
+
+	if entry := h.checkInLinkEntry(w, r, r.PostForm.Get("token")); entry != nil {
+		h.checkIn(w, r, entry, "link")
+	}
+}
+
+// GetChapelCheckIn provides the check-in form linked by the QR code in the chapel, which is protected by a key, so
+// nobody can check in from elsewhere by simply guessing the address.
+func (h *ApiHandler) GetChapelCheckIn(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if !h.isValidChapelKey(key) {
+		writeHtmlPage(w, http.StatusForbidden, "Keine Berechtigung", "Bitte scanne den QR-Code in der Kapelle.")
+		return
+	}
+
+	writeCheckInForm(w, fmt.Sprintf("%s/api/checkin/chapel", os.Getenv("PATH_PREFIX")), key)
+}
+
+// PostChapelCheckIn checks in the participant, whose entry is currently running, based on the email address entered
+// in the form of GetChapelCheckIn.
+func (h *ApiHandler) PostChapelCheckIn(w http.ResponseWriter, r *http.Request) {
+	logger := httplog.LogEntry(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		writeHtmlPage(w, http.StatusBadRequest, "Fehler", "Die Eingabe konnte nicht verarbeitet werden.")
+		return
+	}
+	if !h.isValidChapelKey(r.PostForm.Get("key")) {
+		writeHtmlPage(w, http.StatusForbidden, "Keine Berechtigung", "Bitte scanne den QR-Code in der Kapelle.")
+		return
+	}
+
+	entry, err := h.db.GetCurrentEntryForEmail(r.PostForm.Get("email"), time.Now(), checkInEarly)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeHtmlPage(w, http.StatusNotFound, "Kein Eintrag gefunden",
+			"Für diese E-Mail-Adresse gibt es gerade keinen Eintrag. Bitte prüfe deine Eingabe.")
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	h.checkIn(w, r, entry, "chapel")
+}
+
+// checkIn records the attendance of an entry, given that it is currently running, and responds with a feedback page.
+func (h *ApiHandler) checkIn(w http.ResponseWriter, r *http.Request, entry *CalendarEntryFull, method string) {
+	now := time.Now()
+	dateStr := entry.Start.Format("02.01.2006")
+	startTimeStr := entry.Start.Format("15:04")
+	endTimeStr := entry.End.Format("15:04")
+
+	if entry.Start.Add(-checkInEarly).After(now) {
+		writeHtmlPage(w, http.StatusConflict, "Zu früh",
+			fmt.Sprintf("Der Check-in für den Timeslot am %s für %s bis %s ist erst kurz vor Beginn möglich.", dateStr, startTimeStr, endTimeStr))
+		return
+	}
+	if !entry.End.After(now) {
+		writeHtmlPage(w, http.StatusGone, "Timeslot vorbei", "Dieser Timeslot ist bereits vorbei.")
+		return
+	}
+
+	if err := h.db.CheckIn(entry.Id, now, method); err != nil {
+		httplog.LogEntry(r.Context()).Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	writeHtmlPage(w, http.StatusOK, "Eingecheckt",
+		fmt.Sprintf("Danke, dass du da bist! Du bist für den Timeslot am %s für %s bis %s eingecheckt.", dateStr, startTimeStr, endTimeStr))
+}
+
+// isValidChapelKey checks the key of the chapel QR code in constant time. Without a configured key, the chapel
+// check-in is disabled.
+func (h *ApiHandler) isValidChapelKey(key string) bool {
+	return h.config.ChapelCheckInKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(h.config.ChapelCheckInKey)) == 1
+}
+
+// AttendanceReport is purely a response REST-DTO, summarizing the attendance within a date range.
+type AttendanceReport struct {
+	Entries   []Attendance
+	CheckedIn int
+	Missed    int
+	// Rate is the percentage of checked in entries among those that are due, i.e., excluding future entries
+	Rate float64
+}
+
+// GetAttendance provides the AttendanceReport for the date range given via the query parameters "from" and "to"
+// (both inclusive).
+func (h *ApiHandler) GetAttendance(w http.ResponseWriter, r *http.Request) {
+	from, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+	to, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	records, err := h.db.GetAttendance(from, to.AddDate(0, 0, 1))
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	report := AttendanceReport{Entries: records}
+	deadline := time.Now().Add(-h.config.CheckInDelay)
+	for _, record := range records {
+		if record.CheckedInAt != nil {
+			report.CheckedIn++
+		} else if !record.Start.After(deadline) {
+			report.Missed++
+		}
+	}
+	if due := report.CheckedIn + report.Missed; due > 0 {
+		report.Rate = float64(report.CheckedIn*1000/due) / 10
+	}
+
+	writeJson(w, report)
+}
+
+// CheckInMonitor raises alerts for participants, who did not check in within the configured delay after the start
+// of their entry. The coordinators and the next participant, who might be able to come earlier, are informed. It is
+// supposed to be run periodically as a job of the scheduler.
+type CheckInMonitor struct {
+	db     *DBHandler
+	config *Config
+}
+
+// NewCheckInMonitor is the constructor for CheckInMonitor.
+func NewCheckInMonitor(db *DBHandler, config *Config) *CheckInMonitor {
+	return &CheckInMonitor{db: db, config: config}
+}
+
+// Run checks for missed check-ins and conforms to scheduler.JobFunc.
+func (m *CheckInMonitor) Run(_ context.Context, _ string) error {
+	now := time.Now()
+
+	entries, err := m.db.GetMissedCheckIns(now, now.Add(-m.config.CheckInDelay), m.config.ChapelCheckInKey != "")
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	volunteerEmails, err := m.db.GetVolunteerEmails()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		// The alert is only raised once, even if sending fails, as it is only relevant right now
+		if err := m.db.MarkMissedCheckIn(entry.Id, now); err != nil {
+			log.Println("[checkin] Failed to mark missed check-in:", err)
+			continue
+		}
+
+		if len(m.config.CoordinatorEmails) > 0 {
+			participant := fmt.Sprintf("%s %s (%s)", entry.FirstName, entry.LastName, entry.Email)
+			if err := sendMissedCheckInEmail(m.config.CoordinatorEmails, entry.Start, entry.End, participant); err != nil {
+				log.Println("[checkin] Failed to send missed check-in email to coordinators:", err)
+			}
+		}
+
+		// Emails must only be sent to consenting addresses, so the next participant is only informed, if they are a
+		// confirmed volunteer
+		next, err := m.db.GetNextParticipantEntry(entry.Start.Add(time.Nanosecond))
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		} else if err != nil {
+			log.Println("[checkin] Failed to query next participant:", err)
+			continue
+		}
+		if next.Email != entry.Email && slices.Contains(volunteerEmails, next.Email) {
+			if err := sendMissedCheckInEmail([]string{next.Email}, entry.Start, entry.End, ""); err != nil {
+				log.Println("[checkin] Failed to send missed check-in email to next participant:", err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+// Provides the one-click booking of freed timeslots via the signed claim links of the notification emails
+
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Sakrafux/pray-calendar/backend/security"
+	"github.com/go-chi/httplog/v2"
+)
+
+// createClaimLinks creates a personal claim link for the given timeslot for each of the given email addresses.
+func createClaimLinks(emails []string, start, end time.Time) (map[string]string, error) {
+	links := make(map[string]string, len(emails))
+	for _, email := range emails {
+		token, err := security.CreateClaimToken(security.SlotClaim{Email: email, Start: start, End: end})
+		if err != nil {
+			return nil, err
+		}
+		links[email] = fmt.Sprintf("%s/api/calendar/claim?token=%s", os.Getenv("HOST_BE"), url.QueryEscape(token))
+	}
+
+	return links, nil
+}
+
+// GetSlotClaim provides the page for a volunteer to confirm claiming the freed timeslot of their signed claim link.
+// The timeslot is only booked via PostSlotClaim, as mail scanners and link prefetchers open links on their own.
+// This method is supposed to be directly accessed via a link in an email, thus it responds with simple HTML pages.
+func (h *ApiHandler) GetSlotClaim(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	claim, err := security.ValidateClaimToken(token)
+	if err != nil {
+		httplog.LogEntry(r.Context()).Warn(err.Error())
+		writeHtmlPage(w, http.StatusGone, "Link abgelaufen",
+			"Dieser Link ist leider ungültig oder nicht mehr gültig, da der Timeslot bereits vorbei ist.")
+		return
+	}
+
+	writeConfirmPage(w, "Timeslot übernehmen",
+		fmt.Sprintf("Möchtest du den Timeslot am %s für %s bis %s übernehmen?",
+			claim.Start.Format("02.01.2006"), claim.Start.Format("15:04"), claim.End.Format("15:04")),
+		os.Getenv("PATH_PREFIX")+"/api/calendar/claim", map[string]string{"token": token}, "Übernehmen")
+}
+
+// PostSlotClaim books a freed timeslot for the volunteer whose signed claim link was confirmed via GetSlotClaim. As
+// every volunteer receives their own link, whoever responds first gets the timeslot, while everyone else is informed
+// that it was already taken. If the timeslot already started, only its remainder is booked. The quotas don't apply, as
+// the volunteers are explicitly asked to step in for a cancellation on short notice.
+func (h *ApiHandler) PostSlotClaim(w http.ResponseWriter, r *http.Request) {
+	logger := httplog.LogEntry(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		writeHtmlPage(w, http.StatusBadRequest, "Fehler", "Die Eingabe konnte nicht verarbeitet werden.")
+		return
+	}
+
+	// An expired token implies that the timeslot is already over
+	claim, err := security.ValidateClaimToken(r.PostForm.Get("token"))
+	if err != nil {
+		logger.Warn(err.Error())
+		writeHtmlPage(w, http.StatusGone, "Link abgelaufen",
+			"Dieser Link ist leider ungültig oder nicht mehr gültig, da der Timeslot bereits vorbei ist.")
+		return
+	}
+	if now := time.Now().Truncate(time.Minute).UTC(); claim.Start.Before(now) {
+		claim.Start = now
+	}
+
+	// Only volunteers that are still registered are eligible to claim the timeslot
+	volunteerEmails, err := h.db.GetVolunteerEmails()
+	if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+	if !slices.Contains(volunteerEmails, claim.Email) {
+		writeHtmlPage(w, http.StatusForbidden, "Keine Berechtigung",
+			"Deine E-Mail-Adresse ist nicht mehr für Benachrichtigungen registriert.")
+		return
+	}
+
+	// Volunteers are only known by their email address, so we reuse the name of their most recent entry. If there is
+	// none, the local part of the email address has to suffice.
+	firstname, lastname, err := h.db.GetLatestNameForEmail(claim.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		firstname, _, _ = strings.Cut(claim.Email, "@")
+	} else if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	entry := CalendarEntryFull{
+		CalendarEntry: CalendarEntry{FirstName: firstname, Start: claim.Start, End: claim.End},
+		LastName:      lastname,
+		Email:         claim.Email,
+	}
+
+	dateStr := claim.Start.Format("02.01.2006")
+	startTimeStr := claim.Start.Format("15:04")
+	endTimeStr := claim.End.Format("15:04")
+
+	// A timeslot offered to the waitlist meanwhile is reserved for the user it was offered to
+	if offered, err := h.offeredEntries([]CalendarEntryFull{entry}); err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	} else if len(offered) > 0 {
+		writeHtmlPage(w, http.StatusConflict, "Bereits vergeben",
+			fmt.Sprintf("Der Timeslot am %s für %s bis %s ist gerade jemandem von der Warteliste angeboten. Vielen Dank trotzdem für deine Bereitschaft!", dateStr, startTimeStr, endTimeStr))
+		return
+	}
+
+	// The insert only succeeds if the timeslot is still free, which makes concurrent claims safe
+	insertedEntry, err := h.db.InsertEntry(entry)
+	if err != nil {
+		if !errors.Is(err, ErrEntryNotInserted) {
+			logger.Error(err.Error())
+			writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+			return
+		}
+
+		// Confirming twice should not be reported as a failure. As the start of a running timeslot moves with every
+		// attempt, any entry of the volunteer within it counts.
+		if owned, err := h.db.GetEmailEntriesForRange(claim.Email, claim.Start, claim.End); err == nil && len(owned) > 0 {
+			writeHtmlPage(w, http.StatusOK, "Timeslot übernommen",
+				fmt.Sprintf("Du hast den Timeslot am %s für %s bis %s bereits übernommen.", dateStr, startTimeStr, endTimeStr))
+			return
+		}
+
+		writeHtmlPage(w, http.StatusConflict, "Bereits vergeben",
+			fmt.Sprintf("Jemand anderes war leider schneller und hat den Timeslot am %s für %s bis %s bereits übernommen. Vielen Dank trotzdem für deine Bereitschaft!", dateStr, startTimeStr, endTimeStr))
+		return
+	}
+	h.publishEntries(eventCreated, *insertedEntry)
+
+	if err := h.db.DeleteCoveredPendingAlerts(entry.Start, entry.End); err != nil {
+		logger.Warn(err.Error())
+	}
+
+	// As the claimant is a confirmed volunteer, they receive the usual confirmation email
+	if err := sendEntryConfirmationEmail(entry.Email, entry.Start, entry.End); err != nil {
+		logger.Warn("Failed to send email confirmation for email " + entry.Email + " with error: " + err.Error())
+	}
+
+	writeHtmlPage(w, http.StatusCreated, "Timeslot übernommen",
+		fmt.Sprintf("Vielen Dank! Du hast den Timeslot am %s für %s bis %s übernommen.", dateStr, startTimeStr, endTimeStr))
+}
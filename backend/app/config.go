@@ -0,0 +1,352 @@
+// Provides the configuration of the application, which is read from the environment
+
+package app
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config bundles all the tunable parameters of the application. It is read once on startup and then distributed
+// alongside the DBHandler into the components requiring it.
+type Config struct {
+	// Timezone is the timezone the application actually operates in, e.g. for evaluating schedules of jobs
+	Timezone *time.Location
+	// AlertWindow is the time before the start of a CalendarEntry in which its deletion is considered short notice,
+	// thus requiring a reason from the participant. The volunteers are alerted according to the EscalationStages.
+	AlertWindow time.Duration
+	// AlertGracePeriod is the time a freed timeslot has to remain empty before the volunteers are actually informed.
+	// This prevents needless alerts if e.g. a user simply corrects their entry.
+	AlertGracePeriod time.Duration
+	// ReminderOffsets are the times before the start of a CalendarEntry at which its owner is reminded of it
+	ReminderOffsets []time.Duration
+	// NightStartHour and NightEndHour delimit the night, which is reported separately for the coverage, as the night
+	// hours are the hardest to fill
+	NightStartHour int
+	NightEndHour   int
+	// DigestSchedule is the cron expression at which the digest of uncovered timeslots is sent to the volunteers
+	DigestSchedule string
+	// DigestDays is the number of days, starting today, that the digest covers
+	DigestDays int
+	// EscalationStages is the escalation policy of the calendar for freed timeslots, sorted from the earliest to the
+	// latest stage
+	EscalationStages []EscalationStage
+	// CoordinatorEmails and CoordinatorPhones are the contacts of the coordinators, who are responsible for filling
+	// empty timeslots as a last resort
+	CoordinatorEmails []string
+	CoordinatorPhones []string
+	// WebhookUrl is the optional endpoint for the "webhook" escalation channel
+	WebhookUrl string
+	// SmsGatewayUrl and SmsGatewayToken configure the optional HTTP gateway for the "sms" escalation channel
+	SmsGatewayUrl   string
+	SmsGatewayToken string
+	// CheckInDelay is the time after the start of a CalendarEntry after which a missing check-in raises an alert
+	CheckInDelay time.Duration
+	// ChapelCheckInKey protects the check-in page linked by the QR code displayed in the chapel
+	ChapelCheckInKey string
+	// KioskNameDisplay decides how participants are shown on the kiosk screen, i.e., either "first" for the first
+	// name, "initials" for its initial, or "anonymous"
+	KioskNameDisplay string
+	// KioskRefreshInterval is how often the kiosk screen is updated, as the current timeslot changes with time alone
+	KioskRefreshInterval time.Duration
+	// WaitlistPolicy decides what happens to the first user on the waitlist of a freed timeslot, i.e., either "offer"
+	// to send them a claim link, or "assign" to book the timeslot for them directly
+	WaitlistPolicy string
+	// WaitlistOfferTimeout is how long an offered timeslot is reserved for the user on the waitlist, before it is
+	// offered to the next one, or eventually to all volunteers
+	WaitlistOfferTimeout time.Duration
+	// SlotTemplates define the slot grid of the calendar per time of day, which every CalendarEntry must be aligned
+	// to. They are sorted and cover the whole day without gaps.
+	SlotTemplates []SlotTemplate
+	// BookingHorizon is how far into the future participants may book, while the admin may plan without limit
+	BookingHorizon time.Duration
+	// MaxHoursPerDay and MaxHoursPerWeek limit how many hours a single participant, identified by their email, may book
+	// per day and per week starting on Monday. Zero means unlimited.
+	MaxHoursPerDay  int
+	MaxHoursPerWeek int
+	// CancellationCutoff is the minimum time before the start of a CalendarEntry, after which participants may no
+	// longer cancel it. Past entries can't be cancelled by participants in any case.
+	CancellationCutoff time.Duration
+	// MaxSeriesRepetitions is the maximum number of occurrences of a single Series
+	MaxSeriesRepetitions int
+}
+
+// EscalationStage is a single stage of the escalation policy for an empty timeslot. It is reached at a given time
+// before the start of the timeslot, and notifies an audience, i.e., either "volunteers" or "coordinators", via the
+// given channels, i.e., "email", "sms" or "webhook".
+type EscalationStage struct {
+	Before   time.Duration
+	Audience string
+	Channels []string
+}
+
+// SlotTemplate is the slot grid for the part of the day between From and To, given as offsets from midnight. The
+// slots are of the given Length, and an entry starting within the template must last between MinDuration and
+// MaxDuration.
+type SlotTemplate struct {
+	From        time.Duration
+	To          time.Duration
+	Length      time.Duration
+	MinDuration time.Duration
+	MaxDuration time.Duration
+}
+
+// LoadConfig reads the Config from the environment, falling back to sensible defaults for missing values.
+func LoadConfig() *Config {
+	return &Config{
+		Timezone:         locationFromEnv("TIMEZONE", "Europe/Vienna"),
+		AlertWindow:      durationFromEnv("ALERT_WINDOW", 72*time.Hour),
+		AlertGracePeriod: durationFromEnv("ALERT_GRACE_PERIOD", 10*time.Minute),
+		ReminderOffsets:  durationsFromEnv("REMINDER_OFFSETS", []time.Duration{24 * time.Hour, 2 * time.Hour}),
+		NightStartHour:   intFromEnv("NIGHT_START_HOUR", 22),
+		NightEndHour:     intFromEnv("NIGHT_END_HOUR", 6),
+		DigestSchedule:   stringFromEnv("DIGEST_SCHEDULE", "0 18 * * *"),
+		DigestDays:       intFromEnv("DIGEST_DAYS", 7),
+		EscalationStages: escalationStagesFromEnv("ESCALATION_STAGES", []EscalationStage{
+			{Before: 48 * time.Hour, Audience: "volunteers", Channels: []string{"email"}},
+			{Before: 12 * time.Hour, Audience: "volunteers", Channels: []string{"email"}},
+			{Before: 3 * time.Hour, Audience: "coordinators", Channels: []string{"email"}},
+		}),
+		CoordinatorEmails:    listFromEnv("COORDINATOR_EMAILS"),
+		CoordinatorPhones:    listFromEnv("COORDINATOR_PHONES"),
+		WebhookUrl:           os.Getenv("WEBHOOK_URL"),
+		SmsGatewayUrl:        os.Getenv("SMS_GATEWAY_URL"),
+		SmsGatewayToken:      os.Getenv("SMS_GATEWAY_TOKEN"),
+		CheckInDelay:         durationFromEnv("CHECKIN_DELAY", 15*time.Minute),
+		ChapelCheckInKey:     os.Getenv("CHAPEL_CHECKIN_KEY"),
+		KioskNameDisplay:     stringFromEnv("KIOSK_NAME_DISPLAY", "first"),
+		KioskRefreshInterval: durationFromEnv("KIOSK_REFRESH_INTERVAL", 30*time.Second),
+		WaitlistPolicy:       stringFromEnv("WAITLIST_POLICY", "offer"),
+		WaitlistOfferTimeout: durationFromEnv("WAITLIST_OFFER_TIMEOUT", 2*time.Hour),
+		SlotTemplates: slotTemplatesFromEnv("SLOT_TEMPLATES", []SlotTemplate{
+			{From: 0, To: 24 * time.Hour, Length: time.Hour, MinDuration: time.Hour, MaxDuration: 24 * time.Hour},
+		}),
+		BookingHorizon:       durationFromEnv("BOOKING_HORIZON", 365*24*time.Hour),
+		MaxSeriesRepetitions: intFromEnv("MAX_SERIES_REPETITIONS", 52),
+		MaxHoursPerDay:       intFromEnv("MAX_HOURS_PER_DAY", 0),
+		MaxHoursPerWeek:      intFromEnv("MAX_HOURS_PER_WEEK", 0),
+		CancellationCutoff:   durationFromEnv("CANCELLATION_CUTOFF", 0),
+	}
+}
+
+// stringFromEnv is a utility method to read a string from the environment.
+func stringFromEnv(key string, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+// durationFromEnv is a utility method to read a time.Duration (e.g. "72h" or "10m") from the environment.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	// a malformed configuration should not prevent the application from starting, so we only log it
+	if err != nil {
+		log.Printf("[config] Invalid duration %q for %s, using default %s", value, key, fallback)
+		return fallback
+	}
+
+	return duration
+}
+
+// intFromEnv is a utility method to read an integer from the environment.
+func intFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("[config] Invalid integer %q for %s, using default %d", value, key, fallback)
+		return fallback
+	}
+
+	return number
+}
+
+// durationsFromEnv is a utility method to read a comma-separated list of time.Duration (e.g. "24h,2h") from the
+// environment.
+func durationsFromEnv(key string, fallback []time.Duration) []time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	durations := make([]time.Duration, 0)
+	for _, part := range strings.Split(value, ",") {
+		duration, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			log.Printf("[config] Invalid durations %q for %s, using default %v", value, key, fallback)
+			return fallback
+		}
+		durations = append(durations, duration)
+	}
+
+	return durations
+}
+
+// locationFromEnv is a utility method to read a timezone (e.g. "Europe/Vienna") from the environment.
+func locationFromEnv(key string, fallback string) *time.Location {
+	value := os.Getenv(key)
+	if value == "" {
+		value = fallback
+	}
+
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		log.Printf("[config] Invalid timezone %q for %s, using UTC", value, key)
+		return time.UTC
+	}
+
+	return location
+}
+
+// listFromEnv is a utility method to read a comma-separated list of strings from the environment.
+func listFromEnv(key string) []string {
+	values := make([]string, 0)
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+
+	return values
+}
+
+// escalationStagesFromEnv is a utility method to read the escalation policy from the environment. Each stage is given
+// as "<before>:<audience>:<channel>+<channel>", e.g. "48h:volunteers:email,3h:coordinators:email+sms".
+func escalationStagesFromEnv(key string, fallback []EscalationStage) []EscalationStage {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	stages := make([]EscalationStage, 0)
+	for _, part := range strings.Split(value, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) != 3 {
+			log.Printf("[config] Invalid escalation stages %q for %s, using default", value, key)
+			return fallback
+		}
+
+		before, err := time.ParseDuration(fields[0])
+		if err != nil || (fields[1] != "volunteers" && fields[1] != "coordinators") {
+			log.Printf("[config] Invalid escalation stages %q for %s, using default", value, key)
+			return fallback
+		}
+
+		stages = append(stages, EscalationStage{Before: before, Audience: fields[1], Channels: strings.Split(fields[2], "+")})
+	}
+
+	// The stages are processed in chronological order, i.e., the longest time before the start comes first
+	slices.SortFunc(stages, func(a, b EscalationStage) int {
+		return cmp.Compare(b.Before, a.Before)
+	})
+
+	return stages
+}
+
+// slotTemplatesFromEnv is a utility method to read the slot grid from the environment. Each template is given as
+// "<from>-<to>/<length>" or "<from>-<to>/<length>/<min>-<max>", e.g. "00:00-06:00/30m/30m-6h,06:00-24:00/1h". Without
+// explicit bounds, an entry lasts at least one slot and at most a day. The templates must cover the whole day.
+func slotTemplatesFromEnv(key string, fallback []SlotTemplate) []SlotTemplate {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	templates := make([]SlotTemplate, 0)
+	for _, part := range strings.Split(value, ",") {
+		fields := strings.Split(strings.TrimSpace(part), "/")
+		if len(fields) != 2 && len(fields) != 3 {
+			log.Printf("[config] Invalid slot templates %q for %s, using default", value, key)
+			return fallback
+		}
+
+		from, to, okRange := parseDurationRange(fields[0], parseTimeOfDay)
+		length, err := time.ParseDuration(fields[1])
+		if !okRange || err != nil || length <= 0 || from >= to || (to-from)%length != 0 {
+			log.Printf("[config] Invalid slot templates %q for %s, using default", value, key)
+			return fallback
+		}
+
+		template := SlotTemplate{From: from, To: to, Length: length, MinDuration: length, MaxDuration: 24 * time.Hour}
+		if len(fields) == 3 {
+			minDuration, maxDuration, okBounds := parseDurationRange(fields[2], time.ParseDuration)
+			if !okBounds || minDuration <= 0 || minDuration > maxDuration {
+				log.Printf("[config] Invalid slot templates %q for %s, using default", value, key)
+				return fallback
+			}
+			template.MinDuration = minDuration
+			template.MaxDuration = maxDuration
+		}
+
+		templates = append(templates, template)
+	}
+
+	// The templates are looked up by time of day, so they have to follow each other from midnight to midnight
+	slices.SortFunc(templates, func(a, b SlotTemplate) int {
+		return cmp.Compare(a.From, b.From)
+	})
+	cursor := time.Duration(0)
+	for _, template := range templates {
+		if template.From != cursor {
+			log.Printf("[config] Slot templates %q for %s don't cover the day, using default", value, key)
+			return fallback
+		}
+		cursor = template.To
+	}
+	if cursor != 24*time.Hour {
+		log.Printf("[config] Slot templates %q for %s don't cover the day, using default", value, key)
+		return fallback
+	}
+
+	return templates
+}
+
+// parseDurationRange is a utility method to parse a range "<a>-<b>" of two durations with the given parser.
+func parseDurationRange(value string, parse func(string) (time.Duration, error)) (time.Duration, time.Duration, bool) {
+	bounds := strings.Split(value, "-")
+	if len(bounds) != 2 {
+		return 0, 0, false
+	}
+
+	lower, err := parse(bounds[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	upper, err := parse(bounds[1])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return lower, upper, true
+}
+
+// parseTimeOfDay is a utility method to parse a time of day "<hh>:<mm>" as offset from midnight, including "24:00".
+func parseTimeOfDay(value string) (time.Duration, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hours, &minutes); err != nil {
+		return 0, err
+	}
+	offset := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	if hours < 0 || minutes < 0 || minutes >= 60 || offset > 24*time.Hour {
+		return 0, fmt.Errorf("time of day out of range: %s", value)
+	}
+
+	return offset, nil
+}
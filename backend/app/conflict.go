@@ -0,0 +1,131 @@
+// Provides the explanation of conflicting requests, i.e., which entries collide and where the requested time is free
+
+package app
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/httplog/v2"
+)
+
+// conflictSearch limits how far from a conflicting interval a free alternative is searched
+const conflictSearch = 3 * 24 * time.Hour
+
+// collidesWith checks whether an existing entry prevents the given new entry during their overlap. This mirrors the
+// conflictingEntries query: an entry of a participant and an admin event whose EventType doesn't block bookings never
+// collide, while all other entries do.
+func (i eventTypeIndex) collidesWith(entry, existing CalendarEntry) bool {
+	if existing.EventTypeId == nil && !i.blocksBookings(entry) {
+		return false
+	}
+	if entry.EventTypeId == nil && !i.blocksBookings(existing) {
+		return false
+	}
+	return true
+}
+
+// collidingEntries filters the entries that would prevent the given new entry, if they overlapped it.
+func (i eventTypeIndex) collidingEntries(entry CalendarEntry, entries []CalendarEntry) []CalendarEntry {
+	filtered := make([]CalendarEntry, 0, len(entries))
+	for _, existing := range entries {
+		if i.collidesWith(entry, existing) {
+			filtered = append(filtered, existing)
+		}
+	}
+	return filtered
+}
+
+// findConflicts determines the Conflict of each of the given new entries that collides with existing entries or a
+// timeslot offered to the waitlist, including the nearest free alternative for it. An offered timeslot is named
+// without any personal information.
+func (h *ApiHandler) findConflicts(entries []CalendarEntryFull) ([]Conflict, error) {
+	types, err := loadEventTypes(h.db)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	conflicts := make([]Conflict, 0)
+	for _, entry := range entries {
+		colliding, err := h.db.GetConflictingEntries(entry)
+		if err != nil {
+			return nil, err
+		}
+		offers, err := h.db.GetActiveWaitlistOffers(entry.Start.Add(-conflictSearch), entry.End.Add(conflictSearch), now)
+		if err != nil {
+			return nil, err
+		}
+		reservations := types.offerReservations(entry, offers)
+		for _, reservation := range reservations {
+			if reservation.Start.Before(entry.End) && entry.Start.Before(reservation.End) {
+				colliding = append(colliding, reservation)
+			}
+		}
+		if len(colliding) == 0 {
+			continue
+		}
+
+		conflict := Conflict{Requested: Interval{Start: entry.Start, End: entry.End}, Entries: colliding}
+
+		nearby, err := h.db.GetAllEntriesForRange(entry.Start.Add(-conflictSearch), entry.End.Add(conflictSearch))
+		if err != nil {
+			return nil, err
+		}
+		blocking := append(types.collidingEntries(entry.CalendarEntry, nearby), reservations...)
+		alternatives := nearestFreeIntervals(entry.Start, entry.End, now, blocking, conflictSearch, 1)
+		if len(alternatives) > 0 {
+			conflict.Alternative = &alternatives[0]
+		}
+
+		conflicts = append(conflicts, conflict)
+	}
+
+	return conflicts, nil
+}
+
+// freeEntries filters the given new entries that neither conflict with existing entries nor are offered to the
+// waitlist, i.e., the ones that are booked when skipping the conflicts.
+func (h *ApiHandler) freeEntries(entries []CalendarEntryFull) ([]CalendarEntryFull, error) {
+	offered, err := h.offeredEntries(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	free := make([]CalendarEntryFull, 0, len(entries))
+	for _, entry := range entries {
+		if slices.ContainsFunc(offered, func(o CalendarEntryFull) bool {
+			return o.Start.Equal(entry.Start) && o.End.Equal(entry.End)
+		}) {
+			continue
+		}
+
+		colliding, err := h.db.GetConflictingEntries(entry)
+		if err != nil {
+			return nil, err
+		}
+		if len(colliding) == 0 {
+			free = append(free, entry)
+		}
+	}
+
+	return free, nil
+}
+
+// httpConflictWithLog is the counterpart of httpProblemWithLog for conflicts of the given new entries, whose Problem
+// additionally names the colliding entries.
+func (h *ApiHandler) httpConflictWithLog(r *http.Request, w http.ResponseWriter, err error, entries []CalendarEntryFull) {
+	conflicts, findErr := h.findConflicts(entries)
+	if findErr != nil {
+		httpProblemWithLog(r, w, findErr)
+		return
+	}
+
+	logger := httplog.LogEntry(r.Context())
+	logger.Error(err.Error())
+
+	problem := newProblem(r, err)
+	problem.Conflicts = conflicts
+	writeProblem(w, problem)
+}
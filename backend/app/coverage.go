@@ -0,0 +1,262 @@
+// Provides the coverage report, which shows the timeslots that are still uncovered within a date range
+
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCoverageDays limits the date range of a coverage report to keep the computation reasonable
+const maxCoverageDays = 366
+
+// CoverageStats summarizes the coverage of some period. Time blocked by admin events, which don't count as coverage
+// themselves, is not required to be covered.
+type CoverageStats struct {
+	RequiredMinutes int
+	CoveredMinutes  int
+	// Percentage is 100, if no time was required to be covered
+	Percentage float64
+}
+
+// DayCoverage is the coverage of a single day, additionally broken down into day and night hours.
+type DayCoverage struct {
+	Date  string
+	Total CoverageStats
+	Day   CoverageStats
+	Night CoverageStats
+}
+
+// CoverageReport is purely a response REST-DTO, containing the uncovered intervals within a date range, aligned to the
+// requested slot granularity, as well as the coverage statistics per day.
+type CoverageReport struct {
+	From time.Time
+	To   time.Time
+	// Granularity is the length of a slot in minutes
+	Granularity int
+	Gaps        []Interval
+	Days        []DayCoverage
+	Total       CoverageStats
+}
+
+// coverageAccumulator collects the required and covered time before it is turned into CoverageStats.
+type coverageAccumulator struct {
+	required time.Duration
+	covered  time.Duration
+}
+
+func (a *coverageAccumulator) add(required, uncovered time.Duration) {
+	a.required += required
+	a.covered += required - uncovered
+}
+
+func (a *coverageAccumulator) stats() CoverageStats {
+	percentage := 100.0
+	if a.required > 0 {
+		percentage = math.Round(float64(a.covered)/float64(a.required)*1000) / 10
+	}
+	return CoverageStats{
+		RequiredMinutes: int(a.required.Minutes()),
+		CoveredMinutes:  int(a.covered.Minutes()),
+		Percentage:      percentage,
+	}
+}
+
+// buildCoverageReport computes the CoverageReport for the days between from and to (both inclusive), based on the
+// given entries, which must be sorted by their start. Only admin events whose EventType doesn't block bookings may
+// overlap other entries, as guaranteed by the database.
+func buildCoverageReport(entries []CalendarEntry, types eventTypeIndex, from, to time.Time, granularity time.Duration, nightStart, nightEnd int) CoverageReport {
+	report := CoverageReport{
+		From:        from,
+		To:          to,
+		Granularity: int(granularity.Minutes()),
+		Gaps:        make([]Interval, 0),
+		Days:        make([]DayCoverage, 0),
+	}
+
+	var total coverageAccumulator
+	// As the entries are sorted and barely overlap, the first entry possibly overlapping a slot only ever moves forward
+	first := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		var dayTotal, dayHours, nightHours coverageAccumulator
+
+		for slotStart := day; slotStart.Before(day.AddDate(0, 0, 1)); slotStart = slotStart.Add(granularity) {
+			slotEnd := slotStart.Add(granularity)
+
+			for first < len(entries) && !entries[first].End.After(slotStart) {
+				first++
+			}
+
+			blockers := make([]CalendarEntry, 0)
+			coverers := make([]CalendarEntry, 0)
+			for i := first; i < len(entries) && entries[i].Start.Before(slotEnd); i++ {
+				if types.countsAsCoverage(entries[i]) {
+					coverers = append(coverers, entries[i])
+				} else if types.blocksBookings(entries[i]) {
+					blockers = append(blockers, entries[i])
+				}
+			}
+
+			var required, uncovered time.Duration
+			for _, part := range uncoveredIntervals(slotStart, slotEnd, blockers) {
+				required += part.End.Sub(part.Start)
+				for _, free := range uncoveredIntervals(part.Start, part.End, coverers) {
+					uncovered += free.End.Sub(free.Start)
+				}
+			}
+
+			total.add(required, uncovered)
+			dayTotal.add(required, uncovered)
+			if isNightHour(slotStart.Hour(), nightStart, nightEnd) {
+				nightHours.add(required, uncovered)
+			} else {
+				dayHours.add(required, uncovered)
+			}
+
+			if uncovered == 0 {
+				continue
+			}
+			// Adjacent uncovered slots are merged into a single gap
+			if last := len(report.Gaps) - 1; last >= 0 && report.Gaps[last].End.Equal(slotStart) {
+				report.Gaps[last].End = slotEnd
+			} else {
+				report.Gaps = append(report.Gaps, Interval{Start: slotStart, End: slotEnd})
+			}
+		}
+
+		report.Days = append(report.Days, DayCoverage{
+			Date:  day.Format("2006-01-02"),
+			Total: dayTotal.stats(),
+			Day:   dayHours.stats(),
+			Night: nightHours.stats(),
+		})
+	}
+	report.Total = total.stats()
+
+	return report
+}
+
+// isNightHour checks whether the given hour lies within the night, which usually wraps around midnight.
+func isNightHour(hour, nightStart, nightEnd int) bool {
+	if nightStart <= nightEnd {
+		return hour >= nightStart && hour < nightEnd
+	}
+	return hour >= nightStart || hour < nightEnd
+}
+
+// GetCoverage provides the CoverageReport for the date range given via the query parameters "from" and "to" (both
+// inclusive), with a slot length in minutes given via the optional query parameter "granularity". With the query
+// parameter "format=csv", the report is provided per day in CSV format instead.
+func (h *ApiHandler) GetCoverage(w http.ResponseWriter, r *http.Request) {
+	from, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+	to, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+	if to.Before(from) {
+		httpProblemWithLog(r, w, ErrFromAfterTo)
+		return
+	}
+	if to.Sub(from) > maxCoverageDays*24*time.Hour {
+		httpProblemWithLog(r, w, ErrRangeTooLong)
+		return
+	}
+
+	granularity := 60
+	if granularityStr := r.URL.Query().Get("granularity"); granularityStr != "" {
+		granularity, err = strconv.Atoi(granularityStr)
+		if err != nil {
+			httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+			return
+		}
+	}
+	// The slots must evenly divide a day, so they are aligned the same on every day
+	if granularity < 5 || (24*60)%granularity != 0 {
+		httpProblemWithLog(r, w, ErrInvalidGranularity)
+		return
+	}
+
+	entries, err := h.db.GetAllEntriesForRange(from, to.AddDate(0, 0, 1))
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	types, err := loadEventTypes(h.db)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	report := buildCoverageReport(entries, types, from, to, time.Duration(granularity)*time.Minute, h.config.NightStartHour, h.config.NightEndHour)
+
+	if r.URL.Query().Get("format") == "csv" {
+		writeCoverageCsv(r, w, report)
+		return
+	}
+
+	writeJson(w, report)
+}
+
+// writeCoverageCsv returns a CoverageReport in CSV format, with a row per day, listing the gaps of that day.
+func writeCoverageCsv(r *http.Request, w http.ResponseWriter, report CoverageReport) {
+	filename := fmt.Sprintf("coverage_%s_%s.csv", report.From.Format("2006-01-02"), report.To.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	headers := []string{"Datum", "Abdeckung (%)", "Tag (%)", "Nacht (%)", "Benötigte Minuten", "Abgedeckte Minuten", "Offene Zeiträume"}
+	if err := writer.Write(headers); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	for _, day := range report.Days {
+		date, _ := time.Parse("2006-01-02", day.Date)
+		nextDate := date.AddDate(0, 0, 1)
+
+		// Gaps spanning midnight are listed for both days, each clipped to the respective day
+		gaps := make([]string, 0)
+		for _, gap := range report.Gaps {
+			if !gap.Start.Before(nextDate) || !gap.End.After(date) {
+				continue
+			}
+			start, end := gap.Start, gap.End
+			if start.Before(date) {
+				start = date
+			}
+			endStr := end.Format("15:04")
+			if !end.Before(nextDate) {
+				endStr = "24:00"
+			}
+			gaps = append(gaps, fmt.Sprintf("%s-%s", start.Format("15:04"), endStr))
+		}
+
+		row := []string{
+			date.Format("02.01.2006"),
+			strconv.FormatFloat(day.Total.Percentage, 'f', 1, 64),
+			strconv.FormatFloat(day.Day.Percentage, 'f', 1, 64),
+			strconv.FormatFloat(day.Night.Percentage, 'f', 1, 64),
+			strconv.Itoa(day.Total.RequiredMinutes),
+			strconv.Itoa(day.Total.CoveredMinutes),
+			strings.Join(gaps, "; "),
+		}
+		if err := writer.Write(row); err != nil {
+			log.Println("Error writing row to CSV:", err)
+			return
+		}
+	}
+}
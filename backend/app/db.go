@@ -4,8 +4,12 @@ package app
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,7 +30,12 @@ func NewDBHandler(path string) *DBHandler {
 // connect opens a sqlite database, creating it if it does not exist.
 func connect(path string) *sql.DB {
 	log.Println("[sqlite] Connecting to database...")
-	db, err := sql.Open("sqlite", path)
+	// As background jobs write concurrently to the requests, waiting for a lock is preferable to failing immediately
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	db, err := sql.Open("sqlite", path+separator+"_pragma=busy_timeout(5000)")
 	// an error during database connection is not recoverable
 	if err != nil {
 		log.Fatal(err)
@@ -36,6 +45,11 @@ func connect(path string) *sql.DB {
 	return db
 }
 
+// Conn exposes the underlying database connection for subsystems, like the scheduler, that manage their own tables.
+func (h *DBHandler) Conn() *sql.DB {
+	return h.db
+}
+
 // Close closes the database connection.
 func (h *DBHandler) Close() {
 	err := h.db.Close()
@@ -46,7 +60,8 @@ func (h *DBHandler) Close() {
 }
 
 // Setup creates all the tables for the sqlite database. However, since this application has no versioning, adjusting
-// the database definition most likely requires a deletion of the existing database.
+// the database definition most likely requires a deletion of the existing database. Only purely additive changes,
+// i.e., new columns, are migrated automatically.
 func (h *DBHandler) Setup() {
 	_, err := h.db.Exec(`
 		PRAGMA foreign_keys = ON;
@@ -57,6 +72,14 @@ func (h *DBHandler) Setup() {
 			repetitions INTEGER NOT NULL
 		);
 
+		CREATE TABLE IF NOT EXISTS series_exceptions (
+			series_id INTEGER NOT NULL,
+			starttime DATETIME NOT NULL,
+			endtime DATETIME NOT NULL,
+			PRIMARY KEY (series_id, starttime),
+			FOREIGN KEY (series_id) REFERENCES calendar_series(id) ON DELETE CASCADE
+		);
+
 		CREATE TABLE IF NOT EXISTS calendar_entries (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			firstname TEXT NOT NULL,
@@ -64,7 +87,6 @@ func (h *DBHandler) Setup() {
 			email TEXT NOT NULL,
 			starttime DATETIME NOT NULL,
 			endtime DATETIME NOT NULL,
-			admin_event TEXT,
 			series_id INTEGER,
 			FOREIGN KEY (series_id) REFERENCES calendar_series(id)
 		);
@@ -75,20 +97,235 @@ func (h *DBHandler) Setup() {
 			confirmed BOOLEAN NOT NULL,
 			confirmation_token TEXT NOT NULL
 		);
+
+		CREATE TABLE IF NOT EXISTS pending_alerts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			starttime DATETIME NOT NULL,
+			endtime DATETIME NOT NULL,
+			due_at DATETIME NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS sent_reminders (
+			entry_id INTEGER NOT NULL,
+			offset_minutes INTEGER NOT NULL,
+			sent_at DATETIME NOT NULL,
+			PRIMARY KEY (entry_id, offset_minutes),
+			FOREIGN KEY (entry_id) REFERENCES calendar_entries(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS attendance (
+			entry_id INTEGER PRIMARY KEY,
+			checked_in_at DATETIME,
+			method TEXT,
+			missed_alert_at DATETIME,
+			FOREIGN KEY (entry_id) REFERENCES calendar_entries(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS waitlist (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			firstname TEXT NOT NULL,
+			lastname TEXT NOT NULL,
+			email TEXT NOT NULL,
+			starttime DATETIME NOT NULL,
+			endtime DATETIME NOT NULL,
+			created_at DATETIME NOT NULL,
+			offer_expires_at DATETIME,
+			UNIQUE (email, starttime, endtime)
+		);
+
+		CREATE TABLE IF NOT EXISTS transfers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			entry_id INTEGER NOT NULL,
+			email TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			closed_at DATETIME,
+			FOREIGN KEY (entry_id) REFERENCES calendar_entries(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS transfer_proposals (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			transfer_id INTEGER NOT NULL,
+			firstname TEXT NOT NULL,
+			lastname TEXT NOT NULL,
+			email TEXT NOT NULL,
+			reminders BOOLEAN NOT NULL,
+			swap_entry_id INTEGER,
+			created_at DATETIME NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			FOREIGN KEY (transfer_id) REFERENCES transfers(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS event_types (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			color TEXT NOT NULL,
+			icon TEXT NOT NULL DEFAULT '',
+			blocks_bookings BOOLEAN NOT NULL DEFAULT TRUE,
+			counts_as_coverage BOOLEAN NOT NULL DEFAULT TRUE,
+			translations TEXT NOT NULL DEFAULT '{}'
+		);
+
+		CREATE TABLE IF NOT EXISTS calendar_groups (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			firstname TEXT NOT NULL,
+			lastname TEXT NOT NULL,
+			email TEXT NOT NULL,
+			starttime DATETIME NOT NULL,
+			endtime DATETIME NOT NULL,
+			created_at DATETIME NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS late_cancellations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			entry_id INTEGER NOT NULL,
+			firstname TEXT NOT NULL,
+			lastname TEXT NOT NULL,
+			email TEXT NOT NULL,
+			starttime DATETIME NOT NULL,
+			endtime DATETIME NOT NULL,
+			reason TEXT NOT NULL,
+			cancelled_at DATETIME NOT NULL
+		);
 	`)
 	// an error during table creation is not recoverable
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	h.addColumnIfMissing("calendar_entries", "reminders", "BOOLEAN NOT NULL DEFAULT FALSE")
+	h.addColumnIfMissing("volunteers", "digest_frequency", "TEXT NOT NULL DEFAULT 'daily'")
+	h.addColumnIfMissing("volunteers", "last_digest_at", "DATETIME")
+	h.addColumnIfMissing("pending_alerts", "stage", "INTEGER NOT NULL DEFAULT 0")
+	h.addColumnIfMissing("calendar_entries", "group_id", "INTEGER REFERENCES calendar_groups(id)")
+	h.migrateEventTypes()
+}
+
+// hasColumn checks whether an existing table already contains a column.
+func (h *DBHandler) hasColumn(table, column string) bool {
+	var exists bool
+	err := h.db.QueryRow("SELECT EXISTS (SELECT 1 FROM pragma_table_info($1) WHERE name = $2)", table, column).Scan(&exists)
+	// an error during migration is not recoverable
+	if err != nil {
+		log.Fatal(err)
+	}
+	return exists
+}
+
+// addColumnIfMissing adds a column to an existing table, if it is not already present. This allows databases created
+// by an older version of the application to be used further without deleting them.
+func (h *DBHandler) addColumnIfMissing(table, column, definition string) {
+	if h.hasColumn(table, column) {
+		return
+	}
+
+	if _, err := h.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("[sqlite] Added column %s to table %s", column, table)
+}
+
+// defaultEventTypes are the event types every database starts with. They are keyed by the free-form strings, which
+// older versions of the application stored for admin events, so those can be migrated.
+var defaultEventTypes = []struct {
+	legacy    string
+	eventType EventType
+}{
+	{"mass", EventType{Name: "Messe", Color: "#ef4444", Icon: "Church", BlocksBookings: true, CountsAsCoverage: true,
+		Translations: map[string]string{"de": "Messe", "en": "Mass"}}},
+	{"praise", EventType{Name: "Lobpreis", Color: "#a855f7", Icon: "Music", BlocksBookings: true, CountsAsCoverage: true,
+		Translations: map[string]string{"de": "Lobpreis", "en": "Praise"}}},
+	{"event", EventType{Name: "Veranstaltung", Color: "#4ade80", Icon: "CalendarDays", BlocksBookings: true, CountsAsCoverage: true,
+		Translations: map[string]string{"de": "Veranstaltung", "en": "Event"}}},
+	{"blocker", EventType{Name: "Blocker", Color: "#94a3b8", Icon: "Ban", BlocksBookings: true, CountsAsCoverage: false,
+		Translations: map[string]string{"de": "Blocker", "en": "Blocker"}}},
+}
+
+// migrateEventTypes introduces the reference of the entries to their EventType. At that point, the default event
+// types are created and the free-form strings of older versions are migrated to them, where unknown strings become
+// event types of their own. Everything happens within a transaction, so an interrupted migration is simply repeated.
+func (h *DBHandler) migrateEventTypes() {
+	if h.hasColumn("calendar_entries", "event_type_id") {
+		return
+	}
+	hasLegacy := h.hasColumn("calendar_entries", "admin_event")
+
+	// an error during migration is not recoverable
+	tx, err := h.db.Begin()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	ids := make(map[string]int64)
+	for _, d := range defaultEventTypes {
+		res, err := tx.Exec(insertEventTypeQuery, eventTypeArgs(d.eventType)...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if ids[d.legacy], err = res.LastInsertId(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if _, err := tx.Exec("ALTER TABLE calendar_entries ADD COLUMN event_type_id INTEGER REFERENCES event_types(id)"); err != nil {
+		log.Fatal(err)
+	}
+
+	if hasLegacy {
+		rows, err := tx.Query("SELECT DISTINCT admin_event FROM calendar_entries WHERE admin_event IS NOT NULL")
+		if err != nil {
+			log.Fatal(err)
+		}
+		legacy := make([]string, 0)
+		for rows.Next() {
+			var value string
+			if err := rows.Scan(&value); err != nil {
+				log.Fatal(err)
+			}
+			legacy = append(legacy, value)
+		}
+		rows.Close()
+
+		for _, value := range legacy {
+			id, ok := ids[value]
+			if !ok {
+				res, err := tx.Exec(insertEventTypeQuery, eventTypeArgs(EventType{Name: value, Color: "#000000", BlocksBookings: true, CountsAsCoverage: true})...)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if id, err = res.LastInsertId(); err != nil {
+					log.Fatal(err)
+				}
+			}
+			if _, err := tx.Exec("UPDATE calendar_entries SET event_type_id = $1 WHERE admin_event = $2", id, value); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		if _, err := tx.Exec("ALTER TABLE calendar_entries DROP COLUMN admin_event"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("[sqlite] Migrated admin events to event types")
 }
 
 // GetAllEntriesForWeek queries all CalendarEntry for a week starting at a give date(time).
 func (h *DBHandler) GetAllEntriesForWeek(start time.Time) ([]CalendarEntry, error) {
-	end := start.AddDate(0, 0, 7)
+	return h.GetAllEntriesForRange(start, start.AddDate(0, 0, 7))
+}
+
+// GetAllEntriesForRange queries all CalendarEntry touching the interval between the given start and end.
+func (h *DBHandler) GetAllEntriesForRange(start, end time.Time) ([]CalendarEntry, error) {
 	rows, err := h.db.Query(`
-		SELECT id, firstname, starttime, endtime, admin_event, series_id FROM calendar_entries
-		WHERE starttime <= $1 AND endtime >= $2
-		ORDER BY starttime ASC
+		SELECT e.id, e.firstname, e.starttime, e.endtime, e.event_type_id, e.series_id, e.group_id, g.name
+		FROM calendar_entries e LEFT JOIN calendar_groups g ON g.id = e.group_id
+		WHERE e.starttime <= $1 AND e.endtime >= $2
+		ORDER BY e.starttime ASC
 	`, end, start)
 	if err != nil {
 		return nil, err
@@ -98,7 +335,7 @@ func (h *DBHandler) GetAllEntriesForWeek(start time.Time) ([]CalendarEntry, erro
 	entries := make([]CalendarEntry, 0)
 	for rows.Next() {
 		var entry CalendarEntry
-		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.Start, &entry.End, &entry.AdminEvent, &entry.SeriesId); err != nil {
+		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId, &entry.GroupId, &entry.GroupName); err != nil {
 			return nil, err
 		}
 		entries = append(entries, entry)
@@ -110,11 +347,17 @@ func (h *DBHandler) GetAllEntriesForWeek(start time.Time) ([]CalendarEntry, erro
 // GetAllFullEntriesForWeek queries all CalendarEntryFull for a week starting at a give date(time).
 // As this concerns private user information, this should only be privy to the admin.
 func (h *DBHandler) GetAllFullEntriesForWeek(start time.Time) ([]CalendarEntryFull, error) {
-	end := start.AddDate(0, 0, 7)
+	return h.GetAllFullEntriesForRange(start, start.AddDate(0, 0, 7))
+}
+
+// GetAllFullEntriesForRange queries all CalendarEntryFull touching the interval between the given start and end.
+// As this concerns private user information, this should only be privy to the admin.
+func (h *DBHandler) GetAllFullEntriesForRange(start, end time.Time) ([]CalendarEntryFull, error) {
 	rows, err := h.db.Query(`
-		SELECT id, firstname, lastname, email, starttime, endtime, admin_event, series_id FROM calendar_entries
-		WHERE starttime <= $1 AND endtime >= $2
-		ORDER BY starttime ASC
+		SELECT e.id, e.firstname, e.lastname, e.email, e.starttime, e.endtime, e.event_type_id, e.series_id, e.reminders, e.group_id, g.name
+		FROM calendar_entries e LEFT JOIN calendar_groups g ON g.id = e.group_id
+		WHERE e.starttime <= $1 AND e.endtime >= $2
+		ORDER BY e.starttime ASC
 	`, end, start)
 	if err != nil {
 		return nil, err
@@ -124,7 +367,7 @@ func (h *DBHandler) GetAllFullEntriesForWeek(start time.Time) ([]CalendarEntryFu
 	entries := make([]CalendarEntryFull, 0)
 	for rows.Next() {
 		var entry CalendarEntryFull
-		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End, &entry.AdminEvent, &entry.SeriesId); err != nil {
+		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId, &entry.Reminders, &entry.GroupId, &entry.GroupName); err != nil {
 			return nil, err
 		}
 		entries = append(entries, entry)
@@ -133,23 +376,32 @@ func (h *DBHandler) GetAllFullEntriesForWeek(start time.Time) ([]CalendarEntryFu
 	return entries, nil
 }
 
+// conflictingEntries is the query for the ids of the entries conflicting with a new entry, whose start, end and event
+// type id are given as the respective query parameters. Overlapping entries conflict, unless one of them is a participant's entry
+// and the other one an admin event whose EventType doesn't block bookings.
+func conflictingEntries(start, end, eventTypeId string) string {
+	return fmt.Sprintf(`
+		SELECT c.id FROM calendar_entries c LEFT JOIN event_types ct ON ct.id = c.event_type_id
+		WHERE c.starttime < %[2]s AND c.endtime > %[1]s
+		AND NOT (c.event_type_id IS NULL AND COALESCE((SELECT NOT blocks_bookings FROM event_types WHERE id = %[3]s), FALSE))
+		AND NOT (%[3]s IS NULL AND COALESCE(NOT ct.blocks_bookings, FALSE))
+	`, start, end, eventTypeId)
+}
+
 // InsertEntry inserts a new CalendarEntryFull, i.e., information entered by a user, into the database, given that it
 // does not conflict with the existing data.
 func (h *DBHandler) InsertEntry(entry CalendarEntryFull) (*CalendarEntryFull, error) {
 	res, err := h.db.Exec(`
-		INSERT INTO calendar_entries (firstname, lastname, email, starttime, endtime, admin_event, series_id) 
-		SELECT $1, $2, $3, $4, $5, $6, $7
-		WHERE NOT EXISTS (
-			SELECT 1 FROM calendar_entries
-			WHERE starttime < $5 AND endtime > $4
-		)
-	`, entry.FirstName, entry.LastName, entry.Email, entry.Start, entry.End, entry.AdminEvent, entry.SeriesId)
+		INSERT INTO calendar_entries (firstname, lastname, email, starttime, endtime, event_type_id, series_id, reminders) 
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8
+		WHERE NOT EXISTS (`+conflictingEntries("$4", "$5", "$6")+`)
+	`, entry.FirstName, entry.LastName, entry.Email, entry.Start, entry.End, entry.EventTypeId, entry.SeriesId, entry.Reminders)
 	if err != nil {
 		return nil, err
 	}
 	// as it concerns an insert operation, we would expect a single row to be created
 	if nrOfRows, err := res.RowsAffected(); nrOfRows != 1 || err != nil {
-		return nil, fmt.Errorf("no entry inserted")
+		return nil, ErrEntryNotInserted
 	}
 
 	id, err := res.LastInsertId()
@@ -168,24 +420,50 @@ func (h *DBHandler) InsertEntry(entry CalendarEntryFull) (*CalendarEntryFull, er
 func (h *DBHandler) CheckMultipleTimeslots(entries []CalendarEntryFull) error {
 	// Potentially, it would be more efficient to craft a long query with all the affected start- and endtimes, but
 	// in our case, iterative checking is fine as it is
-	checkTimeslot, err := h.db.Prepare("SELECT 1 FROM calendar_entries WHERE starttime < $2 AND endtime > $1")
+	checkTimeslot, err := h.db.Prepare(conflictingEntries("$1", "$2", "$3"))
 	if err != nil {
 		return err
 	}
 
 	for _, entry := range entries {
-		exec, err := checkTimeslot.Query(entry.Start, entry.End)
+		exec, err := checkTimeslot.Query(entry.Start, entry.End, entry.EventTypeId)
 		if err != nil {
 			return err
 		}
 		if exec.Next() {
-			return fmt.Errorf("timeslot overlap")
+			return ErrTimeslotOverlap
 		}
 	}
 
 	return nil
 }
 
+// GetConflictingEntries queries the CalendarEntry conflicting with the given new entry. Only the public fields are
+// queried, as the conflicts are explained to any user.
+func (h *DBHandler) GetConflictingEntries(entry CalendarEntryFull) ([]CalendarEntry, error) {
+	rows, err := h.db.Query(`
+		SELECT e.id, e.firstname, e.starttime, e.endtime, e.event_type_id, e.series_id, e.group_id, g.name
+		FROM calendar_entries e LEFT JOIN calendar_groups g ON g.id = e.group_id
+		WHERE e.id IN (`+conflictingEntries("$1", "$2", "$3")+`)
+		ORDER BY e.starttime ASC
+	`, entry.Start, entry.End, entry.EventTypeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]CalendarEntry, 0)
+	for rows.Next() {
+		var entry CalendarEntry
+		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId, &entry.GroupId, &entry.GroupName); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
 // InsertSeries inserts a new Series, i.e., meta information for a number of entries belonging together.
 func (h *DBHandler) InsertSeries(series Series) (*Series, error) {
 	res, err := h.db.Exec(`
@@ -212,11 +490,87 @@ func (h *DBHandler) InsertSeries(series Series) (*Series, error) {
 	return &newSeries, nil
 }
 
+// InsertSeriesSkippingConflicts inserts a new Series with all the given entries that don't conflict with existing
+// data, while the conflicting ones, as well as the given reserved ones, are recorded as exceptions of the Series
+// instead. Everything happens within a transaction, so nothing is inserted, if not a single entry is free. It returns
+// the inserted and the skipped entries.
+func (h *DBHandler) InsertSeriesSkippingConflicts(series Series, entries, reserved []CalendarEntryFull) ([]CalendarEntryFull, []CalendarEntryFull, error) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	// A rollback after the commit is a no-op
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO calendar_series (interval, repetitions) VALUES ($1, $2)", series.Interval, series.Repetitions)
+	if err != nil {
+		return nil, nil, err
+	}
+	seriesId, err := res.LastInsertId()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inserted := make([]CalendarEntryFull, 0, len(entries))
+	skipped := make([]CalendarEntryFull, 0)
+	for _, entry := range entries {
+		id := int(seriesId)
+		entry.SeriesId = &id
+
+		var nrOfRows int64
+		isReserved := slices.ContainsFunc(reserved, func(r CalendarEntryFull) bool {
+			return r.Start.Equal(entry.Start) && r.End.Equal(entry.End)
+		})
+		if !isReserved {
+			// Since the transaction sees its own inserts, the entries of the Series can't conflict with each other either
+			res, err = tx.Exec(`
+				INSERT INTO calendar_entries (firstname, lastname, email, starttime, endtime, event_type_id, series_id, reminders) 
+				SELECT $1, $2, $3, $4, $5, $6, $7, $8
+				WHERE NOT EXISTS (`+conflictingEntries("$4", "$5", "$6")+`)
+			`, entry.FirstName, entry.LastName, entry.Email, entry.Start, entry.End, entry.EventTypeId, entry.SeriesId, entry.Reminders)
+			if err != nil {
+				return nil, nil, err
+			}
+			nrOfRows, err = res.RowsAffected()
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if nrOfRows == 0 {
+			if _, err := tx.Exec("INSERT INTO series_exceptions (series_id, starttime, endtime) VALUES ($1, $2, $3)",
+				seriesId, entry.Start, entry.End); err != nil {
+				return nil, nil, err
+			}
+			skipped = append(skipped, entry)
+			continue
+		}
+
+		entryId, err := res.LastInsertId()
+		if err != nil {
+			return nil, nil, err
+		}
+		entry.Id = int(entryId)
+		inserted = append(inserted, entry)
+	}
+
+	// A Series consisting only of exceptions is pointless
+	if len(inserted) == 0 {
+		return nil, nil, ErrEntryNotInserted
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return inserted, skipped, nil
+}
+
 // GetEntry returns a single CalendarEntry. As entries are usually required in bulk, this method is likely used in the
 // context of other operations.
 func (h *DBHandler) GetEntry(id int) (*CalendarEntry, error) {
 	rows, err := h.db.Query(`
-		SELECT id, firstname, starttime, endtime, admin_event, series_id FROM calendar_entries
+		SELECT id, firstname, starttime, endtime, event_type_id, series_id FROM calendar_entries
 		WHERE id = $1
 		ORDER BY starttime ASC
 	`, id)
@@ -227,11 +581,11 @@ func (h *DBHandler) GetEntry(id int) (*CalendarEntry, error) {
 
 	// We would expect a select with a given id to yield a result
 	if !rows.Next() {
-		return nil, fmt.Errorf("no entry found")
+		return nil, ErrEntryNotFound
 	}
 
 	var entry CalendarEntry
-	if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.Start, &entry.End, &entry.AdminEvent, &entry.SeriesId); err != nil {
+	if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId); err != nil {
 		return nil, err
 	}
 
@@ -241,7 +595,7 @@ func (h *DBHandler) GetEntry(id int) (*CalendarEntry, error) {
 // GetSeriesEntries returns all the CalendarEntry associated with a Series, or rather its id.
 func (h *DBHandler) GetSeriesEntries(seriesId int) ([]CalendarEntry, error) {
 	rows, err := h.db.Query(`
-		SELECT id, firstname, starttime, endtime, admin_event, series_id FROM calendar_entries
+		SELECT id, firstname, starttime, endtime, event_type_id, series_id FROM calendar_entries
 		WHERE series_id = $1
 		ORDER BY starttime ASC
 	`, seriesId)
@@ -253,7 +607,56 @@ func (h *DBHandler) GetSeriesEntries(seriesId int) ([]CalendarEntry, error) {
 	entries := make([]CalendarEntry, 0)
 	for rows.Next() {
 		var entry CalendarEntry
-		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.Start, &entry.End, &entry.AdminEvent, &entry.SeriesId); err != nil {
+		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// GetUserEntries returns all the CalendarEntryFull that contain the given user information.
+func (h *DBHandler) GetUserEntries(firstname, lastname, email string) ([]CalendarEntryFull, error) {
+	rows, err := h.db.Query(`
+		SELECT id, firstname, lastname, email, starttime, endtime, event_type_id, series_id, reminders FROM calendar_entries
+		WHERE firstname = $1 AND lastname = $2 AND email = $3
+		ORDER BY starttime ASC
+	`, firstname, lastname, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]CalendarEntryFull, 0)
+	for rows.Next() {
+		var entry CalendarEntryFull
+		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId, &entry.Reminders); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// GetEmailEntriesForRange queries the CalendarEntry booked with the given email that touch the interval between the
+// given start and end, e.g. to check the booking quotas of a participant.
+func (h *DBHandler) GetEmailEntriesForRange(email string, start, end time.Time) ([]CalendarEntry, error) {
+	rows, err := h.db.Query(`
+		SELECT id, firstname, starttime, endtime, event_type_id, series_id, group_id FROM calendar_entries
+		WHERE email = $1 AND starttime < $2 AND endtime > $3
+		ORDER BY starttime ASC
+	`, email, end, start)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]CalendarEntry, 0)
+	for rows.Next() {
+		var entry CalendarEntry
+		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId, &entry.GroupId); err != nil {
 			return nil, err
 		}
 		entries = append(entries, entry)
@@ -271,25 +674,32 @@ func (h *DBHandler) DeleteEntry(id int, email string) error {
 	}
 	// If we couldn't delete exactly one entry, then an issue occurred (though we do not particularly bother to explore which)
 	if nrOfRows, err := res.RowsAffected(); nrOfRows != 1 || err != nil {
-		return fmt.Errorf("no entry deleted")
+		return ErrEntryNotDeleted
 	}
 	return nil
 }
 
-// DeleteSeries deletes all CalendarEntry associated with a Series and then the meta Series database entry. Due to the
-// anonymous design of the application, the user needs to provide the same email he used for creating the CalendarEntry
-// to ensure no foul play.
-func (h *DBHandler) DeleteSeries(id int, email string) error {
-	res, err := h.db.Exec("DELETE FROM calendar_entries WHERE series_id = $1 AND email = $2", id, email)
+// DeleteSeries deletes all CalendarEntry associated with a Series that start after the given time, and then the meta
+// Series database entry, unless earlier entries remain. Due to the anonymous design of the application, the user needs
+// to provide the same email he used for creating the CalendarEntry to ensure no foul play.
+func (h *DBHandler) DeleteSeries(id int, email string, after time.Time) error {
+	res, err := h.db.Exec("DELETE FROM calendar_entries WHERE series_id = $1 AND email = $2 AND starttime > $3", id, email, after)
 	if err != nil {
 		return err
 	}
 	// Deleting a Series without (active) entries indicates some kind of caller issue
 	if nrOfRows, err := res.RowsAffected(); nrOfRows == 0 || err != nil {
-		return fmt.Errorf("no entry deleted")
+		return ErrEntryNotDeleted
 	}
 	// We don't need to check for success, since orphaned Series are not actually an issue
-	h.db.Exec("DELETE FROM calendar_series WHERE id = $1", id)
+	h.db.Exec(`
+		DELETE FROM series_exceptions
+		WHERE series_id = $1 AND NOT EXISTS (SELECT 1 FROM calendar_entries WHERE series_id = $1)
+	`, id)
+	h.db.Exec(`
+		DELETE FROM calendar_series
+		WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM calendar_entries WHERE series_id = $1)
+	`, id)
 	return nil
 }
 
@@ -300,7 +710,7 @@ func (h *DBHandler) DeleteEntryAdmin(id int) error {
 		return err
 	}
 	if nrOfRows, err := res.RowsAffected(); nrOfRows != 1 || err != nil {
-		return fmt.Errorf("no entry deleted")
+		return ErrEntryNotDeleted
 	}
 	return nil
 }
@@ -312,8 +722,9 @@ func (h *DBHandler) DeleteSeriesAdmin(id int) error {
 		return err
 	}
 	if nrOfRows, err := res.RowsAffected(); nrOfRows == 0 || err != nil {
-		return fmt.Errorf("no entry deleted")
+		return ErrEntryNotDeleted
 	}
+	h.db.Exec("DELETE FROM series_exceptions WHERE series_id = $1", id)
 	h.db.Exec("DELETE FROM calendar_series WHERE id = $1", id)
 	return nil
 }
@@ -329,6 +740,38 @@ func (h *DBHandler) DeleteUserInformation(firstname, lastname, email string) err
 		return err
 	}
 
+	// ...as well as any place on the waitlist...
+	_, err = h.db.Exec("DELETE FROM waitlist WHERE firstname = $1 AND lastname = $2 AND email = $3",
+		firstname, lastname, email)
+	if err != nil {
+		return err
+	}
+
+	// ...and any group booked as contact...
+	_, err = h.db.Exec("DELETE FROM calendar_groups WHERE firstname = $1 AND lastname = $2 AND email = $3",
+		firstname, lastname, email)
+	if err != nil {
+		return err
+	}
+
+	// ...and any late cancellation...
+	_, err = h.db.Exec("DELETE FROM late_cancellations WHERE firstname = $1 AND lastname = $2 AND email = $3",
+		firstname, lastname, email)
+	if err != nil {
+		return err
+	}
+
+	// ...and any involvement in transfers...
+	_, err = h.db.Exec("DELETE FROM transfer_proposals WHERE firstname = $1 AND lastname = $2 AND email = $3",
+		firstname, lastname, email)
+	if err != nil {
+		return err
+	}
+	_, err = h.db.Exec("DELETE FROM transfers WHERE email = $1", email)
+	if err != nil {
+		return err
+	}
+
 	// ...and anonymize the future entries
 	_, err = h.db.Exec("UPDATE calendar_entries SET firstname = '---', lastname = '---', email = '---' WHERE firstname = $1 AND lastname = $2 AND email = $3",
 		firstname, lastname, email)
@@ -414,7 +857,7 @@ func (h *DBHandler) CreateVolunteer(email string) (*Volunteer, error) {
 		return nil, err
 	}
 	if nrOfRows, err := res.RowsAffected(); nrOfRows != 1 || err != nil {
-		return nil, fmt.Errorf("no volunteer inserted")
+		return nil, ErrVolunteerNotInserted
 	}
 
 	id, err := res.LastInsertId()
@@ -449,7 +892,7 @@ func (h *DBHandler) ConfirmVolunteer(email, token string) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("no volunteer confirmed")
+		return ErrVolunteerNotConfirmed
 	}
 
 	return nil
@@ -462,7 +905,7 @@ func (h *DBHandler) DeleteVolunteer(email string) error {
 		return err
 	}
 	if nrOfRows, err := res.RowsAffected(); nrOfRows != 1 || err != nil {
-		return fmt.Errorf("no entry deleted")
+		return ErrVolunteerNotDeleted
 	}
 	return nil
 }
@@ -492,3 +935,1125 @@ func (h *DBHandler) GetVolunteerEmails() ([]string, error) {
 
 	return results, nil
 }
+
+// InsertPendingAlert queues an alert for a freed timeslot, which is supposed to be sent to the volunteers at the given
+// due time, unless the timeslot was booked again in the meantime.
+func (h *DBHandler) InsertPendingAlert(start, end, dueAt time.Time) error {
+	_, err := h.db.Exec(`
+		INSERT INTO pending_alerts (starttime, endtime, due_at)
+		SELECT $1, $2, $3
+	`, start, end, dueAt)
+	return err
+}
+
+// GetDuePendingAlerts queries all PendingAlert whose due time has passed at the given point in time.
+func (h *DBHandler) GetDuePendingAlerts(now time.Time) ([]PendingAlert, error) {
+	rows, err := h.db.Query(`
+		SELECT id, starttime, endtime, due_at, stage FROM pending_alerts
+		WHERE due_at <= $1
+		ORDER BY due_at ASC
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]PendingAlert, 0)
+	for rows.Next() {
+		var alert PendingAlert
+		if err := rows.Scan(&alert.Id, &alert.Start, &alert.End, &alert.DueAt, &alert.Stage); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+// UpdatePendingAlertStage advances a PendingAlert to the given stage of the escalation policy, which is due at the
+// given time.
+func (h *DBHandler) UpdatePendingAlertStage(id, stage int, dueAt time.Time) error {
+	_, err := h.db.Exec("UPDATE pending_alerts SET stage = $1, due_at = $2 WHERE id = $3", stage, dueAt, id)
+	return err
+}
+
+// DeletePendingAlert removes a PendingAlert, either because it was fully escalated or because it became obsolete.
+func (h *DBHandler) DeletePendingAlert(id int) error {
+	_, err := h.db.Exec("DELETE FROM pending_alerts WHERE id = $1", id)
+	return err
+}
+
+// DeleteCoveredPendingAlerts removes all PendingAlert whose timeslot is entirely covered by the given interval, i.e.,
+// which became obsolete because the timeslot was booked again.
+func (h *DBHandler) DeleteCoveredPendingAlerts(start, end time.Time) error {
+	_, err := h.db.Exec("DELETE FROM pending_alerts WHERE starttime >= $1 AND endtime <= $2", start, end)
+	return err
+}
+
+// GetLatestNameForEmail queries the first and last name the owner of the given email address used for their most
+// recent CalendarEntry. This allows e.g. volunteers, who are only known by their email address, to book a timeslot
+// without entering their information again.
+func (h *DBHandler) GetLatestNameForEmail(email string) (string, string, error) {
+	var firstname, lastname string
+	err := h.db.QueryRow(`
+		SELECT firstname, lastname FROM calendar_entries
+		WHERE email = $1
+		ORDER BY starttime DESC
+		LIMIT 1
+	`, email).Scan(&firstname, &lastname)
+	if err != nil {
+		return "", "", err
+	}
+
+	return firstname, lastname, nil
+}
+
+// HasEntry checks whether an entry with exactly the given timeslot exists for the given email address.
+func (h *DBHandler) HasEntry(email string, start, end time.Time) (bool, error) {
+	var exists bool
+	err := h.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM calendar_entries
+			WHERE email = $1 AND starttime = $2 AND endtime = $3
+		)
+	`, email, start, end).Scan(&exists)
+	return exists, err
+}
+
+// GetReminderCandidates queries all CalendarEntryFull starting within the given horizon, whose owners opted in to
+// reminders and are confirmed volunteers, since emails must only be sent to consenting addresses.
+func (h *DBHandler) GetReminderCandidates(now time.Time, horizon time.Duration) ([]CalendarEntryFull, error) {
+	rows, err := h.db.Query(`
+		SELECT e.id, e.firstname, e.lastname, e.email, e.starttime, e.endtime, e.event_type_id, e.series_id, e.reminders
+		FROM calendar_entries e
+		JOIN volunteers v ON v.email = e.email AND v.confirmed = TRUE
+		WHERE e.reminders = TRUE AND e.starttime > $1 AND e.starttime <= $2
+		ORDER BY e.starttime ASC
+	`, now, now.Add(horizon))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]CalendarEntryFull, 0)
+	for rows.Next() {
+		var entry CalendarEntryFull
+		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId, &entry.Reminders); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// MarkReminderSent records that the reminder with the given offset was sent for an entry. It returns false, if this
+// was already recorded before, which allows to claim a reminder before actually sending it, preventing duplicates.
+func (h *DBHandler) MarkReminderSent(entryId int, offset time.Duration, now time.Time) (bool, error) {
+	res, err := h.db.Exec(`
+		INSERT OR IGNORE INTO sent_reminders (entry_id, offset_minutes, sent_at)
+		SELECT $1, $2, $3
+	`, entryId, int(offset.Minutes()), now)
+	if err != nil {
+		return false, err
+	}
+
+	nrOfRows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return nrOfRows == 1, nil
+}
+
+// UnmarkReminderSent reverts MarkReminderSent, e.g. if sending the reminder failed and should be retried.
+func (h *DBHandler) UnmarkReminderSent(entryId int, offset time.Duration) error {
+	_, err := h.db.Exec("DELETE FROM sent_reminders WHERE entry_id = $1 AND offset_minutes = $2", entryId, int(offset.Minutes()))
+	return err
+}
+
+// GetDigestRecipients queries all confirmed Volunteer that did not opt out of the digest of uncovered timeslots.
+func (h *DBHandler) GetDigestRecipients() ([]Volunteer, error) {
+	rows, err := h.db.Query(`
+		SELECT id, email, confirmed, confirmation_token, digest_frequency, last_digest_at FROM volunteers
+		WHERE confirmed == TRUE AND digest_frequency <> 'never'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	volunteers := make([]Volunteer, 0)
+	for rows.Next() {
+		var volunteer Volunteer
+		if err := rows.Scan(&volunteer.Id, &volunteer.Email, &volunteer.Confirmed, &volunteer.ConfirmationToken, &volunteer.DigestFrequency, &volunteer.LastDigestAt); err != nil {
+			return nil, err
+		}
+		volunteers = append(volunteers, volunteer)
+	}
+
+	return volunteers, nil
+}
+
+// MarkDigestSent records when a Volunteer last received the digest, which is necessary to respect their frequency.
+func (h *DBHandler) MarkDigestSent(id int, now time.Time) error {
+	_, err := h.db.Exec("UPDATE volunteers SET last_digest_at = $1 WHERE id = $2", now, id)
+	return err
+}
+
+// SetDigestFrequency changes how often a Volunteer receives the digest. Like ConfirmVolunteer, it requires the token
+// of the volunteer, which is only known to the owner of the email address.
+func (h *DBHandler) SetDigestFrequency(email, token, frequency string) error {
+	res, err := h.db.Exec(`
+		UPDATE volunteers
+		SET digest_frequency = $1
+		WHERE email = $2 AND confirmation_token = $3
+	`, frequency, email, token)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrVolunteerNotUpdated
+	}
+
+	return nil
+}
+
+// GetFullEntry returns a single CalendarEntryFull. As this concerns private user information, it must only be used
+// internally or for the admin.
+func (h *DBHandler) GetFullEntry(id int) (*CalendarEntryFull, error) {
+	var entry CalendarEntryFull
+	err := h.db.QueryRow(`
+		SELECT id, firstname, lastname, email, starttime, endtime, event_type_id, series_id, reminders FROM calendar_entries
+		WHERE id = $1
+	`, id).Scan(&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId, &entry.Reminders)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// GetCurrentEntryForEmail returns the CalendarEntryFull of the given email address that is currently running, or
+// starts within the given tolerance.
+func (h *DBHandler) GetCurrentEntryForEmail(email string, now time.Time, early time.Duration) (*CalendarEntryFull, error) {
+	var entry CalendarEntryFull
+	err := h.db.QueryRow(`
+		SELECT id, firstname, lastname, email, starttime, endtime, event_type_id, series_id, reminders FROM calendar_entries
+		WHERE email = $1 AND starttime <= $2 AND endtime > $3
+		ORDER BY starttime ASC
+		LIMIT 1
+	`, email, now.Add(early), now).Scan(&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId, &entry.Reminders)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// GetNextParticipantEntry returns the first CalendarEntryFull of a participant, i.e., no admin event, that starts
+// at or after the given time.
+func (h *DBHandler) GetNextParticipantEntry(after time.Time) (*CalendarEntryFull, error) {
+	var entry CalendarEntryFull
+	err := h.db.QueryRow(`
+		SELECT id, firstname, lastname, email, starttime, endtime, event_type_id, series_id, reminders FROM calendar_entries
+		WHERE starttime >= $1 AND event_type_id IS NULL
+		ORDER BY starttime ASC
+		LIMIT 1
+	`, after).Scan(&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId, &entry.Reminders)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// CheckIn records that the participant of an entry is present. Only the first check-in is kept.
+func (h *DBHandler) CheckIn(entryId int, now time.Time, method string) error {
+	_, err := h.db.Exec(`
+		INSERT INTO attendance (entry_id, checked_in_at, method)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (entry_id) DO UPDATE SET
+			checked_in_at = COALESCE(attendance.checked_in_at, excluded.checked_in_at),
+			method = COALESCE(attendance.method, excluded.method)
+	`, entryId, now, method)
+	return err
+}
+
+// GetMissedCheckIns queries all running entries of participants that started before the given deadline without a
+// check-in, and for which no alert was raised yet. Only entries whose participant had a way to check in count, i.e.,
+// who received the personal check-in link with their reminder, or anyone, if the chapel check-in is available.
+// Anonymized entries and entries of a Group that are still owned by the group contact are ignored.
+func (h *DBHandler) GetMissedCheckIns(now, deadline time.Time, chapelCheckIn bool) ([]CalendarEntryFull, error) {
+	rows, err := h.db.Query(`
+		SELECT e.id, e.firstname, e.lastname, e.email, e.starttime, e.endtime, e.event_type_id, e.series_id, e.reminders
+		FROM calendar_entries e
+		LEFT JOIN attendance a ON a.entry_id = e.id
+		WHERE e.starttime <= $1 AND e.endtime > $2
+		AND e.event_type_id IS NULL AND e.email <> '---'
+		AND a.checked_in_at IS NULL AND a.missed_alert_at IS NULL
+		AND ($3 OR (e.reminders = TRUE AND EXISTS (
+			SELECT 1 FROM volunteers v WHERE v.email = e.email AND v.confirmed = TRUE
+		)))
+		AND NOT EXISTS (SELECT 1 FROM calendar_groups g WHERE g.id = e.group_id AND g.email = e.email)
+		ORDER BY e.starttime ASC
+	`, deadline, now, chapelCheckIn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]CalendarEntryFull, 0)
+	for rows.Next() {
+		var entry CalendarEntryFull
+		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId, &entry.Reminders); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// MarkMissedCheckIn records that an alert was raised for the missing check-in of an entry.
+func (h *DBHandler) MarkMissedCheckIn(entryId int, now time.Time) error {
+	_, err := h.db.Exec(`
+		INSERT INTO attendance (entry_id, missed_alert_at)
+		VALUES ($1, $2)
+		ON CONFLICT (entry_id) DO UPDATE SET missed_alert_at = excluded.missed_alert_at
+	`, entryId, now)
+	return err
+}
+
+// GetAttendance queries the attendance of all participants' entries starting in the given interval.
+func (h *DBHandler) GetAttendance(start, end time.Time) ([]Attendance, error) {
+	rows, err := h.db.Query(`
+		SELECT e.id, e.firstname, e.lastname, e.email, e.starttime, e.endtime, a.checked_in_at, a.method, a.missed_alert_at
+		FROM calendar_entries e
+		LEFT JOIN attendance a ON a.entry_id = e.id
+		WHERE e.starttime >= $1 AND e.starttime < $2 AND e.event_type_id IS NULL
+		ORDER BY e.starttime ASC
+	`, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]Attendance, 0)
+	for rows.Next() {
+		var record Attendance
+		if err := rows.Scan(&record.EntryId, &record.FirstName, &record.LastName, &record.Email, &record.Start, &record.End, &record.CheckedInAt, &record.Method, &record.MissedAlertAt); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// InsertWaitlistEntry queues a new WaitlistEntry. Each email address may only be queued once for the same timeslot.
+func (h *DBHandler) InsertWaitlistEntry(entry WaitlistEntry) (*WaitlistEntry, error) {
+	res, err := h.db.Exec(`
+		INSERT OR IGNORE INTO waitlist (firstname, lastname, email, starttime, endtime, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, entry.FirstName, entry.LastName, entry.Email, entry.Start, entry.End, entry.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrAlreadyOnWaitlist
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	entry.Id = int(id)
+
+	return &entry, nil
+}
+
+// GetWaitlistEntry returns a single WaitlistEntry.
+func (h *DBHandler) GetWaitlistEntry(id int) (*WaitlistEntry, error) {
+	var entry WaitlistEntry
+	err := h.db.QueryRow(`
+		SELECT id, firstname, lastname, email, starttime, endtime, created_at, offer_expires_at FROM waitlist
+		WHERE id = $1
+	`, id).Scan(&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End, &entry.CreatedAt, &entry.OfferExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// DeleteWaitlistEntry removes a WaitlistEntry, given the email address it was queued with, analogous to DeleteEntry.
+func (h *DBHandler) DeleteWaitlistEntry(id int, email string) error {
+	res, err := h.db.Exec("DELETE FROM waitlist WHERE id = $1 AND email = $2", id, email)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrWaitlistEntryNotDeleted
+	}
+	return nil
+}
+
+// RemoveWaitlistEntry removes a WaitlistEntry unconditionally, e.g. after its offer was claimed or expired.
+func (h *DBHandler) RemoveWaitlistEntry(id int) error {
+	_, err := h.db.Exec("DELETE FROM waitlist WHERE id = $1", id)
+	return err
+}
+
+// GetWaitlistCandidates queries the WaitlistEntry without an offer, whose timeslot lies within the interval between
+// the given start and end, in the order they were queued.
+func (h *DBHandler) GetWaitlistCandidates(start, end time.Time) ([]WaitlistEntry, error) {
+	return h.queryWaitlist(`
+		SELECT id, firstname, lastname, email, starttime, endtime, created_at, offer_expires_at FROM waitlist
+		WHERE starttime >= $1 AND endtime <= $2 AND offer_expires_at IS NULL
+		ORDER BY created_at ASC, id ASC
+	`, start, end)
+}
+
+// GetActiveWaitlistOffers queries the WaitlistEntry with an offer, which is still open at the given point in time and
+// overlaps the interval between the given start and end.
+func (h *DBHandler) GetActiveWaitlistOffers(start, end, now time.Time) ([]WaitlistEntry, error) {
+	return h.queryWaitlist(`
+		SELECT id, firstname, lastname, email, starttime, endtime, created_at, offer_expires_at FROM waitlist
+		WHERE starttime < $1 AND endtime > $2 AND offer_expires_at > $3
+		ORDER BY starttime ASC
+	`, end, start, now)
+}
+
+// GetExpiredWaitlistOffers queries the WaitlistEntry, whose offer expired at the given point in time.
+func (h *DBHandler) GetExpiredWaitlistOffers(now time.Time) ([]WaitlistEntry, error) {
+	return h.queryWaitlist(`
+		SELECT id, firstname, lastname, email, starttime, endtime, created_at, offer_expires_at FROM waitlist
+		WHERE offer_expires_at <= $1
+		ORDER BY starttime ASC
+	`, now)
+}
+
+// SetWaitlistOffer records that the timeslot was offered to a WaitlistEntry until the given point in time.
+func (h *DBHandler) SetWaitlistOffer(id int, expiresAt time.Time) error {
+	_, err := h.db.Exec("UPDATE waitlist SET offer_expires_at = $1 WHERE id = $2", expiresAt, id)
+	return err
+}
+
+// DeletePastWaitlistEntries removes all WaitlistEntry, whose timeslot already started, as they are irrelevant now.
+func (h *DBHandler) DeletePastWaitlistEntries(now time.Time) error {
+	_, err := h.db.Exec("DELETE FROM waitlist WHERE starttime <= $1", now)
+	return err
+}
+
+// queryWaitlist is a utility method to query and scan multiple WaitlistEntry.
+func (h *DBHandler) queryWaitlist(query string, args ...any) ([]WaitlistEntry, error) {
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]WaitlistEntry, 0)
+	for rows.Next() {
+		var entry WaitlistEntry
+		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End, &entry.CreatedAt, &entry.OfferExpiresAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// InsertTransfer opens a Transfer for the entry with the given id, given that it is owned by the given email address,
+// not an admin event, starts after the given point in time, and doesn't already seek a replacement.
+func (h *DBHandler) InsertTransfer(entryId int, email string, now time.Time) (*Transfer, error) {
+	res, err := h.db.Exec(`
+		INSERT INTO transfers (entry_id, email, created_at)
+		SELECT id, email, $3 FROM calendar_entries
+		WHERE id = $1 AND email = $2 AND event_type_id IS NULL AND starttime > $3
+		AND NOT EXISTS (
+			SELECT 1 FROM transfers
+			WHERE entry_id = $1 AND email = $2 AND closed_at IS NULL
+		)
+	`, entryId, email, now)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return nil, ErrTransferExists
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return h.GetOpenTransfer(int(id))
+}
+
+// GetOpenTransfer returns a single open Transfer. A Transfer is only open as long as its entry still exists and is
+// owned by the same email address, e.g. it is implicitly closed once the entry is deleted.
+func (h *DBHandler) GetOpenTransfer(id int) (*Transfer, error) {
+	var transfer Transfer
+	err := h.db.QueryRow(`
+		SELECT t.id, t.entry_id, t.created_at, e.id, e.firstname, e.starttime, e.endtime, e.event_type_id, e.series_id
+		FROM transfers t JOIN calendar_entries e ON e.id = t.entry_id AND e.email = t.email
+		WHERE t.id = $1 AND t.closed_at IS NULL
+	`, id).Scan(&transfer.Id, &transfer.EntryId, &transfer.CreatedAt, &transfer.Entry.Id, &transfer.Entry.FirstName,
+		&transfer.Entry.Start, &transfer.Entry.End, &transfer.Entry.EventTypeId, &transfer.Entry.SeriesId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transfer, nil
+}
+
+// GetOpenTransferForEntry returns the open Transfer of the entry with the given id, analogous to GetOpenTransfer.
+func (h *DBHandler) GetOpenTransferForEntry(entryId int) (*Transfer, error) {
+	var id int
+	err := h.db.QueryRow(`
+		SELECT t.id FROM transfers t JOIN calendar_entries e ON e.id = t.entry_id AND e.email = t.email
+		WHERE t.entry_id = $1 AND t.closed_at IS NULL
+	`, entryId).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.GetOpenTransfer(id)
+}
+
+// GetOpenTransfersForRange queries all open Transfer, whose entry starts within the interval between the given start
+// and end, analogous to GetOpenTransfer.
+func (h *DBHandler) GetOpenTransfersForRange(start, end time.Time) ([]Transfer, error) {
+	rows, err := h.db.Query(`
+		SELECT t.id, t.entry_id, t.created_at, e.id, e.firstname, e.starttime, e.endtime, e.event_type_id, e.series_id
+		FROM transfers t JOIN calendar_entries e ON e.id = t.entry_id AND e.email = t.email
+		WHERE e.starttime >= $1 AND e.starttime < $2 AND t.closed_at IS NULL
+		ORDER BY e.starttime ASC
+	`, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := make([]Transfer, 0)
+	for rows.Next() {
+		var transfer Transfer
+		if err := rows.Scan(&transfer.Id, &transfer.EntryId, &transfer.CreatedAt, &transfer.Entry.Id, &transfer.Entry.FirstName,
+			&transfer.Entry.Start, &transfer.Entry.End, &transfer.Entry.EventTypeId, &transfer.Entry.SeriesId); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
+
+// CloseTransfer withdraws the open Transfer of the entry with the given id, given the email address it was opened
+// with, and declines all its pending TransferProposal.
+func (h *DBHandler) CloseTransfer(entryId int, email string, now time.Time) error {
+	res, err := h.db.Exec("UPDATE transfers SET closed_at = $1 WHERE entry_id = $2 AND email = $3 AND closed_at IS NULL",
+		now, entryId, email)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTransferNotClosed
+	}
+
+	_, err = h.db.Exec(`
+		UPDATE transfer_proposals SET status = 'declined'
+		WHERE status = 'pending' AND transfer_id IN (SELECT id FROM transfers WHERE entry_id = $1 AND closed_at IS NOT NULL)
+	`, entryId)
+	return err
+}
+
+// InsertTransferProposal inserts a new pending TransferProposal.
+func (h *DBHandler) InsertTransferProposal(proposal TransferProposal) (*TransferProposal, error) {
+	res, err := h.db.Exec(`
+		INSERT INTO transfer_proposals (transfer_id, firstname, lastname, email, reminders, swap_entry_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, proposal.TransferId, proposal.FirstName, proposal.LastName, proposal.Email, proposal.Reminders, proposal.SwapEntryId, proposal.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	proposal.Id = int(id)
+	proposal.Status = "pending"
+
+	return &proposal, nil
+}
+
+// GetTransferProposal returns a single TransferProposal.
+func (h *DBHandler) GetTransferProposal(id int) (*TransferProposal, error) {
+	var proposal TransferProposal
+	err := h.db.QueryRow(`
+		SELECT id, transfer_id, firstname, lastname, email, reminders, swap_entry_id, created_at, status FROM transfer_proposals
+		WHERE id = $1
+	`, id).Scan(&proposal.Id, &proposal.TransferId, &proposal.FirstName, &proposal.LastName, &proposal.Email,
+		&proposal.Reminders, &proposal.SwapEntryId, &proposal.CreatedAt, &proposal.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proposal, nil
+}
+
+// DeclineTransferProposal declines a pending TransferProposal.
+func (h *DBHandler) DeclineTransferProposal(id int) error {
+	res, err := h.db.Exec("UPDATE transfer_proposals SET status = 'declined' WHERE id = $1 AND status = 'pending'", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrProposalNotDeclined
+	}
+	return nil
+}
+
+// CompleteTransfer accepts a pending TransferProposal and changes the ownership of the entries accordingly, i.e., the
+// entry of the Transfer goes to the proposing participant and, in case of a swap, their entry goes to the previous
+// owner. Everything happens within a single transaction, so either both sides change or nothing does, and only if
+// all involved entries are still owned as they were when the Transfer was opened and the TransferProposal was made.
+// Transferred entries leave their Series, as they no longer belong to the same participant.
+func (h *DBHandler) CompleteTransfer(proposalId int, now time.Time) ([]CalendarEntryFull, error) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	// A rollback after the commit is a no-op
+	defer tx.Rollback()
+
+	var proposal TransferProposal
+	var ownerEmail string
+	var entry CalendarEntryFull
+	err = tx.QueryRow(`
+		SELECT p.id, p.transfer_id, p.firstname, p.lastname, p.email, p.reminders, p.swap_entry_id, t.email,
+			e.id, e.firstname, e.lastname, e.email, e.starttime, e.endtime, e.event_type_id, e.series_id, e.reminders
+		FROM transfer_proposals p
+		JOIN transfers t ON t.id = p.transfer_id
+		JOIN calendar_entries e ON e.id = t.entry_id AND e.email = t.email
+		WHERE p.id = $1 AND p.status = 'pending' AND t.closed_at IS NULL AND e.starttime > $2
+	`, proposalId, now).Scan(&proposal.Id, &proposal.TransferId, &proposal.FirstName, &proposal.LastName, &proposal.Email,
+		&proposal.Reminders, &proposal.SwapEntryId, &ownerEmail,
+		&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId, &entry.Reminders)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransferNotAvailable
+	} else if err != nil {
+		return nil, err
+	}
+
+	transferred := CalendarEntryFull{
+		CalendarEntry: CalendarEntry{Id: entry.Id, FirstName: proposal.FirstName, Start: entry.Start, End: entry.End},
+		LastName:      proposal.LastName,
+		Email:         proposal.Email,
+		Reminders:     proposal.Reminders,
+	}
+	entries := []CalendarEntryFull{transferred}
+
+	if proposal.SwapEntryId != nil {
+		var swap CalendarEntryFull
+		err = tx.QueryRow(`
+			SELECT id, firstname, lastname, email, starttime, endtime, event_type_id, series_id, reminders FROM calendar_entries
+			WHERE id = $1 AND email = $2 AND event_type_id IS NULL AND starttime > $3
+		`, *proposal.SwapEntryId, proposal.Email, now).Scan(&swap.Id, &swap.FirstName, &swap.LastName, &swap.Email,
+			&swap.Start, &swap.End, &swap.EventTypeId, &swap.SeriesId, &swap.Reminders)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTransferNotAvailable
+		} else if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, CalendarEntryFull{
+			CalendarEntry: CalendarEntry{Id: swap.Id, FirstName: entry.FirstName, Start: swap.Start, End: swap.End},
+			LastName:      entry.LastName,
+			Email:         entry.Email,
+			Reminders:     entry.Reminders,
+		})
+	}
+
+	for _, e := range entries {
+		_, err := tx.Exec(`
+			UPDATE calendar_entries SET firstname = $1, lastname = $2, email = $3, reminders = $4, series_id = NULL
+			WHERE id = $5
+		`, e.FirstName, e.LastName, e.Email, e.Reminders, e.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		// The new owner receives their own reminders
+		if _, err := tx.Exec("DELETE FROM sent_reminders WHERE entry_id = $1", e.Id); err != nil {
+			return nil, err
+		}
+		// Any other open Transfer of the entry was made by the previous owner
+		if _, err := tx.Exec("UPDATE transfers SET closed_at = $1 WHERE entry_id = $2 AND closed_at IS NULL", now, e.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE transfer_proposals SET status = CASE WHEN id = $1 THEN 'accepted' ELSE 'declined' END
+		WHERE transfer_id = $2 AND status = 'pending'
+	`, proposal.Id, proposal.TransferId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// InsertGroup inserts a new Group together with its entries, given that none of them conflicts with the existing
+// data. Everything happens within a single transaction, so the block is either booked as a whole or not at all.
+func (h *DBHandler) InsertGroup(group Group, entries []CalendarEntryFull) (*Group, []CalendarEntryFull, error) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	// A rollback after the commit is a no-op
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		INSERT INTO calendar_groups (name, firstname, lastname, email, starttime, endtime, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, group.Name, group.FirstName, group.LastName, group.Email, group.Start, group.End, group.CreatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, nil, err
+	}
+	group.Id = int(id)
+
+	for i := range entries {
+		res, err := tx.Exec(`
+			INSERT INTO calendar_entries (firstname, lastname, email, starttime, endtime, reminders, group_id)
+			SELECT $1, $2, $3, $4, $5, $6, $7
+			WHERE NOT EXISTS (`+conflictingEntries("$4", "$5", "NULL")+`)
+		`, entries[i].FirstName, entries[i].LastName, entries[i].Email, entries[i].Start, entries[i].End, entries[i].Reminders, group.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n, _ := res.RowsAffected(); n != 1 {
+			return nil, nil, ErrTimeslotOverlap
+		}
+
+		entryId, err := res.LastInsertId()
+		if err != nil {
+			return nil, nil, err
+		}
+		entries[i].Id = int(entryId)
+		entries[i].GroupId = &group.Id
+		entries[i].GroupName = &group.Name
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return &group, entries, nil
+}
+
+// GetGroup returns a single Group.
+func (h *DBHandler) GetGroup(id int) (*Group, error) {
+	var group Group
+	err := h.db.QueryRow(`
+		SELECT id, name, firstname, lastname, email, starttime, endtime, created_at FROM calendar_groups
+		WHERE id = $1
+	`, id).Scan(&group.Id, &group.Name, &group.FirstName, &group.LastName, &group.Email, &group.Start, &group.End, &group.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+// GetGroupEntries returns all the CalendarEntryFull of a Group, whether claimed by a member or not.
+func (h *DBHandler) GetGroupEntries(groupId int) ([]CalendarEntryFull, error) {
+	rows, err := h.db.Query(`
+		SELECT e.id, e.firstname, e.lastname, e.email, e.starttime, e.endtime, e.event_type_id, e.series_id, e.reminders, e.group_id, g.name
+		FROM calendar_entries e JOIN calendar_groups g ON g.id = e.group_id
+		WHERE e.group_id = $1
+		ORDER BY e.starttime ASC
+	`, groupId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]CalendarEntryFull, 0)
+	for rows.Next() {
+		var entry CalendarEntryFull
+		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End, &entry.EventTypeId, &entry.SeriesId, &entry.Reminders, &entry.GroupId, &entry.GroupName); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// ClaimGroupEntry assigns an entry of a Group to a member, given that it is still unclaimed, i.e., owned by the group
+// contact, and starts after the given point in time.
+func (h *DBHandler) ClaimGroupEntry(groupId, entryId int, member CalendarEntryFull, now time.Time) error {
+	res, err := h.db.Exec(`
+		UPDATE calendar_entries SET firstname = $1, lastname = $2, email = $3, reminders = $4
+		WHERE id = $5 AND group_id = $6 AND starttime > $7
+		AND email = (SELECT email FROM calendar_groups WHERE id = $6)
+	`, member.FirstName, member.LastName, member.Email, member.Reminders, entryId, groupId, now)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return ErrEntryNotClaimed
+	}
+	return nil
+}
+
+// DeleteGroup deletes all CalendarEntry of a Group and then the Group itself, given the email address of the group
+// contact, analogous to DeleteSeries.
+func (h *DBHandler) DeleteGroup(id int, email string) error {
+	res, err := h.db.Exec(`
+		DELETE FROM calendar_entries
+		WHERE group_id = $1 AND EXISTS (SELECT 1 FROM calendar_groups WHERE id = $1 AND email = $2)
+	`, id, email)
+	if err != nil {
+		return err
+	}
+	if nrOfRows, err := res.RowsAffected(); nrOfRows == 0 || err != nil {
+		return ErrGroupNotDeleted
+	}
+	h.db.Exec("DELETE FROM calendar_groups WHERE id = $1", id)
+	return nil
+}
+
+// DeleteGroupAdmin does the same as DeleteGroup, but doesn't require an email, since only the admin should be able to do this.
+func (h *DBHandler) DeleteGroupAdmin(id int) error {
+	res, err := h.db.Exec("DELETE FROM calendar_entries WHERE group_id = $1", id)
+	if err != nil {
+		return err
+	}
+	if nrOfRows, err := res.RowsAffected(); nrOfRows == 0 || err != nil {
+		return ErrGroupNotDeleted
+	}
+	h.db.Exec("DELETE FROM calendar_groups WHERE id = $1", id)
+	return nil
+}
+
+// insertEventTypeQuery inserts an EventType with the arguments of eventTypeArgs.
+const insertEventTypeQuery = `
+	INSERT INTO event_types (name, color, icon, blocks_bookings, counts_as_coverage, translations)
+	VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+// eventTypeArgs provides the columns of an EventType in the order of insertEventTypeQuery, with the translations
+// stored as JSON.
+func eventTypeArgs(eventType EventType) []any {
+	translations := eventType.Translations
+	if translations == nil {
+		translations = make(map[string]string)
+	}
+	// a map of strings can always be marshalled
+	encoded, _ := json.Marshal(translations)
+
+	return []any{eventType.Name, eventType.Color, eventType.Icon, eventType.BlocksBookings, eventType.CountsAsCoverage, string(encoded)}
+}
+
+// GetEventTypes returns all EventType, ordered by their creation.
+func (h *DBHandler) GetEventTypes() ([]EventType, error) {
+	rows, err := h.db.Query(`
+		SELECT id, name, color, icon, blocks_bookings, counts_as_coverage, translations FROM event_types
+		ORDER BY id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	eventTypes := make([]EventType, 0)
+	for rows.Next() {
+		var eventType EventType
+		var translations string
+		if err := rows.Scan(&eventType.Id, &eventType.Name, &eventType.Color, &eventType.Icon, &eventType.BlocksBookings,
+			&eventType.CountsAsCoverage, &translations); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(translations), &eventType.Translations); err != nil {
+			return nil, err
+		}
+		eventTypes = append(eventTypes, eventType)
+	}
+
+	return eventTypes, nil
+}
+
+// GetEventType returns a single EventType.
+func (h *DBHandler) GetEventType(id int) (*EventType, error) {
+	var eventType EventType
+	var translations string
+	err := h.db.QueryRow(`
+		SELECT id, name, color, icon, blocks_bookings, counts_as_coverage, translations FROM event_types
+		WHERE id = $1
+	`, id).Scan(&eventType.Id, &eventType.Name, &eventType.Color, &eventType.Icon, &eventType.BlocksBookings,
+		&eventType.CountsAsCoverage, &translations)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(translations), &eventType.Translations); err != nil {
+		return nil, err
+	}
+
+	return &eventType, nil
+}
+
+// InsertEventType inserts a new EventType.
+func (h *DBHandler) InsertEventType(eventType EventType) (*EventType, error) {
+	res, err := h.db.Exec(insertEventTypeQuery, eventTypeArgs(eventType)...)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return h.GetEventType(int(id))
+}
+
+// UpdateEventType replaces all fields of an existing EventType. The changed rules apply to all its entries, including
+// the existing ones.
+func (h *DBHandler) UpdateEventType(eventType EventType) (*EventType, error) {
+	args := append(eventTypeArgs(eventType), eventType.Id)
+	res, err := h.db.Exec(`
+		UPDATE event_types
+		SET name = $1, color = $2, icon = $3, blocks_bookings = $4, counts_as_coverage = $5, translations = $6
+		WHERE id = $7
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	if nrOfRows, err := res.RowsAffected(); nrOfRows != 1 || err != nil {
+		return nil, ErrEventTypeNotFound
+	}
+
+	return h.GetEventType(eventType.Id)
+}
+
+// DeleteEventType deletes an EventType, given that no entry references it anymore.
+func (h *DBHandler) DeleteEventType(id int) error {
+	res, err := h.db.Exec(`
+		DELETE FROM event_types
+		WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM calendar_entries WHERE event_type_id = $1)
+	`, id)
+	if err != nil {
+		return err
+	}
+	if nrOfRows, err := res.RowsAffected(); nrOfRows != 1 || err != nil {
+		var exists bool
+		if err := h.db.QueryRow("SELECT EXISTS (SELECT 1 FROM event_types WHERE id = $1)", id).Scan(&exists); err == nil && exists {
+			return ErrEventTypeInUse
+		}
+		return ErrEventTypeNotFound
+	}
+	return nil
+}
+
+// GetOverlappingFullEntries returns all CalendarEntryFull overlapping the interval between start and end, i.e., the
+// entries that may conflict with a new entry there, unlike GetAllEntriesForRange, which includes touching entries.
+func (h *DBHandler) GetOverlappingFullEntries(start, end time.Time) ([]CalendarEntryFull, error) {
+	rows, err := h.db.Query(`
+		SELECT e.id, e.firstname, e.lastname, e.email, e.starttime, e.endtime, e.event_type_id, e.series_id, e.reminders, e.group_id, g.name
+		FROM calendar_entries e LEFT JOIN calendar_groups g ON g.id = e.group_id
+		WHERE e.starttime < $2 AND e.endtime > $1
+		ORDER BY e.starttime ASC
+	`, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]CalendarEntryFull, 0)
+	for rows.Next() {
+		var entry CalendarEntryFull
+		if err := rows.Scan(&entry.Id, &entry.FirstName, &entry.LastName, &entry.Email, &entry.Start, &entry.End,
+			&entry.EventTypeId, &entry.SeriesId, &entry.Reminders, &entry.GroupId, &entry.GroupName); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// DisplaceEntries removes or shortens the entries of participants according to the given displacements, and inserts
+// the given entries of admin events in their place. If a Series is given, it is inserted as well and composed of the
+// entries. An entry keeping several remaining intervals is shortened to the first one, while the others become copies
+// of it. Everything happens within a transaction, so nothing is displaced, if the entries changed in the meantime or
+// the admin events still conflict. It returns the inserted entries as well as the shortened entries and their copies.
+func (h *DBHandler) DisplaceEntries(displacements []Displacement, series *Series, entries []CalendarEntryFull) ([]CalendarEntryFull, []CalendarEntryFull, error) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	// A rollback after the commit is a no-op
+	defer tx.Rollback()
+
+	if series != nil {
+		res, err := tx.Exec("INSERT INTO calendar_series (interval, repetitions) VALUES ($1, $2)", series.Interval, series.Repetitions)
+		if err != nil {
+			return nil, nil, err
+		}
+		seriesId, err := res.LastInsertId()
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range entries {
+			id := int(seriesId)
+			entries[i].SeriesId = &id
+		}
+	}
+
+	remainders := make([]CalendarEntryFull, 0)
+	for _, displacement := range displacements {
+		entry := displacement.Entry
+
+		var res sql.Result
+		if len(displacement.Remains) == 0 {
+			res, err = tx.Exec(`
+				DELETE FROM calendar_entries
+				WHERE id = $1 AND starttime = $2 AND endtime = $3 AND event_type_id IS NULL
+			`, entry.Id, entry.Start, entry.End)
+		} else {
+			res, err = tx.Exec(`
+				UPDATE calendar_entries SET starttime = $4, endtime = $5
+				WHERE id = $1 AND starttime = $2 AND endtime = $3 AND event_type_id IS NULL
+			`, entry.Id, entry.Start, entry.End, displacement.Remains[0].Start, displacement.Remains[0].End)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if n, _ := res.RowsAffected(); n != 1 {
+			return nil, nil, ErrEntryNotDisplaced
+		}
+		if len(displacement.Remains) == 0 {
+			continue
+		}
+
+		// The reminders are sent anew for the changed timeslot
+		if _, err := tx.Exec("DELETE FROM sent_reminders WHERE entry_id = $1", entry.Id); err != nil {
+			return nil, nil, err
+		}
+		entry.Start, entry.End = displacement.Remains[0].Start, displacement.Remains[0].End
+		remainders = append(remainders, entry)
+
+		for _, remain := range displacement.Remains[1:] {
+			res, err := tx.Exec(`
+				INSERT INTO calendar_entries (firstname, lastname, email, starttime, endtime, series_id, reminders, group_id)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			`, entry.FirstName, entry.LastName, entry.Email, remain.Start, remain.End, entry.SeriesId, entry.Reminders, entry.GroupId)
+			if err != nil {
+				return nil, nil, err
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			copied := entry
+			copied.Id = int(id)
+			copied.Start, copied.End = remain.Start, remain.End
+			remainders = append(remainders, copied)
+		}
+	}
+
+	for i := range entries {
+		res, err := tx.Exec(`
+			INSERT INTO calendar_entries (firstname, lastname, email, starttime, endtime, event_type_id, series_id, reminders)
+			SELECT $1, $2, $3, $4, $5, $6, $7, $8
+			WHERE NOT EXISTS (`+conflictingEntries("$4", "$5", "$6")+`)
+		`, entries[i].FirstName, entries[i].LastName, entries[i].Email, entries[i].Start, entries[i].End, entries[i].EventTypeId,
+			entries[i].SeriesId, entries[i].Reminders)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n, _ := res.RowsAffected(); n != 1 {
+			return nil, nil, ErrTimeslotOverlap
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, nil, err
+		}
+		entries[i].Id = int(id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return entries, remainders, nil
+}
+
+// InsertLateCancellations records the given LateCancellation, which are all part of the same cancellation.
+func (h *DBHandler) InsertLateCancellations(cancellations []LateCancellation) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	// A rollback after the commit is a no-op
+	defer tx.Rollback()
+
+	for _, c := range cancellations {
+		_, err := tx.Exec(`
+			INSERT INTO late_cancellations(entry_id, firstname, lastname, email, starttime, endtime, reason, cancelled_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, c.EntryId, c.FirstName, c.LastName, c.Email, c.Start, c.End, c.Reason, c.CancelledAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetLateCancellations queries all LateCancellation, sorted by participant and then by time of cancellation. If an
+// email is given, only the LateCancellation of that participant are returned.
+func (h *DBHandler) GetLateCancellations(email string) ([]LateCancellation, error) {
+	rows, err := h.db.Query(`
+		SELECT id, entry_id, firstname, lastname, email, starttime, endtime, reason, cancelled_at FROM late_cancellations
+		WHERE $1 = '' OR email = $1
+		ORDER BY email ASC, cancelled_at ASC
+	`, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cancellations := make([]LateCancellation, 0)
+	for rows.Next() {
+		var c LateCancellation
+		if err := rows.Scan(&c.Id, &c.EntryId, &c.FirstName, &c.LastName, &c.Email, &c.Start, &c.End, &c.Reason, &c.CancelledAt); err != nil {
+			return nil, err
+		}
+		cancellations = append(cancellations, c)
+	}
+
+	return cancellations, nil
+}
@@ -0,0 +1,174 @@
+// Provides the digest of uncovered timeslots, which is periodically sent to the volunteers
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+)
+
+// digestFrequencies are the valid values of Volunteer.DigestFrequency
+var digestFrequencies = []string{"daily", "weekly", "never"}
+
+// DigestSender sends the volunteers a summary of the uncovered timeslots of the upcoming days. Contrary to the alerts
+// on short-notice cancellations, this also includes timeslots that were never booked in the first place. It is
+// supposed to be run as a job of the scheduler according to the configured schedule.
+type DigestSender struct {
+	db     *DBHandler
+	config *Config
+}
+
+// NewDigestSender is the constructor for DigestSender.
+func NewDigestSender(db *DBHandler, config *Config) *DigestSender {
+	return &DigestSender{db: db, config: config}
+}
+
+// Run sends the digest and conforms to scheduler.JobFunc.
+func (d *DigestSender) Run(_ context.Context, _ string) error {
+	return d.send(time.Now())
+}
+
+// send computes the uncovered timeslots from now on and sends them to every volunteer whose frequency is due. If
+// there are no gaps, nobody needs to be bothered.
+func (d *DigestSender) send(now time.Time) error {
+	year, month, day := now.Date()
+	from := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, d.config.DigestDays-1)
+
+	entries, err := d.db.GetAllEntriesForRange(from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	types, err := loadEventTypes(d.db)
+	if err != nil {
+		return err
+	}
+	report := buildCoverageReport(entries, types, from, to, time.Hour, d.config.NightStartHour, d.config.NightEndHour)
+
+	// Only gaps that are still ahead are relevant
+	gaps := make([]Interval, 0)
+	for _, gap := range splitByDay(report.Gaps) {
+		if !gap.End.After(now) {
+			continue
+		}
+		if gap.Start.Before(now) {
+			gap.Start = now.Truncate(time.Hour)
+		}
+		gaps = append(gaps, gap)
+	}
+	if len(gaps) == 0 {
+		log.Println("[digest] No uncovered timeslots, skipping digest")
+		return nil
+	}
+
+	volunteers, err := d.db.GetDigestRecipients()
+	if err != nil {
+		return err
+	}
+
+	recipients := make([]Volunteer, 0)
+	for _, volunteer := range volunteers {
+		// A small tolerance ensures that a weekly digest isn't skipped just because the previous run was a bit late
+		if volunteer.DigestFrequency == "weekly" && volunteer.LastDigestAt != nil &&
+			now.Sub(*volunteer.LastDigestAt) < 7*24*time.Hour-time.Hour {
+			continue
+		}
+		recipients = append(recipients, volunteer)
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	if err := sendDigestEmail(recipients, gaps); err != nil {
+		return err
+	}
+
+	for _, volunteer := range recipients {
+		if err := d.db.MarkDigestSent(volunteer.Id, now); err != nil {
+			log.Println("[digest] Failed to mark digest as sent:", err)
+		}
+	}
+
+	return nil
+}
+
+// createSlotLink creates a deep link to the calendar page of the UI, which directly opens the given timeslot.
+func createSlotLink(start time.Time) string {
+	return fmt.Sprintf("%s/calendar?date=%s&hour=%d", os.Getenv("HOST_FE"), start.Format("2006-01-02"), start.Hour())
+}
+
+// createDigestPreferenceLink creates a link for a volunteer to change their digest frequency via GetDigestPreference.
+func createDigestPreferenceLink(volunteer Volunteer, frequency string) string {
+	return fmt.Sprintf("%s/api/volunteer/digest?email=%s&token=%s&frequency=%s", os.Getenv("HOST_BE"),
+		url.QueryEscape(volunteer.Email), volunteer.ConfirmationToken, frequency)
+}
+
+// parseDigestPreference extracts and checks the email address and frequency of a digest preference link.
+func parseDigestPreference(values url.Values) (string, string, error) {
+	email := values.Get("email")
+	if err := validateVolunteerEmail(email); err != nil {
+		return "", "", err
+	}
+
+	frequency := values.Get("frequency")
+	if !slices.Contains(digestFrequencies, frequency) {
+		return "", "", ErrInvalidFrequency
+	}
+
+	return email, frequency, nil
+}
+
+// GetDigestPreference provides the page for a volunteer to confirm changing how often they receive the digest of
+// uncovered timeslots, which is only changed via PostDigestPreference, as mail scanners and link prefetchers open
+// links on their own. As this method is supposed to be directly accessed via a link in the digest email, it responds
+// with a simple HTML page.
+func (h *ApiHandler) GetDigestPreference(w http.ResponseWriter, r *http.Request) {
+	email, frequency, err := parseDigestPreference(r.URL.Query())
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	message := map[string]string{
+		"daily":  "Möchtest du die Übersicht über offene Timeslots ab jetzt täglich erhalten?",
+		"weekly": "Möchtest du die Übersicht über offene Timeslots ab jetzt wöchentlich erhalten?",
+		"never":  "Möchtest du keine Übersicht über offene Timeslots mehr erhalten? Benachrichtigungen über kurzfristige Ausfälle erhältst du weiterhin.",
+	}[frequency]
+
+	writeConfirmPage(w, "Einstellung ändern", message, os.Getenv("PATH_PREFIX")+"/api/volunteer/digest",
+		map[string]string{"email": email, "token": r.URL.Query().Get("token"), "frequency": frequency}, "Bestätigen")
+}
+
+// PostDigestPreference changes how often a volunteer receives the digest of uncovered timeslots, based on the form of
+// GetDigestPreference.
+func (h *ApiHandler) PostDigestPreference(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	email, frequency, err := parseDigestPreference(r.PostForm)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	if err := h.db.SetDigestFrequency(email, r.PostForm.Get("token"), frequency); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	message := map[string]string{
+		"daily":  "Du erhältst die Übersicht über offene Timeslots ab jetzt täglich.",
+		"weekly": "Du erhältst die Übersicht über offene Timeslots ab jetzt wöchentlich.",
+		"never":  "Du erhältst ab jetzt keine Übersicht über offene Timeslots mehr. Benachrichtigungen über kurzfristige Ausfälle erhältst du weiterhin.",
+	}[frequency]
+
+	writeHtmlPage(w, http.StatusOK, "Einstellung gespeichert", message)
+}
@@ -11,10 +11,13 @@ type CalendarEntry struct {
 	FirstName string
 	Start     time.Time
 	End       time.Time
-	// AdminEvent is an optional string signifying some extraordinary circumstances.
+	// EventTypeId optionally references the EventType of an admin event, signifying some extraordinary circumstances.
 	// It implies that the personal information is empty.
-	AdminEvent *string
-	SeriesId   *int
+	EventTypeId *int
+	SeriesId    *int
+	// GroupId is set for the entries of a Group, which are publicly shown under the GroupName
+	GroupId   *int
+	GroupName *string
 }
 
 // CalendarEntryFull is an extension of CalendarEntry, thus also corresponding to the table "calendar_entries", with
@@ -23,6 +26,8 @@ type CalendarEntryFull struct {
 	CalendarEntry
 	LastName string
 	Email    string
+	// Reminders signifies that the owner wants to be reminded of the entry via email before it starts
+	Reminders bool
 }
 
 // Series corresponds to the table "calendar_series" and mainly serves to capture the meta information of a series for traceability.
@@ -39,6 +44,13 @@ type SeriesRequest struct {
 	Entry  CalendarEntryFull
 }
 
+// SeriesResponse is purely a response REST-DTO for a Series posted while skipping its conflicts. It contains the Booked
+// entries and the Conflict of each Skipped entry, which are recorded as exceptions of the Series.
+type SeriesResponse struct {
+	Booked  []CalendarEntryFull
+	Skipped []Conflict
+}
+
 // Volunteer corresponds to the table "volunteers" and captures the email addresses of volunteers for automated emails,
 // and confirmation information to facilitate the consent for those automated emails.
 type Volunteer struct {
@@ -46,4 +58,181 @@ type Volunteer struct {
 	Email             string
 	Confirmed         bool
 	ConfirmationToken string
+	// DigestFrequency is either "daily", "weekly", or "never" and decides how often the volunteer receives the digest
+	// of uncovered timeslots
+	DigestFrequency string
+	LastDigestAt    *time.Time
+}
+
+// PendingAlert corresponds to the table "pending_alerts" and captures a short-notice cancellation, whose notification
+// of the volunteers is deferred until DueAt, to give the timeslot the chance to be booked again in the meantime.
+type PendingAlert struct {
+	Id    int
+	Start time.Time
+	End   time.Time
+	DueAt time.Time
+	// Stage is the index of the next stage of the escalation policy, which is due at DueAt
+	Stage int
+}
+
+// Attendance corresponds to the table "attendance", joined with the participant information of its CalendarEntryFull.
+// It records whether a participant actually showed up for their entry.
+type Attendance struct {
+	EntryId   int
+	FirstName string
+	LastName  string
+	Email     string
+	Start     time.Time
+	End       time.Time
+	// CheckedInAt is nil, if the participant did not check in (yet)
+	CheckedInAt *time.Time
+	// Method is either "link", i.e., the personal check-in link, or "chapel", i.e., the QR code in the chapel
+	Method *string
+	// MissedAlertAt is set, if an alert was raised because of a missing check-in
+	MissedAlertAt *time.Time
+}
+
+// LateCancellation corresponds to the table "late_cancellations" and records a CalendarEntry that was cancelled on short
+// notice, i.e., within the alert window, together with its original owner and their Reason.
+type LateCancellation struct {
+	Id          int
+	EntryId     int
+	FirstName   string
+	LastName    string
+	Email       string
+	Start       time.Time
+	End         time.Time
+	Reason      string
+	CancelledAt time.Time
+}
+
+// LateCancellationHistory bundles all LateCancellation of a single participant, identified by their Email.
+type LateCancellationHistory struct {
+	FirstName     string
+	LastName      string
+	Email         string
+	Count         int
+	Cancellations []LateCancellation
+}
+
+// WaitlistEntry corresponds to the table "waitlist" and captures a user queueing for an occupied timeslot. Once the
+// timeslot is freed, the first user in the queue is offered it or directly assigned, depending on the waitlist policy.
+type WaitlistEntry struct {
+	Id        int
+	FirstName string
+	LastName  string
+	Email     string
+	Start     time.Time
+	End       time.Time
+	CreatedAt time.Time
+	// OfferExpiresAt is set, once the freed timeslot was offered to the user, and nil while they are still waiting
+	OfferExpiresAt *time.Time
+}
+
+// Transfer corresponds to the table "transfers" and captures an entry, whose owner seeks a replacement. The entry
+// stays owned and covered, until the owner confirms a TransferProposal of another participant.
+type Transfer struct {
+	Id        int
+	EntryId   int
+	CreatedAt time.Time
+	// Entry is the publicly visible information of the offered entry
+	Entry CalendarEntry
+}
+
+// TransferProposal corresponds to the table "transfer_proposals" and captures a participant, who offers to take over
+// the entry of a Transfer. With SwapEntryId, they propose to swap it with one of their own entries instead.
+type TransferProposal struct {
+	Id          int
+	TransferId  int
+	FirstName   string
+	LastName    string
+	Email       string
+	Reminders   bool
+	SwapEntryId *int
+	CreatedAt   time.Time
+	// Status is one of "pending", "accepted" or "declined"
+	Status string
+}
+
+// Group corresponds to the table "calendar_groups" and captures a block booking of a parish group, e.g. the choir. The
+// block is split into hourly entries, which initially belong to the group contact, until group members claim them.
+type Group struct {
+	Id        int
+	Name      string
+	FirstName string
+	LastName  string
+	Email     string
+	Start     time.Time
+	End       time.Time
+	CreatedAt time.Time
+}
+
+// GroupDetails is purely a response REST-DTO, combining a Group with its entries and the invite link for its members.
+// As the entries contain the personal information of the members, it is only meant for the group contact or admin.
+type GroupDetails struct {
+	Group      Group
+	InviteLink string
+	Entries    []CalendarEntryFull
+}
+
+// EventType corresponds to the table "event_types" and defines a kind of admin event, e.g. a mass, together with its
+// presentation and the rules it imposes on the calendar.
+type EventType struct {
+	Id   int
+	Name string
+	// Color is a CSS color, e.g. "#ef4444"
+	Color string
+	// Icon is the name of a lucide icon, e.g. "Church"
+	Icon string
+	// BlocksBookings signifies that participants can't book the time of such an event
+	BlocksBookings bool
+	// CountsAsCoverage signifies that the time of such an event is covered, e.g. by a mass. If it neither counts as
+	// coverage nor allows bookings, the time is not required to be covered at all, e.g. because the chapel is closed.
+	CountsAsCoverage bool
+	// Translations maps a language code to the localised name, falling back to Name for missing languages
+	Translations map[string]string
+}
+
+// Displacement is purely a response REST-DTO, describing how the entry of a participant gives way to overriding admin
+// events. The entry is removed, if nothing Remains, and shortened to the remaining intervals otherwise.
+type Displacement struct {
+	Entry   CalendarEntryFull
+	Remains []Interval
+}
+
+// Conflict describes a Requested interval of a new entry that collides with existing Entries, which only expose their
+// public fields. Alternative is the nearest free interval of the same length, if there is one.
+type Conflict struct {
+	Requested   Interval
+	Entries     []CalendarEntry
+	Alternative *Interval
+}
+
+// Slot is a single slot of the slot grid, whose occupancy is given by the Entries overlapping it, which only expose
+// their public fields.
+type Slot struct {
+	Interval
+	Entries []CalendarEntry
+}
+
+// Problem is the response body of all failures, following RFC 7807 "Problem Details for HTTP APIs". Unlike the other
+// DTOs, it uses the member names of the RFC. It is extended by the stable Code of the DomainError, by the Conflicts of
+// entries, if it can explain them, and by the field Errors of an invalid request.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	Conflicts []Conflict   `json:"conflicts,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is a single violated rule of a request. Field names the offending member, e.g. "Entry.Start", while Code
+// and Detail are those of the DomainError of the rule.
+type FieldError struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
 }
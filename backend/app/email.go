@@ -6,7 +6,10 @@ package app
 
 import (
 	"fmt"
+	"html"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -49,12 +52,67 @@ func sendConfirmationEmail(email, confirmationLink string) error {
 	return err
 }
 
-// sendNotificationEmail is supposed to be used if a timeslot in the near future is freed up. It informs the volunteer
+// sendNotificationEmail is supposed to be used if a timeslot in the near future is freed up. It informs the volunteers
 // of the timeslot that opened up and provides a direct link to the calendar page of the UI for easy access.
-func sendNotificationEmail(emails []string, start, end time.Time) error {
+//
+// Additionally, every volunteer receives an individual email with a personal claim link, given as a map from email
+// address to link, which allows them to book the timeslot in a single click.
+func sendNotificationEmail(claimLinks map[string]string, start, end time.Time) error {
+	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
+
+	calendarLink := calendarPageLink()
+	dateStr := start.Format("02.01.2006")
+	startTimeStr := start.Format("15:04")
+	endTimeStr := end.Format("15:04")
+
+	requests := make([]*resend.SendEmailRequest, 0, len(claimLinks))
+	for email, claimLink := range claimLinks {
+		requests = append(requests, &resend.SendEmailRequest{
+			From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
+			To:      []string{email},
+			Subject: fmt.Sprintf("Ausfall am %s um %s-%s - 24/7 Anbetung St. Pölten", dateStr, startTimeStr, endTimeStr),
+			Html: fmt.Sprintf(`
+				<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+					<h2 style="color: #c0392b; border-bottom: 2px solid #c0392b; padding-bottom: 10px;">Ausfall am %s um %s-%s</h2>
+					<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+					
+					<p style="text-align: justify;">Jemand hat sich kurzfristig vom Timeslot am %s für <strong>%s bis %s</strong> abgemeldet.</p>
+					
+					<p style="text-align: justify;">Falls du einspringen kannst, übernimm den Timeslot direkt über deinen persönlichen Link:</p>
+					
+					<div style="text-align: center; margin: 30px 0;">
+						<a href="%s" style="background-color: #27ae60; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Timeslot übernehmen</a>
+					</div>
+					
+					<p style="text-align: justify;">Alternativ kannst du dich auch selbst im Kalender eintragen:</p>
+					
+					<div style="text-align: center; margin: 30px 0;">
+						<a href="%s" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Zum Kalender</a>
+					</div>
+					
+					<hr style="border: 0; border-top: 1px solid #eeeeee; margin-top: 30px;">
+					
+					<p style="font-size: 12px; color: #7f8c8d;">Vielen Dank für deinen wertvollen Dienst in der Anbetung!</p>
+				</div>
+			`, dateStr, startTimeStr, endTimeStr, dateStr, startTimeStr, endTimeStr, claimLink, calendarLink),
+		})
+	}
+
+	// The batch API of resend accepts at most 100 emails per call
+	for chunk := range slices.Chunk(requests, 100) {
+		if _, err := client.Batch.Send(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendEscalationEmail is supposed to be used as a last resort, if a timeslot freed on short notice is still empty
+// shortly before it starts. It informs the coordinators, who are then responsible for filling it.
+func sendEscalationEmail(emails []string, start, end time.Time) error {
 	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
 
-	calendarLink := fmt.Sprintf("%s/calendar", os.Getenv("HOST_FE"))
 	dateStr := start.Format("02.01.2006")
 	startTimeStr := start.Format("15:04")
 	endTimeStr := end.Format("15:04")
@@ -63,25 +121,62 @@ func sendNotificationEmail(emails []string, start, end time.Time) error {
 		From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
 		To:      []string{"volunteers@24-7fastenzeitgebet.com"},
 		Bcc:     emails,
-		Subject: fmt.Sprintf("Ausfall am %s um %s-%s - 24/7 Anbetung St. Pölten", dateStr, startTimeStr, endTimeStr),
+		Subject: fmt.Sprintf("Dringend: Unbesetzter Timeslot am %s um %s-%s - 24/7 Anbetung St. Pölten", dateStr, startTimeStr, endTimeStr),
 		Html: fmt.Sprintf(`
 			<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
-				<h2 style="color: #c0392b; border-bottom: 2px solid #c0392b; padding-bottom: 10px;">Ausfall am %s um %s-%s</h2>
+				<h2 style="color: #c0392b; border-bottom: 2px solid #c0392b; padding-bottom: 10px;">Unbesetzter Timeslot am %s um %s-%s</h2>
 				<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
 				
-				<p style="text-align: justify;">Jemand hat sich kurzfristig vom Timeslot am %s für <strong>%s bis %s</strong> abgemeldet.</p>
+				<p style="text-align: justify;">Der Timeslot am %s für <strong>%s bis %s</strong> ist trotz Benachrichtigung der Freiwilligen weiterhin unbesetzt.</p>
 				
-				<p style="text-align: justify;">Falls du einspringen kannst, melde dich bitte im Kalender an:</p>
+				<p style="text-align: justify;">Bitte kümmere dich als Koordinator:in darum, dass jemand einspringt:</p>
 				
 				<div style="text-align: center; margin: 30px 0;">
-					<a href="%s" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Zum Kalender</a>
+					<a href="%s" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Zum Timeslot</a>
 				</div>
+			</div>
+		`, dateStr, startTimeStr, endTimeStr, dateStr, startTimeStr, endTimeStr, createSlotLink(start)),
+	}
+
+	_, err := client.Emails.Send(params)
+	return err
+}
+
+// sendLateCancellationEmail informs the coordinators about entries cancelled on short notice, i.e., who dropped out of
+// which timeslots and why, so they can follow up on it.
+func sendLateCancellationEmail(emails []string, cancellations []LateCancellation) error {
+	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
+
+	first := cancellations[0]
+	participant := html.EscapeString(fmt.Sprintf("%s %s (%s)", first.FirstName, first.LastName, first.Email))
+
+	var slotRows strings.Builder
+	for _, c := range cancellations {
+		slotRows.WriteString(fmt.Sprintf(`
+					<li style="margin-bottom: 6px;">
+						<a href="%s" style="color: #2c3e50; text-decoration: underline;">%s, <strong>%s bis %s</strong></a>
+					</li>`, createSlotLink(c.Start), c.Start.Format("02.01.2006"), c.Start.Format("15:04"), c.End.Format("15:04")))
+	}
+
+	params := &resend.SendEmailRequest{
+		From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
+		To:      []string{"volunteers@24-7fastenzeitgebet.com"},
+		Bcc:     emails,
+		Subject: fmt.Sprintf("Kurzfristige Absage von %s %s - 24/7 Anbetung St. Pölten", first.FirstName, first.LastName),
+		Html: fmt.Sprintf(`
+			<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+				<h2 style="color: #c0392b; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">Kurzfristige Absage</h2>
+				<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
 				
-				<hr style="border: 0; border-top: 1px solid #eeeeee; margin-top: 30px;">
+				<p style="text-align: justify;"><strong>%s</strong> hat die folgenden Timeslots kurzfristig abgesagt:</p>
 				
-				<p style="font-size: 12px; color: #7f8c8d;">Vielen Dank für deinen wertvollen Dienst in der Anbetung!</p>
+				<ul>%s
+				</ul>
+				
+				<p style="text-align: justify;">Angegebener Grund:</p>
+				<blockquote style="margin: 0 0 20px 0; padding: 10px 15px; border-left: 4px solid #f1c40f; background-color: #fdfaf0;">%s</blockquote>
 			</div>
-		`, dateStr, startTimeStr, endTimeStr, dateStr, startTimeStr, endTimeStr, calendarLink),
+		`, participant, slotRows.String(), html.EscapeString(first.Reason)),
 	}
 
 	_, err := client.Emails.Send(params)
@@ -96,31 +191,7 @@ func sendEntryConfirmationEmail(email string, start, end time.Time) error {
 	startTimeStr := start.Format("15:04")
 	endTimeStr := end.Format("15:04")
 
-	yearS, monthS, dayS := start.Date()
-	yearE, monthE, dayE := end.Date()
-
-	// Because I was not careful regarding dates, everything is technically handled as UTC, which is largely no issue,
-	// since this project only deals with a single timezone, but it's an issue for the calendar entry to correctly resolve
-	loc, _ := time.LoadLocation("Europe/Vienna")
-	correctedStartTime := time.Date(yearS, monthS, dayS, start.Hour(), 0, 0, 0, loc)
-	correctedEndTime := time.Date(yearE, monthE, dayE, end.Hour(), 0, 0, 0, loc)
-
-	const layout = "20060102T150405Z"
-	ics := fmt.Sprintf("BEGIN:VCALENDAR\r\n"+
-		"VERSION:2.0\r\n"+
-		"PRODID:-//AnbetungStp//DE\r\n"+
-		"BEGIN:VEVENT\r\n"+
-		"UID:%s@send.24-7fastenzeitgebet.com\r\n"+
-		"DTSTAMP:%s\r\n"+
-		"DTSTART:%s\r\n"+
-		"DTEND:%s\r\n"+
-		"SUMMARY:24/7 Anbetung St. Pölten\r\n"+
-		"END:VEVENT\r\n"+
-		"END:VCALENDAR",
-		uuid.New().String(),
-		time.Now().UTC().Format(layout),
-		correctedStartTime.UTC().Format(layout),
-		correctedEndTime.UTC().Format(layout))
+	correctedStartTime, correctedEndTime := correctTimezone(start, end)
 
 	params := &resend.SendEmailRequest{
 		From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
@@ -148,15 +219,463 @@ func sendEntryConfirmationEmail(email string, start, end time.Time) error {
 				<p style="font-size: 12px; color: #7f8c8d;">Vielen Dank für deinen wertvollen Dienst in der Anbetung!</p>
 			</div>
 		`, correctedStartTime.Format(time.RFC3339), correctedEndTime.Format(time.RFC3339), dateStr, startTimeStr, endTimeStr, dateStr, startTimeStr, endTimeStr),
-		Attachments: []*resend.Attachment{
-			{
-				Content:     []byte(ics),
-				Filename:    "anbetung.ics",
-				ContentType: "text/calendar",
-			},
-		},
+		Attachments: []*resend.Attachment{createIcsAttachment(correctedStartTime, correctedEndTime)},
 	}
 
 	_, err := client.Emails.Send(params)
 	return err
 }
+
+// sendReminderEmail is supposed to be sent ahead of an entry, whose owner opted in to reminders. Like the
+// confirmation, it contains the time information for the entry to be directly entered into a calendar application.
+func sendReminderEmail(email, checkInLink string, start, end time.Time) error {
+	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
+
+	dateStr := start.Format("02.01.2006")
+	startTimeStr := start.Format("15:04")
+	endTimeStr := end.Format("15:04")
+
+	correctedStartTime, correctedEndTime := correctTimezone(start, end)
+
+	params := &resend.SendEmailRequest{
+		From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
+		To:      []string{email},
+		Subject: fmt.Sprintf("Erinnerung an deinen Eintrag am %s um %s-%s - 24/7 Anbetung St. Pölten", dateStr, startTimeStr, endTimeStr),
+		Html: fmt.Sprintf(`
+			<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+				<h2 style="color: #2c3e50; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">Erinnerung an deinen Eintrag am %s um %s-%s</h2>
+				<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+				
+				<p style="text-align: justify;">Wir möchten dich daran erinnern, dass du dich für den Timeslot am %s für <strong>%s bis %s</strong> angemeldet hast.</p>
+				
+				<p style="text-align: justify;">Falls du doch nicht kommen kannst, trage dich bitte so früh wie möglich im Kalender aus, damit jemand anderes einspringen kann:</p>
+				
+				<div style="text-align: center; margin: 30px 0;">
+					<a href="%s" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Zum Kalender</a>
+				</div>
+				
+				<p style="text-align: justify;">Wenn du in der Kapelle angekommen bist, checke bitte kurz ein, damit wir wissen, dass die Anbetung besetzt ist:</p>
+				
+				<div style="text-align: center; margin: 30px 0;">
+					<a href="%s" style="background-color: #27ae60; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Einchecken</a>
+				</div>
+				
+				<hr style="border: 0; border-top: 1px solid #eeeeee; margin-top: 30px;">
+				
+				<p style="font-size: 12px; color: #7f8c8d;">Vielen Dank für deinen wertvollen Dienst in der Anbetung!</p>
+			</div>
+		`, dateStr, startTimeStr, endTimeStr, dateStr, startTimeStr, endTimeStr, calendarPageLink(), checkInLink),
+		Attachments: []*resend.Attachment{createIcsAttachment(correctedStartTime, correctedEndTime)},
+	}
+
+	_, err := client.Emails.Send(params)
+	return err
+}
+
+// sendMissedCheckInEmail informs about a participant, who did not check in for the given timeslot. For the
+// coordinators, the participant is given, while the next participant is asked whether they can come earlier.
+func sendMissedCheckInEmail(emails []string, start, end time.Time, participant string) error {
+	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
+
+	dateStr := start.Format("02.01.2006")
+	startTimeStr := start.Format("15:04")
+	endTimeStr := end.Format("15:04")
+
+	message := `<p style="text-align: justify;">Die Person, die für diesen Timeslot eingetragen ist, hat noch nicht eingecheckt. Falls es dir möglich ist, komm bitte schon früher in die Kapelle, damit die Anbetung nicht unterbrochen wird.</p>`
+	if participant != "" {
+		message = fmt.Sprintf(`<p style="text-align: justify;">Für diesen Timeslot hat <strong>%s</strong> noch nicht eingecheckt. Bitte prüft, ob die Anbetung besetzt ist.</p>`, html.EscapeString(participant))
+	}
+
+	params := &resend.SendEmailRequest{
+		From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
+		To:      []string{"volunteers@24-7fastenzeitgebet.com"},
+		Bcc:     emails,
+		Subject: fmt.Sprintf("Kein Check-in am %s um %s-%s - 24/7 Anbetung St. Pölten", dateStr, startTimeStr, endTimeStr),
+		Html: fmt.Sprintf(`
+			<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+				<h2 style="color: #c0392b; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">Kein Check-in am %s um %s-%s</h2>
+				<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+				
+				%s
+				
+				<div style="text-align: center; margin: 30px 0;">
+					<a href="%s" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Zum Timeslot</a>
+				</div>
+			</div>
+		`, dateStr, startTimeStr, endTimeStr, message, createSlotLink(start)),
+	}
+
+	_, err := client.Emails.Send(params)
+	return err
+}
+
+// sendWaitlistOfferEmail offers a freed timeslot to the first user on its waitlist, who may claim it via the given
+// link until the offer expires. Afterward, it is offered to the next user on the waitlist.
+func sendWaitlistOfferEmail(email, claimLink string, start, end, expiresAt time.Time) error {
+	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
+
+	dateStr := start.Format("02.01.2006")
+	startTimeStr := start.Format("15:04")
+	endTimeStr := end.Format("15:04")
+	expiresStr := expiresAt.Format("02.01.2006 15:04")
+
+	params := &resend.SendEmailRequest{
+		From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
+		To:      []string{email},
+		Subject: fmt.Sprintf("Timeslot am %s um %s-%s ist frei - 24/7 Anbetung St. Pölten", dateStr, startTimeStr, endTimeStr),
+		Html: fmt.Sprintf(`
+			<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+				<h2 style="color: #2c3e50; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">Timeslot am %s um %s-%s ist frei</h2>
+				<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+				
+				<p style="text-align: justify;">Du stehst auf der Warteliste für den Timeslot am %s für <strong>%s bis %s</strong>, der soeben frei geworden ist.</p>
+				
+				<p style="text-align: justify;">Der Timeslot ist bis <strong>%s</strong> für dich reserviert. Mit einem Klick kannst du ihn übernehmen:</p>
+				
+				<div style="text-align: center; margin: 30px 0;">
+					<a href="%s" style="background-color: #27ae60; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Timeslot übernehmen</a>
+				</div>
+				
+				<hr style="border: 0; border-top: 1px solid #eeeeee; margin-top: 30px;">
+				
+				<p style="font-size: 12px; color: #7f8c8d;">Danach wird der Timeslot der nächsten Person auf der Warteliste angeboten.</p>
+			</div>
+		`, dateStr, startTimeStr, endTimeStr, dateStr, startTimeStr, endTimeStr, expiresStr, claimLink),
+	}
+
+	_, err := client.Emails.Send(params)
+	return err
+}
+
+// sendWaitlistAssignedEmail informs the first user on the waitlist of a freed timeslot, that it was directly booked
+// for them. Like sendEntryConfirmationEmail, it contains an ICS file for the entry.
+func sendWaitlistAssignedEmail(email string, start, end time.Time) error {
+	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
+
+	dateStr := start.Format("02.01.2006")
+	startTimeStr := start.Format("15:04")
+	endTimeStr := end.Format("15:04")
+
+	correctedStartTime, correctedEndTime := correctTimezone(start, end)
+
+	params := &resend.SendEmailRequest{
+		From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
+		To:      []string{email},
+		Subject: fmt.Sprintf("Eintrag am %s um %s-%s von der Warteliste - 24/7 Anbetung St. Pölten", dateStr, startTimeStr, endTimeStr),
+		Html: fmt.Sprintf(`
+			<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+				<h2 style="color: #2c3e50; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">Eintrag am %s um %s-%s</h2>
+				<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+				
+				<p style="text-align: justify;">Der Timeslot am %s für <strong>%s bis %s</strong>, für den du auf der Warteliste standest, ist frei geworden und wurde direkt für dich eingetragen.</p>
+				
+				<p style="text-align: justify;">Falls du doch nicht kommen kannst, trage dich bitte so früh wie möglich im Kalender aus:</p>
+				
+				<div style="text-align: center; margin: 30px 0;">
+					<a href="%s" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Zum Kalender</a>
+				</div>
+				
+				<hr style="border: 0; border-top: 1px solid #eeeeee; margin-top: 30px;">
+				
+				<p style="font-size: 12px; color: #7f8c8d;">Vielen Dank für deinen wertvollen Dienst in der Anbetung!</p>
+			</div>
+		`, dateStr, startTimeStr, endTimeStr, dateStr, startTimeStr, endTimeStr, createSlotLink(start)),
+		Attachments: []*resend.Attachment{createIcsAttachment(correctedStartTime, correctedEndTime)},
+	}
+
+	_, err := client.Emails.Send(params)
+	return err
+}
+
+// sendTransferProposalEmail asks the owner of an entry, which seeks a replacement, to confirm or decline the proposal
+// of another participant to take it over, or to swap it with the given entry of theirs.
+func sendTransferProposalEmail(email, acceptLink, declineLink, proposer string, start, end time.Time, swap *CalendarEntryFull) error {
+	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
+
+	dateStr := start.Format("02.01.2006")
+	startTimeStr := start.Format("15:04")
+	endTimeStr := end.Format("15:04")
+
+	proposal := fmt.Sprintf("<strong>%s</strong> möchte deinen Timeslot übernehmen.", html.EscapeString(proposer))
+	if swap != nil {
+		proposal = fmt.Sprintf("<strong>%s</strong> möchte deinen Timeslot mit dem Timeslot am %s für <strong>%s bis %s</strong> tauschen.",
+			html.EscapeString(proposer), swap.Start.Format("02.01.2006"), swap.Start.Format("15:04"), swap.End.Format("15:04"))
+	}
+
+	params := &resend.SendEmailRequest{
+		From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
+		To:      []string{email},
+		Subject: fmt.Sprintf("Ersatz für deinen Timeslot am %s um %s-%s - 24/7 Anbetung St. Pölten", dateStr, startTimeStr, endTimeStr),
+		Html: fmt.Sprintf(`
+			<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+				<h2 style="color: #2c3e50; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">Ersatz für deinen Timeslot am %s um %s-%s</h2>
+				<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+				
+				<p style="text-align: justify;">Für deinen Timeslot am %s für <strong>%s bis %s</strong> hat sich ein Ersatz gefunden. %s</p>
+				
+				<p style="text-align: justify;">Erst wenn du bestätigst, geht der Timeslot über. Bis dahin bleibt er auf dich eingetragen.</p>
+				
+				<div style="text-align: center; margin: 30px 0;">
+					<a href="%s" style="background-color: #27ae60; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Bestätigen</a>
+					<a href="%s" style="background-color: #c0392b; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block; margin-left: 10px;">Ablehnen</a>
+				</div>
+				
+				<hr style="border: 0; border-top: 1px solid #eeeeee; margin-top: 30px;">
+				
+				<p style="font-size: 12px; color: #7f8c8d;">Vielen Dank für deinen wertvollen Dienst in der Anbetung!</p>
+			</div>
+		`, dateStr, startTimeStr, endTimeStr, dateStr, startTimeStr, endTimeStr, proposal, acceptLink, declineLink),
+	}
+
+	_, err := client.Emails.Send(params)
+	return err
+}
+
+// sendTransferDeclinedEmail informs a participant, that their proposal to take over the entry between start and end
+// was declined by its owner.
+func sendTransferDeclinedEmail(email string, start, end time.Time) error {
+	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
+
+	dateStr := start.Format("02.01.2006")
+	startTimeStr := start.Format("15:04")
+	endTimeStr := end.Format("15:04")
+
+	params := &resend.SendEmailRequest{
+		From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
+		To:      []string{email},
+		Subject: fmt.Sprintf("Timeslot am %s um %s-%s - 24/7 Anbetung St. Pölten", dateStr, startTimeStr, endTimeStr),
+		Html: fmt.Sprintf(`
+			<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+				<h2 style="color: #2c3e50; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">Timeslot am %s um %s-%s</h2>
+				<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+				
+				<p style="text-align: justify;">Dein Angebot, den Timeslot am %s für <strong>%s bis %s</strong> zu übernehmen, wurde leider abgelehnt. Deine eigenen Einträge bleiben unverändert.</p>
+				
+				<p style="text-align: justify;">Vielleicht findest du im Kalender einen anderen freien Timeslot:</p>
+				
+				<div style="text-align: center; margin: 30px 0;">
+					<a href="%s" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Zum Kalender</a>
+				</div>
+				
+				<hr style="border: 0; border-top: 1px solid #eeeeee; margin-top: 30px;">
+				
+				<p style="font-size: 12px; color: #7f8c8d;">Vielen Dank für deine Bereitschaft!</p>
+			</div>
+		`, dateStr, startTimeStr, endTimeStr, dateStr, startTimeStr, endTimeStr, calendarPageLink()),
+	}
+
+	_, err := client.Emails.Send(params)
+	return err
+}
+
+// sendGroupCreatedEmail informs the contact of a newly booked Group about the invite link for its members and the
+// overview link, where the contact sees which member claimed which entry.
+func sendGroupCreatedEmail(group Group, inviteLink, overviewLink string) error {
+	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
+
+	dateStr := group.Start.Format("02.01.2006")
+	startTimeStr := group.Start.Format("15:04")
+	endTimeStr := group.End.Format("15:04")
+	name := html.EscapeString(group.Name)
+
+	params := &resend.SendEmailRequest{
+		From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
+		To:      []string{group.Email},
+		Subject: fmt.Sprintf("Gruppenbuchung %s am %s um %s-%s - 24/7 Anbetung St. Pölten", group.Name, dateStr, startTimeStr, endTimeStr),
+		Html: fmt.Sprintf(`
+			<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+				<h2 style="color: #2c3e50; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">Gruppenbuchung %s</h2>
+				<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+				
+				<p style="text-align: justify;">Vielen Dank! Der Block am %s für <strong>%s bis %s</strong> ist für <strong>%s</strong> reserviert. Bis sich jemand aus der Gruppe für eine Stunde einträgt, ist sie auf dich eingetragen.</p>
+				
+				<p style="text-align: justify;">Teile diesen Link mit den Mitgliedern deiner Gruppe, damit sie sich selbst für die einzelnen Stunden eintragen können:</p>
+				
+				<p style="text-align: center; word-break: break-all;"><a href="%s" style="color: #2c3e50;">%s</a></p>
+				
+				<p style="text-align: justify;">In der Übersicht siehst du jederzeit, wer sich für welche Stunde eingetragen hat:</p>
+				
+				<div style="text-align: center; margin: 30px 0;">
+					<a href="%s" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Zur Übersicht</a>
+				</div>
+				
+				<hr style="border: 0; border-top: 1px solid #eeeeee; margin-top: 30px;">
+				
+				<p style="font-size: 12px; color: #7f8c8d;">Vielen Dank für euren wertvollen Dienst in der Anbetung!</p>
+			</div>
+		`, name, dateStr, startTimeStr, endTimeStr, name, inviteLink, inviteLink, overviewLink),
+	}
+
+	_, err := client.Emails.Send(params)
+	return err
+}
+
+// sendDisplacementEmail apologizes to a participant, whose entry gave way to an admin event, i.e., was removed or
+// shortened to the remaining intervals. To make up for it, it suggests free alternatives, each linking directly to the
+// calendar page of the UI.
+func sendDisplacementEmail(email, eventName string, displacement Displacement, alternatives []Interval) error {
+	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
+
+	entry := displacement.Entry
+	dateStr := entry.Start.Format("02.01.2006")
+	startTimeStr := entry.Start.Format("15:04")
+	endTimeStr := entry.End.Format("15:04")
+
+	change := "musste leider entfallen"
+	if len(displacement.Remains) > 0 {
+		remains := make([]string, len(displacement.Remains))
+		for i, remain := range displacement.Remains {
+			remains[i] = fmt.Sprintf("%s bis %s", remain.Start.Format("15:04"), remain.End.Format("15:04"))
+		}
+		change = fmt.Sprintf("musste leider auf <strong>%s</strong> gekürzt werden", strings.Join(remains, " und "))
+	}
+
+	suggestion := `<p style="text-align: justify;">Vielleicht findest du im Kalender einen anderen freien Timeslot:</p>`
+	if len(alternatives) > 0 {
+		var alternativeRows strings.Builder
+		for _, alternative := range alternatives {
+			alternativeRows.WriteString(fmt.Sprintf(`
+					<li style="margin-bottom: 6px;">
+						<a href="%s" style="color: #2c3e50; text-decoration: underline;">%s, <strong>%s bis %s</strong></a>
+					</li>`, createSlotLink(alternative.Start), alternative.Start.Format("02.01.2006"),
+				alternative.Start.Format("15:04"), alternative.End.Format("15:04")))
+		}
+		suggestion = fmt.Sprintf(`<p style="text-align: justify;">Die folgenden Timeslots wären zum Beispiel noch frei. Klicke auf einen Timeslot, um dich direkt im Kalender einzutragen:</p>
+				
+				<ul>%s
+				</ul>
+				
+				<p style="text-align: justify;">Oder du suchst dir im Kalender einen anderen freien Timeslot aus:</p>`, alternativeRows.String())
+	}
+
+	params := &resend.SendEmailRequest{
+		From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
+		To:      []string{email},
+		Subject: fmt.Sprintf("Änderung deines Timeslots am %s um %s-%s - 24/7 Anbetung St. Pölten", dateStr, startTimeStr, endTimeStr),
+		Html: fmt.Sprintf(`
+			<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+				<h2 style="color: #2c3e50; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">Änderung deines Timeslots</h2>
+				<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+				
+				<p style="text-align: justify;">Es tut uns sehr leid, aber dein Timeslot am %s für <strong>%s bis %s</strong> %s, da zu dieser Zeit folgendes stattfindet: <strong>%s</strong>.</p>
+				
+				%s
+				
+				<div style="text-align: center; margin: 30px 0;">
+					<a href="%s" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Zum Kalender</a>
+				</div>
+				
+				<hr style="border: 0; border-top: 1px solid #eeeeee; margin-top: 30px;">
+				
+				<p style="font-size: 12px; color: #7f8c8d;">Vielen Dank für dein Verständnis und deine Bereitschaft!</p>
+			</div>
+		`, dateStr, startTimeStr, endTimeStr, change, html.EscapeString(eventName), suggestion, calendarPageLink()),
+	}
+
+	_, err := client.Emails.Send(params)
+	return err
+}
+
+// correctTimezone reinterprets the given times in the actual timezone of the application.
+//
+// Because I was not careful regarding dates, everything is technically handled as UTC, which is largely no issue,
+// since this project only deals with a single timezone, but it's an issue for the calendar entry to correctly resolve
+func correctTimezone(start, end time.Time) (time.Time, time.Time) {
+	yearS, monthS, dayS := start.Date()
+	yearE, monthE, dayE := end.Date()
+
+	loc, _ := time.LoadLocation("Europe/Vienna")
+	correctedStartTime := time.Date(yearS, monthS, dayS, start.Hour(), 0, 0, 0, loc)
+	correctedEndTime := time.Date(yearE, monthE, dayE, end.Hour(), 0, 0, 0, loc)
+
+	return correctedStartTime, correctedEndTime
+}
+
+// createIcsAttachment creates an ICS file for the given (timezone corrected) times, so the entry can be directly
+// entered into Google Calendar etc.
+func createIcsAttachment(start, end time.Time) *resend.Attachment {
+	const layout = "20060102T150405Z"
+	ics := fmt.Sprintf("BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//AnbetungStp//DE\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:%s@send.24-7fastenzeitgebet.com\r\n"+
+		"DTSTAMP:%s\r\n"+
+		"DTSTART:%s\r\n"+
+		"DTEND:%s\r\n"+
+		"SUMMARY:24/7 Anbetung St. Pölten\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR",
+		uuid.New().String(),
+		time.Now().UTC().Format(layout),
+		start.UTC().Format(layout),
+		end.UTC().Format(layout))
+
+	return &resend.Attachment{
+		Content:     []byte(ics),
+		Filename:    "anbetung.ics",
+		ContentType: "text/calendar",
+	}
+}
+
+// sendDigestEmail is supposed to be sent periodically, summarizing the uncovered timeslots of the upcoming days for
+// the volunteers. Each timeslot links directly to the calendar page of the UI, and every volunteer receives
+// individual links to change how often they receive the digest.
+func sendDigestEmail(volunteers []Volunteer, gaps []Interval) error {
+	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
+
+	var gapRows strings.Builder
+	for _, gap := range gaps {
+		endTimeStr := gap.End.Format("15:04")
+		// A gap until midnight is more intuitively presented as ending at 24:00
+		if gap.End.Hour() == 0 && gap.End.Minute() == 0 && gap.End.After(gap.Start) {
+			endTimeStr = "24:00"
+		}
+		gapRows.WriteString(fmt.Sprintf(`
+					<li style="margin-bottom: 6px;">
+						<a href="%s" style="color: #2c3e50; text-decoration: underline;">%s, <strong>%s bis %s</strong></a>
+					</li>`, createSlotLink(gap.Start), gap.Start.Format("02.01.2006"), gap.Start.Format("15:04"), endTimeStr))
+	}
+
+	requests := make([]*resend.SendEmailRequest, 0, len(volunteers))
+	for _, volunteer := range volunteers {
+		requests = append(requests, &resend.SendEmailRequest{
+			From:    "24/7 Anbetung St. Pölten <no-reply@send.24-7fastenzeitgebet.com>",
+			To:      []string{volunteer.Email},
+			Subject: fmt.Sprintf("%d offene Timeslots - 24/7 Anbetung St. Pölten", len(gaps)),
+			Html: fmt.Sprintf(`
+				<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+					<h2 style="color: #2c3e50; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">Offene Timeslots</h2>
+					<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+					
+					<p style="text-align: justify;">Für die folgenden Timeslots hat sich noch niemand eingetragen. Falls du Zeit hast, klicke auf einen Timeslot, um dich direkt im Kalender einzutragen:</p>
+					
+					<ul>%s
+					</ul>
+					
+					<hr style="border: 0; border-top: 1px solid #eeeeee; margin-top: 30px;">
+					
+					<p style="font-size: 12px; color: #7f8c8d;">Vielen Dank für deinen wertvollen Dienst in der Anbetung!</p>
+					<p style="font-size: 12px; color: #7f8c8d;">Diese Übersicht erhalten: <a href="%s" style="color: #7f8c8d;">täglich</a> | <a href="%s" style="color: #7f8c8d;">wöchentlich</a> | <a href="%s" style="color: #7f8c8d;">nicht mehr</a></p>
+				</div>
+			`, gapRows.String(),
+				createDigestPreferenceLink(volunteer, "daily"),
+				createDigestPreferenceLink(volunteer, "weekly"),
+				createDigestPreferenceLink(volunteer, "never")),
+		})
+	}
+
+	// The batch API of resend accepts at most 100 emails per call
+	for chunk := range slices.Chunk(requests, 100) {
+		if _, err := client.Batch.Send(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// calendarPageLink provides the link to the calendar page of the UI.
+func calendarPageLink() string {
+	return fmt.Sprintf("%s/calendar", os.Getenv("HOST_FE"))
+}
@@ -0,0 +1,195 @@
+// Provides the typed errors of the application, whose kind decides the status of the problem response and whose stable
+// code allows the clients to localise them
+
+package app
+
+import (
+	"errors"
+	"net/http"
+)
+
+// The kinds of a DomainError, which can be checked via errors.Is
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUpstream     = errors.New("upstream failure")
+)
+
+// DomainError is an error of a known Kind, which is identified by its stable Code and described by its Detail. It may
+// wrap the Err that caused it.
+type DomainError struct {
+	Kind   error
+	Code   string
+	Detail string
+	Err    error
+}
+
+func (e *DomainError) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+// Unwrap exposes both the Kind and the cause to errors.Is and errors.As.
+func (e *DomainError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// Is matches any DomainError with the same Code, so the sentinels below still match once they wrap a cause.
+func (e *DomainError) Is(target error) bool {
+	t, ok := target.(*DomainError)
+	return ok && t.Code == e.Code
+}
+
+// Wrap provides a copy of the DomainError with the given cause.
+func (e *DomainError) Wrap(err error) *DomainError {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// status maps the Kind of the DomainError to the status of its problem response.
+func (e *DomainError) status() int {
+	switch e.Kind {
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrConflict:
+		return http.StatusConflict
+	case ErrValidation:
+		return http.StatusBadRequest
+	case ErrUnauthorized:
+		return http.StatusUnauthorized
+	case ErrForbidden:
+		return http.StatusForbidden
+	case ErrUpstream:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// notFoundError creates a DomainError for missing resources, including those the caller may not know about.
+func notFoundError(code, detail string) *DomainError {
+	return &DomainError{Kind: ErrNotFound, Code: code, Detail: detail}
+}
+
+// conflictError creates a DomainError for requests that collide with the current state.
+func conflictError(code, detail string) *DomainError {
+	return &DomainError{Kind: ErrConflict, Code: code, Detail: detail}
+}
+
+// validationError creates a DomainError for requests that violate the "business rules" or are malformed.
+func validationError(code, detail string) *DomainError {
+	return &DomainError{Kind: ErrValidation, Code: code, Detail: detail}
+}
+
+// unauthorizedError creates a DomainError for requests whose credentials are missing or invalid.
+func unauthorizedError(code, detail string) *DomainError {
+	return &DomainError{Kind: ErrUnauthorized, Code: code, Detail: detail}
+}
+
+// forbiddenError creates a DomainError for requests that lack the required permissions.
+func forbiddenError(code, detail string) *DomainError {
+	return &DomainError{Kind: ErrForbidden, Code: code, Detail: detail}
+}
+
+// upstreamError creates a DomainError for failures of external services, e.g. the email provider.
+func upstreamError(code, detail string) *DomainError {
+	return &DomainError{Kind: ErrUpstream, Code: code, Detail: detail}
+}
+
+// The errors of the requests themselves
+var (
+	ErrMalformedRequest   = validationError("malformed-request", "Malformed request")
+	ErrMalformedEmail     = validationError("malformed-email", "Email is not well formed")
+	ErrStartInPast        = validationError("start-in-past", "Start time must be in the future")
+	ErrStartAfterEnd      = validationError("start-after-end", "Start must be before End")
+	ErrDurationTooLong    = validationError("duration-too-long", "Duration may not be too long")
+	ErrFromAfterTo        = validationError("from-after-to", "From must not be after To")
+	ErrRangeTooLong       = validationError("range-too-long", "Date range may not be too long")
+	ErrInvalidGranularity = validationError("invalid-granularity", "Granularity must evenly divide a day and be at least 5 minutes")
+	ErrInvalidScale       = validationError("invalid-scale", "Scale is out of range")
+	ErrInvalidInterval    = validationError("invalid-interval", "Invalid interval")
+	ErrInvalidFrequency   = validationError("invalid-frequency", "Invalid frequency")
+	ErrInvalidAggregate   = validationError("invalid-aggregate", "Invalid aggregate")
+	ErrNameMissing        = validationError("name-missing", "Name must be provided")
+	ErrInvalidColor       = validationError("invalid-color", "Color must be a hex color, e.g. #ef4444")
+	ErrUnknownQrTarget    = notFoundError("unknown-qr-target", "Unknown QR code target")
+	ErrInvalidFields      = validationError("invalid-fields", "Request contains invalid fields")
+	ErrFieldRequired      = validationError("field-required", "Field must be provided")
+	ErrDurationTooShort   = validationError("duration-too-short", "Duration may not be too short")
+	ErrSlotMisaligned     = validationError("slot-misaligned", "Time must be aligned to the slot grid")
+	ErrBeyondHorizon      = validationError("beyond-horizon", "Time is beyond the booking horizon")
+	ErrInvalidRepetitions = validationError("invalid-repetitions", "Repetitions are out of range")
+)
+
+// The errors of authentication and permissions
+var (
+	ErrInvalidLogin  = unauthorizedError("invalid-login", "Invalid login")
+	ErrMissingToken  = unauthorizedError("missing-token", "Missing or invalid token format")
+	ErrInvalidToken  = unauthorizedError("invalid-token", "Invalid or expired token")
+	ErrAdminRequired = forbiddenError("admin-required", "Admin permissions required")
+)
+
+// The errors of the calendar entries and series
+var (
+	ErrEntryNotFound        = notFoundError("entry-not-found", "no entry found")
+	ErrEntryNotInserted     = conflictError("entry-not-inserted", "no entry inserted")
+	ErrEntryNotDeleted      = notFoundError("entry-not-deleted", "no entry deleted")
+	ErrTimeslotOverlap      = conflictError("timeslot-overlap", "timeslot overlap")
+	ErrTimeslotCovered      = conflictError("timeslot-covered", "Timeslot is already covered")
+	ErrTimeslotFree         = conflictError("timeslot-free", "Timeslot is free")
+	ErrTimeslotOffered      = conflictError("timeslot-offered", "Timeslot is offered to the waitlist")
+	ErrUnknownEventType     = validationError("unknown-event-type", "Unknown event type")
+	ErrEventTypeNotFound    = notFoundError("event-type-not-found", "no event type found")
+	ErrEventTypeInUse       = conflictError("event-type-in-use", "event type in use")
+	ErrOverrideNotAdmin     = validationError("override-not-admin-event", "Only admin events may override entries")
+	ErrInvalidOverrideMode  = validationError("invalid-override-mode", "Invalid override mode")
+	ErrEntryNotDisplaced    = conflictError("entry-not-displaced", "no entry displaced")
+	ErrCheckInNotConfigured = notFoundError("check-in-not-configured", "Chapel check-in is not configured")
+)
+
+// The errors of the booking policies
+var (
+	ErrDailyQuotaExceeded  = conflictError("daily-quota-exceeded", "Maximum hours per day exceeded")
+	ErrWeeklyQuotaExceeded = conflictError("weekly-quota-exceeded", "Maximum hours per week exceeded")
+	ErrCancellationTooLate = forbiddenError("cancellation-too-late", "Entry starts too soon to be cancelled")
+	ErrEntryInPast         = forbiddenError("entry-in-past", "Past entries can't be cancelled")
+	ErrReasonRequired      = validationError("cancellation-reason-required", "Cancellations on short notice require a reason")
+)
+
+// The errors of the volunteers
+var (
+	ErrVolunteerNotInserted  = conflictError("volunteer-not-inserted", "no volunteer inserted")
+	ErrVolunteerNotConfirmed = notFoundError("volunteer-not-confirmed", "no volunteer confirmed")
+	ErrVolunteerNotUpdated   = notFoundError("volunteer-not-updated", "no volunteer updated")
+	ErrVolunteerNotDeleted   = notFoundError("volunteer-not-deleted", "no volunteer deleted")
+	ErrEmailNotSent          = upstreamError("email-not-sent", "Email could not be sent")
+)
+
+// The errors of the waitlist, transfers and groups
+var (
+	ErrAlreadyOnWaitlist       = conflictError("already-on-waitlist", "already on waitlist")
+	ErrWaitlistEntryNotDeleted = notFoundError("waitlist-entry-not-deleted", "no waitlist entry deleted")
+	ErrTransferNotFound        = notFoundError("transfer-not-found", "Transfer not found")
+	ErrNoOpenTransfer          = notFoundError("no-open-transfer", "Entry doesn't seek a replacement")
+	ErrAdminEventTransfer      = validationError("admin-event-transfer", "Admin events can't be transferred")
+	ErrEntryAlreadyOwned       = validationError("entry-already-owned", "Entry is already owned")
+	ErrSwapEntryInPast         = validationError("swap-entry-in-past", "Swap entry must be a future entry")
+	ErrTransferExists          = conflictError("transfer-exists", "Entry already seeks a replacement")
+	ErrTransferNotClosed       = notFoundError("transfer-not-closed", "no transfer closed")
+	ErrTransferNotAvailable    = conflictError("transfer-not-available", "transfer not available")
+	ErrProposalNotDeclined     = conflictError("proposal-not-declined", "no proposal declined")
+	ErrSwapEntryNotFound       = notFoundError("swap-entry-not-found", "Swap entry not found")
+	ErrGroupNotFound           = notFoundError("group-not-found", "Group not found")
+	ErrGroupNameMissing        = validationError("group-name-missing", "Group name must be provided")
+	ErrGroupNotDeleted         = notFoundError("group-not-deleted", "no group deleted")
+	ErrEntryNotClaimed         = conflictError("entry-not-claimed", "no entry claimed")
+)
@@ -0,0 +1,202 @@
+// Provides the publishing of changes to the calendar entries to connected clients, so they stay up to date live
+
+package app
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Sakrafux/pray-calendar/backend/security"
+	"github.com/go-chi/httplog/v2"
+)
+
+// The types of CalendarEvent, which are also the event names of the Server-Sent Events
+const (
+	eventCreated = "created"
+	eventUpdated = "updated"
+	eventDeleted = "deleted"
+)
+
+// subscriptionBuffer is the number of events a subscriber may lag behind, before it is dropped
+const subscriptionBuffer = 64
+
+// CalendarEvent is a change to a single calendar entry. It always holds the full entry, which is reduced to the
+// publicly visible fields for non-admin subscribers.
+type CalendarEvent struct {
+	Type  string
+	Entry CalendarEntryFull
+}
+
+// subscription receives the CalendarEvent concerning the interval between start and end.
+type subscription struct {
+	start  time.Time
+	end    time.Time
+	events chan CalendarEvent
+}
+
+// EventBroker distributes the CalendarEvent to all subscriptions, whose interval they concern. It is shared by all
+// requests and closed on shutdown, which ends all open streams.
+type EventBroker struct {
+	mu            sync.Mutex
+	subscriptions map[*subscription]struct{}
+	closed        bool
+}
+
+// NewEventBroker is the constructor for EventBroker.
+func NewEventBroker() *EventBroker {
+	return &EventBroker{subscriptions: make(map[*subscription]struct{})}
+}
+
+// Subscribe creates a subscription for the events concerning the interval between start and end. Its channel is
+// closed once the subscription ends, either via Unsubscribe, Close, or because the subscriber lagged behind.
+func (b *EventBroker) Subscribe(start, end time.Time) *subscription {
+	s := &subscription{start: start, end: end, events: make(chan CalendarEvent, subscriptionBuffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(s.events)
+		return s
+	}
+	b.subscriptions[s] = struct{}{}
+
+	return s
+}
+
+// Unsubscribe ends a subscription. It is safe to call for subscriptions that already ended.
+func (b *EventBroker) Unsubscribe(s *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(s)
+}
+
+// Publish sends the events to all subscriptions they concern. Publishing never blocks, so a subscriber that can't
+// keep up is dropped instead, which causes the client to reconnect and query the current state again.
+func (b *EventBroker) Publish(events ...CalendarEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subscriptions {
+		for _, event := range events {
+			// Same semantics as GetAllEntriesForRange, so the client receives exactly the events of its queried entries
+			if event.Entry.Start.After(s.end) || event.Entry.End.Before(s.start) {
+				continue
+			}
+
+			select {
+			case s.events <- event:
+			default:
+				b.remove(s)
+			}
+			if _, ok := b.subscriptions[s]; !ok {
+				break
+			}
+		}
+	}
+}
+
+// Close ends all subscriptions and rejects new ones. It is supposed to be called on shutdown.
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subscriptions {
+		b.remove(s)
+	}
+}
+
+// remove ends a subscription, given that the lock is held.
+func (b *EventBroker) remove(s *subscription) {
+	if _, ok := b.subscriptions[s]; !ok {
+		return
+	}
+	delete(b.subscriptions, s)
+	close(s.events)
+}
+
+// publishEntries is a utility method to publish events of the same type for several entries.
+func (h *ApiHandler) publishEntries(eventType string, entries ...CalendarEntryFull) {
+	events := make([]CalendarEvent, len(entries))
+	for i, entry := range entries {
+		events[i] = CalendarEvent{Type: eventType, Entry: entry}
+	}
+	h.events.Publish(events...)
+}
+
+// GetCalendarEvents streams the changes to the entries of the week starting at a date given via query parameter
+// "start" as Server-Sent Events of type "created", "updated" and "deleted", each containing the affected entry.
+// Like GetAllEntries, it provides CalendarEntryFull instead of CalendarEntry, if admin permissions are available.
+// As EventSource can't send headers, a stream token (see PostStreamToken) may alternatively be given via query
+// parameter "token". The access token itself must never be part of the URL, as URLs end up in the logs.
+func (h *ApiHandler) GetCalendarEvents(w http.ResponseWriter, r *http.Request) {
+	logger := httplog.LogEntry(r.Context())
+
+	start, err := time.Parse("2006-01-02", r.URL.Query().Get("start"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	// An expired stream token is rejected instead of silently providing the public stream, so the client notices and
+	// reconnects with a new one
+	admin := r.Context().Value("admin").(bool)
+	if token := r.URL.Query().Get("token"); !admin && token != "" {
+		if err := security.ValidateStreamToken(token); err != nil {
+			httpProblemWithLog(r, w, ErrInvalidToken.Wrap(err))
+			return
+		}
+		admin = true
+	}
+
+	s := h.events.Subscribe(start, start.AddDate(0, 0, 7))
+	defer h.events.Unsubscribe(s)
+
+	rc, err := startSseStream(w)
+	if err != nil {
+		logger.Warn(err.Error())
+		return
+	}
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if err := writeSseKeepAlive(w, rc); err != nil {
+				return
+			}
+		case event, ok := <-s.events:
+			if !ok {
+				return
+			}
+
+			// Non-admins must never receive the personal information of others
+			var data any = event.Entry
+			if !admin || event.Type == eventDeleted {
+				data = event.Entry.CalendarEntry
+			}
+			if err := writeSseEvent(w, rc, event.Type, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// PostStreamToken provides a short-lived stream token, which allows the admin to subscribe to GetCalendarEvents with
+// admin permissions.
+func (h *ApiHandler) PostStreamToken(w http.ResponseWriter, r *http.Request) {
+	token, err := security.CreateStreamToken()
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	writeJson(w, token)
+}
@@ -0,0 +1,175 @@
+// Provides the event types of admin events, which define their presentation and the rules they impose on the calendar
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// colorRegex matches the hex notation of CSS colors, which is the only notation the clients need to support
+var colorRegex = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// eventTypeIndex maps the ids of the EventType to themselves, to look up the rules of the entries.
+type eventTypeIndex map[int]EventType
+
+// loadEventTypes queries all EventType as an eventTypeIndex.
+func loadEventTypes(db *DBHandler) (eventTypeIndex, error) {
+	eventTypes, err := db.GetEventTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(eventTypeIndex, len(eventTypes))
+	for _, eventType := range eventTypes {
+		index[eventType.Id] = eventType
+	}
+
+	return index, nil
+}
+
+// blocksBookings checks whether participants can't book the time of an entry. The entries of participants, as well as
+// those of unknown event types, always do.
+func (i eventTypeIndex) blocksBookings(entry CalendarEntry) bool {
+	if entry.EventTypeId == nil {
+		return true
+	}
+	eventType, ok := i[*entry.EventTypeId]
+	return !ok || eventType.BlocksBookings
+}
+
+// countsAsCoverage checks whether the time of an entry is covered. The entries of participants, as well as those of
+// unknown event types, always do.
+func (i eventTypeIndex) countsAsCoverage(entry CalendarEntry) bool {
+	if entry.EventTypeId == nil {
+		return true
+	}
+	eventType, ok := i[*entry.EventTypeId]
+	return !ok || eventType.CountsAsCoverage
+}
+
+// blockingEntries filters the entries that prevent a booking of their time.
+func (i eventTypeIndex) blockingEntries(entries []CalendarEntry) []CalendarEntry {
+	filtered := make([]CalendarEntry, 0, len(entries))
+	for _, entry := range entries {
+		if i.blocksBookings(entry) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// coveringEntries filters the entries after which nobody needs to be found for their time anymore, as they either
+// cover it or block it, which makes covering it impossible.
+func (i eventTypeIndex) coveringEntries(entries []CalendarEntry) []CalendarEntry {
+	filtered := make([]CalendarEntry, 0, len(entries))
+	for _, entry := range entries {
+		if i.countsAsCoverage(entry) || i.blocksBookings(entry) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// localizedName provides the name of the EventType in the given language, falling back to its default name.
+func (t EventType) localizedName(lang string) string {
+	if name, ok := t.Translations[lang]; ok && name != "" {
+		return name
+	}
+	return t.Name
+}
+
+// validateEventType is a utility method to check the "business rules" of an EventType, returning the violated one.
+func validateEventType(eventType EventType) error {
+	if eventType.Name == "" {
+		return ErrNameMissing
+	}
+	if !colorRegex.MatchString(eventType.Color) {
+		return ErrInvalidColor
+	}
+	return nil
+}
+
+// GetEventTypes provides all EventType, so the clients can present the admin events accordingly.
+func (h *ApiHandler) GetEventTypes(w http.ResponseWriter, r *http.Request) {
+	eventTypes, err := h.db.GetEventTypes()
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	writeJson(w, eventTypes)
+}
+
+// PostEventType creates a new EventType, which is provided via the request body.
+func (h *ApiHandler) PostEventType(w http.ResponseWriter, r *http.Request) {
+	var eventType EventType
+	if err := json.NewDecoder(r.Body).Decode(&eventType); err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	if err := validateEventType(eventType); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	insertedEventType, err := h.db.InsertEventType(eventType)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	writeJsonStatus(w, http.StatusCreated, insertedEventType)
+}
+
+// PutEventType replaces the EventType with the id given via the URL by the one provided via the request body. The
+// changed rules immediately apply to all its entries.
+func (h *ApiHandler) PutEventType(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	var eventType EventType
+	if err := json.NewDecoder(r.Body).Decode(&eventType); err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+	eventType.Id = id
+
+	if err := validateEventType(eventType); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	updatedEventType, err := h.db.UpdateEventType(eventType)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	writeJson(w, updatedEventType)
+}
+
+// DeleteEventType deletes the EventType with the id given via the URL. As the entries reference their EventType, it
+// can only be deleted once no entry uses it anymore.
+func (h *ApiHandler) DeleteEventType(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	if err := h.db.DeleteEventType(id); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
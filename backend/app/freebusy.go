@@ -0,0 +1,122 @@
+// Provides the free/busy information of the calendar, which only reveals which times can't be booked anymore, but
+// neither who booked them nor why
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FreeBusy is purely a response REST-DTO, containing the Busy intervals within the range [From, To). The intervals are
+// merged, so they reveal nothing about the entries behind them. Like all times of the API, they are wall times of the
+// calendar's timezone given as UTC, whereas the iCalendar format converts them to actual UTC.
+type FreeBusy struct {
+	From time.Time
+	To   time.Time
+	Busy []Interval
+}
+
+// busyIntervals merges the given entries, which must be sorted by their start, into disjoint intervals within [start,
+// end). Adjacent entries are merged as well, as there is no free time between them.
+func busyIntervals(entries []CalendarEntry, start, end time.Time) []Interval {
+	busy := make([]Interval, 0)
+	for _, entry := range entries {
+		// Only overlapping entries count, merely touching the range doesn't
+		if !entry.Start.Before(end) || !entry.End.After(start) {
+			continue
+		}
+
+		interval := Interval{Start: entry.Start, End: entry.End}
+		if interval.Start.Before(start) {
+			interval.Start = start
+		}
+		if interval.End.After(end) {
+			interval.End = end
+		}
+
+		if last := len(busy) - 1; last >= 0 && !interval.Start.After(busy[last].End) {
+			if interval.End.After(busy[last].End) {
+				busy[last].End = interval.End
+			}
+			continue
+		}
+		busy = append(busy, interval)
+	}
+
+	return busy
+}
+
+// inTimezone reinterprets a time, which is stored as wall time in UTC, in the actual timezone of the calendar.
+func (c *Config) inTimezone(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), c.Timezone)
+}
+
+// GetFreeBusy provides the FreeBusy for the date range given via the query parameters "from" and "to" (both inclusive),
+// or for a week starting at the date given via query parameter "start". Only the entries that prevent a booking are
+// busy. With the query parameter "format=ics", it is provided as iCalendar VFREEBUSY component instead.
+func (h *ApiHandler) GetFreeBusy(w http.ResponseWriter, r *http.Request) {
+	start, end, err := parseEntryRange(r)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	entries, err := h.db.GetAllEntriesForRange(start, end)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	types, err := loadEventTypes(h.db)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	freeBusy := FreeBusy{From: start, To: end, Busy: busyIntervals(types.blockingEntries(entries), start, end)}
+
+	if r.URL.Query().Get("format") == "ics" {
+		h.writeFreeBusyIcs(w, freeBusy)
+		return
+	}
+
+	writeJson(w, freeBusy)
+}
+
+// writeFreeBusyIcs returns a FreeBusy as RFC 5545 VFREEBUSY component, whose times are given in actual UTC. As the
+// component is merely published for download, it omits METHOD, which would require an ORGANIZER.
+func (h *ApiHandler) writeFreeBusyIcs(w http.ResponseWriter, freeBusy FreeBusy) {
+	const layout = "20060102T150405Z"
+
+	var periods strings.Builder
+	for _, busy := range freeBusy.Busy {
+		periods.WriteString(fmt.Sprintf("FREEBUSY;FBTYPE=BUSY:%s/%s\r\n",
+			h.config.inTimezone(busy.Start).UTC().Format(layout), h.config.inTimezone(busy.End).UTC().Format(layout)))
+	}
+
+	ics := fmt.Sprintf("BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//AnbetungStp//DE\r\n"+
+		"BEGIN:VFREEBUSY\r\n"+
+		"UID:%s@send.24-7fastenzeitgebet.com\r\n"+
+		"DTSTAMP:%s\r\n"+
+		"DTSTART:%s\r\n"+
+		"DTEND:%s\r\n"+
+		"%s"+
+		"END:VFREEBUSY\r\n"+
+		"END:VCALENDAR\r\n",
+		uuid.New().String(),
+		time.Now().UTC().Format(layout),
+		h.config.inTimezone(freeBusy.From).UTC().Format(layout),
+		h.config.inTimezone(freeBusy.To).UTC().Format(layout),
+		periods.String())
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=freebusy_%s.ics", freeBusy.From.Format("2006-01-02")))
+	_, _ = w.Write([]byte(ics))
+}
@@ -0,0 +1,419 @@
+// Provides the block bookings of parish groups, whose hours are claimed by the group members via an invite link
+
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sakrafux/pray-calendar/backend/security"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httplog/v2"
+)
+
+// maxGroupDuration is the maximum length of a Group block
+const maxGroupDuration = 24 * time.Hour
+
+// createGroupLink creates the link of the given role for a Group, i.e., either the invite link for the members or the
+// overview link for the group contact.
+func createGroupLink(group Group, role string) (string, error) {
+	token, err := security.CreateGroupToken(group.Id, role, group.End)
+	if err != nil {
+		return "", err
+	}
+
+	path := map[string]string{"invite": "invite", "coordinator": "overview"}[role]
+	return fmt.Sprintf("%s/api/calendar/groups/%s?token=%s", os.Getenv("HOST_BE"), path, url.QueryEscape(token)), nil
+}
+
+// isUnclaimed checks whether an entry of a Group is still owned by the group contact.
+func (g Group) isUnclaimed(entry CalendarEntryFull) bool {
+	return entry.Email == g.Email
+}
+
+// PostGroup books a block for a Group, which is provided via the request body together with the group contact. The
+// block has to adhere to the same rules as PostEntry, as it is split into one entry per slot of the slot grid.
+// The contact receives the invite link for the members and the overview link via email. The quotas don't apply to the
+// block, as it is booked for all the members, but each member is bound to them when claiming an entry.
+func (h *ApiHandler) PostGroup(w http.ResponseWriter, r *http.Request) {
+	logger := httplog.LogEntry(r.Context())
+
+	var group Group
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	if err := validateGroup(group, h.config, r.Context().Value("admin").(bool)); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	// Until a member claims an entry, it is owned by the contact
+	entries := make([]CalendarEntryFull, 0)
+	for _, slot := range h.config.slotsBetween(group.Start, group.End) {
+		entries = append(entries, CalendarEntryFull{
+			CalendarEntry: CalendarEntry{FirstName: group.FirstName, Start: slot.Start, End: slot.End},
+			LastName:      group.LastName,
+			Email:         group.Email,
+		})
+	}
+
+	// A timeslot offered to the waitlist is reserved for the user it was offered to
+	if offered, err := h.offeredEntries(entries); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	} else if len(offered) > 0 {
+		h.httpConflictWithLog(r, w, ErrTimeslotOffered, offered)
+		return
+	}
+
+	group.CreatedAt = time.Now()
+	insertedGroup, insertedEntries, err := h.db.InsertGroup(group, entries)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+	h.publishEntries(eventCreated, insertedEntries...)
+
+	// If the timeslots were freed on short notice before, the volunteers don't need to be alerted anymore
+	if err := h.db.DeleteCoveredPendingAlerts(group.Start, group.End); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	inviteLink, err := createGroupLink(*insertedGroup, "invite")
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+	overviewLink, err := createGroupLink(*insertedGroup, "coordinator")
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	if err := sendGroupCreatedEmail(*insertedGroup, inviteLink, overviewLink); err != nil {
+		logger.Warn("Failed to send group links for email " + group.Email + " with error: " + err.Error())
+	}
+
+	// Same as for PostEntry, the contact only receives the information they entered themselves
+	writeJsonStatus(w, http.StatusCreated, GroupDetails{Group: *insertedGroup, InviteLink: inviteLink, Entries: insertedEntries})
+}
+
+// GetGroup provides the GroupDetails of the Group given via the URL parameter "id", given that the user is either
+// admin or provided the email address of the group contact.
+func (h *ApiHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	// A wrong email address is treated like a missing group, so the contact of a group can't be guessed
+	group, err := h.db.GetGroup(id)
+	if errors.Is(err, sql.ErrNoRows) ||
+		(err == nil && !r.Context().Value("admin").(bool) && group.Email != r.URL.Query().Get("email")) {
+		httpProblemWithLog(r, w, ErrGroupNotFound)
+		return
+	} else if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	details, err := h.groupDetails(*group)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	writeJson(w, details)
+}
+
+// groupDetails queries the entries of a Group and creates its invite link.
+func (h *ApiHandler) groupDetails(group Group) (*GroupDetails, error) {
+	entries, err := h.db.GetGroupEntries(group.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	inviteLink, err := createGroupLink(group, "invite")
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupDetails{Group: group, InviteLink: inviteLink, Entries: entries}, nil
+}
+
+// DeleteGroup deletes a Group with all its entries, whether claimed by members or not, given that the user is either
+// admin or provided the email address of the group contact. Otherwise, it works the same as DeleteSeries.
+func (h *ApiHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	entries, err := h.db.GetGroupEntries(id)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	freed := make([]CalendarEntry, len(entries))
+	for i, entry := range entries {
+		freed[i] = entry.CalendarEntry
+	}
+
+	var late []CalendarEntryFull
+	reason := r.URL.Query().Get("reason")
+	if r.Context().Value("admin").(bool) {
+		err = h.db.DeleteGroupAdmin(id)
+	} else if len(entries) > 0 {
+		// The block is cancelled as a whole, thus its first entry decides
+		if err = h.checkCancellation(entries[0].Start); err == nil {
+			if late, err = h.lateEntries(freed...); err == nil {
+				if err = checkCancellationReason(late, reason); err == nil {
+					err = h.db.DeleteGroup(id, r.URL.Query().Get("email"))
+				}
+			}
+		}
+	} else {
+		err = h.db.DeleteGroup(id, r.URL.Query().Get("email"))
+	}
+
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	for _, entry := range freed {
+		h.publishEntries(eventDeleted, CalendarEntryFull{CalendarEntry: entry})
+	}
+
+	if err := h.recordLateCancellations(r, late, reason); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	if err := h.offerToWaitlist(freed...); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	if err := h.queueCancellationAlerts(freed...); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// groupSlot is a single entry of a Group as shown on the pages of the group.
+type groupSlot struct {
+	Id      int
+	Time    string
+	Claimed bool
+	Name    string
+	Email   string
+}
+
+// groupPage is the page for the members of a Group to claim its entries, as well as for its contact to see who
+// claimed which entry. The claim form is only shown to the members, the personal information only to the contact.
+var groupPage = template.Must(template.New("group").Parse(`<!DOCTYPE html>
+<html lang="de">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>{{.Name}} - 24/7 Anbetung St. Pölten</title>
+</head>
+<body>
+	<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 40px auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+		<h2 style="color: #2c3e50; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">{{.Name}}</h2>
+		<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+
+		<p style="text-align: justify;">Block am {{.Date}} für <strong>{{.Time}}</strong></p>
+
+		<table style="width: 100%; border-collapse: collapse; margin: 20px 0;">
+			{{range .Slots}}
+			<tr style="border-bottom: 1px solid #eeeeee;">
+				<td style="padding: 8px;">{{.Time}}</td>
+				<td style="padding: 8px;">{{if .Claimed}}{{if $.Coordinator}}{{.Name}} ({{.Email}}){{else}}Vergeben{{end}}{{else}}<span style="color: #27ae60;">Frei</span>{{end}}</td>
+			</tr>
+			{{end}}
+		</table>
+
+		{{if not .Coordinator}}
+		<form method="post" action="{{.Action}}" style="text-align: center; margin: 30px 0;">
+			<input type="hidden" name="token" value="{{.Token}}">
+			<select name="entryId" required style="width: 80%; padding: 10px; margin-bottom: 15px; border: 1px solid #cccccc; border-radius: 5px;">
+				{{range .Slots}}{{if not .Claimed}}<option value="{{.Id}}">{{.Time}}</option>{{end}}{{end}}
+			</select>
+			<input type="text" name="firstname" required placeholder="Vorname" style="width: 80%; padding: 10px; margin-bottom: 15px; border: 1px solid #cccccc; border-radius: 5px;">
+			<input type="text" name="lastname" required placeholder="Nachname" style="width: 80%; padding: 10px; margin-bottom: 15px; border: 1px solid #cccccc; border-radius: 5px;">
+			<input type="email" name="email" required placeholder="E-Mail" style="width: 80%; padding: 10px; margin-bottom: 15px; border: 1px solid #cccccc; border-radius: 5px;">
+			<br>
+			<button type="submit" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; border: none; border-radius: 5px; font-weight: bold; cursor: pointer;">Eintragen</button>
+		</form>
+		{{end}}
+	</div>
+</body>
+</html>`))
+
+// writeGroupPage is a utility method to return the page of a Group for the members or, if coordinator, its contact.
+func (h *ApiHandler) writeGroupPage(w http.ResponseWriter, r *http.Request, groupId int, token string, coordinator bool) {
+	logger := httplog.LogEntry(r.Context())
+
+	group, err := h.db.GetGroup(groupId)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeHtmlPage(w, http.StatusNotFound, "Gruppe nicht gefunden", "Diese Gruppenbuchung existiert nicht mehr.")
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	entries, err := h.db.GetGroupEntries(group.Id)
+	if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	now := time.Now()
+	slots := make([]groupSlot, 0, len(entries))
+	for _, entry := range entries {
+		slots = append(slots, groupSlot{
+			Id:   entry.Id,
+			Time: fmt.Sprintf("%s - %s", entry.Start.Format("15:04"), entry.End.Format("15:04")),
+			// Entries that already started can't be claimed anymore
+			Claimed: !group.isUnclaimed(entry) || !entry.Start.After(now),
+			Name:    fmt.Sprintf("%s %s", entry.FirstName, entry.LastName),
+			Email:   entry.Email,
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = groupPage.Execute(w, map[string]any{
+		"Name":        group.Name,
+		"Date":        group.Start.Format("02.01.2006"),
+		"Time":        fmt.Sprintf("%s bis %s", group.Start.Format("15:04"), group.End.Format("15:04")),
+		"Slots":       slots,
+		"Coordinator": coordinator,
+		"Action":      os.Getenv("PATH_PREFIX") + "/api/calendar/groups/invite",
+		"Token":       token,
+	})
+}
+
+// GetGroupInvite provides the page for the members of a Group to claim its entries. This method is supposed to be
+// directly accessed via the invite link.
+func (h *ApiHandler) GetGroupInvite(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	groupId, err := security.ValidateGroupToken(token, "invite")
+	if err != nil {
+		httplog.LogEntry(r.Context()).Warn(err.Error())
+		writeHtmlPage(w, http.StatusGone, "Link abgelaufen", "Dieser Link ist ungültig oder bereits abgelaufen.")
+		return
+	}
+
+	h.writeGroupPage(w, r, groupId, token, false)
+}
+
+// GetGroupOverview provides the page for the contact of a Group to see which member claimed which entry. This method
+// is supposed to be directly accessed via the overview link.
+func (h *ApiHandler) GetGroupOverview(w http.ResponseWriter, r *http.Request) {
+	groupId, err := security.ValidateGroupToken(r.URL.Query().Get("token"), "coordinator")
+	if err != nil {
+		httplog.LogEntry(r.Context()).Warn(err.Error())
+		writeHtmlPage(w, http.StatusGone, "Link abgelaufen", "Dieser Link ist ungültig oder bereits abgelaufen.")
+		return
+	}
+
+	h.writeGroupPage(w, r, groupId, "", true)
+}
+
+// PostGroupInvite claims an entry of a Group for the member, based on the form of GetGroupInvite.
+func (h *ApiHandler) PostGroupInvite(w http.ResponseWriter, r *http.Request) {
+	logger := httplog.LogEntry(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		writeHtmlPage(w, http.StatusBadRequest, "Fehler", "Die Eingabe konnte nicht verarbeitet werden.")
+		return
+	}
+
+	groupId, err := security.ValidateGroupToken(r.PostForm.Get("token"), "invite")
+	if err != nil {
+		logger.Warn(err.Error())
+		writeHtmlPage(w, http.StatusGone, "Link abgelaufen", "Dieser Link ist ungültig oder bereits abgelaufen.")
+		return
+	}
+
+	entryId, err := strconv.Atoi(r.PostForm.Get("entryId"))
+	member := CalendarEntryFull{
+		CalendarEntry: CalendarEntry{FirstName: strings.TrimSpace(r.PostForm.Get("firstname"))},
+		LastName:      strings.TrimSpace(r.PostForm.Get("lastname")),
+		Email:         strings.TrimSpace(r.PostForm.Get("email")),
+	}
+	if err != nil || member.FirstName == "" || member.LastName == "" || !isValidEmail(member.Email) {
+		writeHtmlPage(w, http.StatusBadRequest, "Fehler", "Bitte fülle alle Felder korrekt aus.")
+		return
+	}
+
+	// The member is bound to the quotas like any participant, while the entry itself merely changes its owner
+	if entry, err := h.db.GetFullEntry(entryId); err == nil {
+		member.Start, member.End = entry.Start, entry.End
+		if err := checkBookingQuotas(h.db, h.config, []CalendarEntryFull{member}, entry.Id); isQuotaExceeded(err) {
+			writeHtmlPage(w, http.StatusConflict, "Nicht möglich", quotaExceededMessage)
+			return
+		} else if err != nil {
+			logger.Error(err.Error())
+			writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+			return
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	if err := h.db.ClaimGroupEntry(groupId, entryId, member, time.Now()); err != nil {
+		if errors.Is(err, ErrEntryNotClaimed) {
+			writeHtmlPage(w, http.StatusConflict, "Bereits vergeben", "Diese Stunde ist leider bereits vergeben.")
+			return
+		}
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	entry, err := h.db.GetFullEntry(entryId)
+	if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+	if group, err := h.db.GetGroup(groupId); err == nil {
+		entry.GroupId = &group.Id
+		entry.GroupName = &group.Name
+	}
+	h.publishEntries(eventUpdated, *entry)
+
+	if err := sendEntryConfirmationEmail(entry.Email, entry.Start, entry.End); err != nil {
+		logger.Warn("Failed to send email confirmation for email " + entry.Email + " with error: " + err.Error())
+	}
+
+	writeHtmlPage(w, http.StatusOK, "Eingetragen",
+		fmt.Sprintf("Vielen Dank! Du bist am %s für %s bis %s eingetragen.",
+			entry.Start.Format("02.01.2006"), entry.Start.Format("15:04"), entry.End.Format("15:04")))
+}
@@ -0,0 +1,93 @@
+// Provides utility functions for reasoning about time intervals, e.g. which parts of a timeslot are still free
+
+package app
+
+import (
+	"slices"
+	"time"
+)
+
+// Interval is a simple half-open time interval [Start, End), which follows the same overlap semantics as the
+// calendar entries, i.e., two intervals only conflict if one starts before the other ends.
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// uncoveredIntervals computes the parts of the interval [start, end) that are not covered by any of the given entries.
+func uncoveredIntervals(start, end time.Time, entries []CalendarEntry) []Interval {
+	// Work on a sorted copy, so the caller's slice stays untouched
+	sorted := slices.Clone(entries)
+	slices.SortFunc(sorted, func(a, b CalendarEntry) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	free := make([]Interval, 0)
+	cursor := start
+	for _, entry := range sorted {
+		if !entry.End.After(cursor) {
+			continue
+		}
+		if !entry.Start.Before(end) {
+			break
+		}
+		if entry.Start.After(cursor) {
+			free = append(free, Interval{Start: cursor, End: entry.Start})
+		}
+		cursor = entry.End
+	}
+	if cursor.Before(end) {
+		free = append(free, Interval{Start: cursor, End: end})
+	}
+
+	return free
+}
+
+// splitByDay splits intervals spanning midnight into one interval per day, so they can be presented per day.
+func splitByDay(intervals []Interval) []Interval {
+	split := make([]Interval, 0, len(intervals))
+	for _, interval := range intervals {
+		start := interval.Start
+		for start.Before(interval.End) {
+			year, month, day := start.Date()
+			nextDay := time.Date(year, month, day+1, 0, 0, 0, 0, start.Location())
+			end := interval.End
+			if nextDay.Before(end) {
+				end = nextDay
+			}
+			split = append(split, Interval{Start: start, End: end})
+			start = end
+		}
+	}
+
+	return split
+}
+
+// nearestFreeIntervals searches up to n free intervals of the same length as the interval [start, end), which are
+// shifted by whole hours and lie within the given search distance. The nearest ones are preferred, the earlier one on
+// a tie. Intervals that don't start after now are skipped, and the found intervals don't overlap each other. The
+// result is sorted by start.
+func nearestFreeIntervals(start, end, now time.Time, entries []CalendarEntry, search time.Duration, n int) []Interval {
+	duration := end.Sub(start)
+	found := make([]CalendarEntry, 0, n)
+	for offset := time.Hour; offset <= search && len(found) < n; offset += time.Hour {
+		for _, candidate := range []time.Time{start.Add(-offset), start.Add(offset)} {
+			if len(found) == n || !candidate.After(now) {
+				continue
+			}
+			if isFree(candidate, candidate.Add(duration), entries) && isFree(candidate, candidate.Add(duration), found) {
+				found = append(found, CalendarEntry{Start: candidate, End: candidate.Add(duration)})
+			}
+		}
+	}
+
+	intervals := make([]Interval, len(found))
+	for i, f := range found {
+		intervals[i] = Interval{Start: f.Start, End: f.End}
+	}
+	slices.SortFunc(intervals, func(a, b Interval) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return intervals
+}
@@ -0,0 +1,74 @@
+// Registers all the background jobs of the application with the scheduler
+
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Sakrafux/pray-calendar/backend/scheduler"
+)
+
+// RegisterJobs registers all recurring jobs and one-shot job handlers of the application with the given scheduler.
+func RegisterJobs(jobs *scheduler.Scheduler, db *DBHandler, config *Config, events *EventBroker) error {
+	// Short-notice cancellations are only sent to the volunteers after a grace period
+	if err := jobs.Every("cancellation-alerts", "@every 30s", NewAlertDispatcher(db, config).Run); err != nil {
+		return err
+	}
+
+	// Reminders ahead of the booked timeslots
+	if err := jobs.Every("reminders", "@every 1m", NewReminderScheduler(db, config).Run); err != nil {
+		return err
+	}
+
+	// Summary of the uncovered timeslots of the upcoming days
+	if err := jobs.Every("gap-digest", config.DigestSchedule, NewDigestSender(db, config).Run); err != nil {
+		return err
+	}
+
+	// Alerts for participants, who did not check in at the start of their timeslot
+	if err := jobs.Every("missed-check-ins", "@every 1m", NewCheckInMonitor(db, config).Run); err != nil {
+		return err
+	}
+
+	// Offers of freed timeslots to the waitlist, which expire unless claimed in time
+	if err := jobs.Every("waitlist-offers", "@every 1m", NewWaitlistMonitor(db, config, events).Run); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// JobOverview is purely a response REST-DTO, combining the persisted jobs with their recent history.
+type JobOverview struct {
+	Jobs []scheduler.Job
+	Runs []scheduler.Run
+}
+
+// GetJobs provides an overview of all scheduled jobs and their most recent runs, limited via the optional query
+// parameter "limit".
+func (h *ApiHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+			return
+		}
+	}
+
+	jobs, err := h.jobs.GetJobs()
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	runs, err := h.jobs.GetRuns(limit)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	writeJson(w, JobOverview{Jobs: jobs, Runs: runs})
+}
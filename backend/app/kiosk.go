@@ -0,0 +1,280 @@
+// Provides the kiosk screen at the chapel door, which shows who is praying now and who comes next
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Sakrafux/pray-calendar/backend/security"
+	"github.com/go-chi/httplog/v2"
+)
+
+// KioskSlot is purely a response REST-DTO, representing a single entry on the kiosk screen. Name is formatted
+// according to the configured name display, and empty for anonymous display. For admin events, it is the name of
+// their EventType instead.
+type KioskSlot struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// KioskState is purely a response REST-DTO, containing everything the kiosk screen shows. Each field is nil, if
+// there is no such entry within the next week.
+type KioskState struct {
+	Now        *KioskSlot
+	Next       *KioskSlot
+	AdminEvent *KioskSlot
+}
+
+// KioskToken is purely a response REST-DTO, containing a newly created kiosk token and the link of the kiosk screen.
+type KioskToken struct {
+	Token string
+	Link  string
+}
+
+// buildKioskState determines the current and next participant, as well as the admin event in progress, from the
+// given entries, which must be sorted by their start.
+func buildKioskState(entries []CalendarEntry, types eventTypeIndex, now time.Time, nameDisplay string) KioskState {
+	var state KioskState
+	for _, entry := range entries {
+		running := !entry.Start.After(now) && entry.End.After(now)
+
+		if entry.EventTypeId != nil {
+			if running && state.AdminEvent == nil {
+				// The kiosk is located in the chapel, thus it always shows German
+				state.AdminEvent = &KioskSlot{Name: types[*entry.EventTypeId].localizedName("de"), Start: entry.Start, End: entry.End}
+			}
+			continue
+		}
+
+		slot := &KioskSlot{Name: kioskName(entry.FirstName, nameDisplay), Start: entry.Start, End: entry.End}
+		if running && state.Now == nil {
+			state.Now = slot
+		} else if entry.Start.After(now) && state.Next == nil {
+			state.Next = slot
+		}
+	}
+
+	return state
+}
+
+// kioskName formats a first name according to the name display. Unknown values are treated as "anonymous", so a
+// misconfiguration never reveals more than intended.
+func kioskName(firstName, nameDisplay string) string {
+	switch nameDisplay {
+	case "first":
+		return firstName
+	case "initials":
+		if r, _ := utf8.DecodeRuneInString(firstName); r != utf8.RuneError {
+			return string(r) + "."
+		}
+		return ""
+	default:
+		return ""
+	}
+}
+
+// kioskState queries the current KioskState.
+func (h *ApiHandler) kioskState() (KioskState, error) {
+	now := time.Now()
+	entries, err := h.db.GetAllEntriesForWeek(now)
+	if err != nil {
+		return KioskState{}, err
+	}
+	types, err := loadEventTypes(h.db)
+	if err != nil {
+		return KioskState{}, err
+	}
+
+	return buildKioskState(entries, types, now, h.config.KioskNameDisplay), nil
+}
+
+// PostKioskToken creates a kiosk token for the kiosk named via the query parameter "name", e.g. "chapel-door".
+func (h *ApiHandler) PostKioskToken(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		httpProblemWithLog(r, w, ErrNameMissing)
+		return
+	}
+
+	token, err := security.CreateKioskToken(name)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	writeJson(w, KioskToken{
+		Token: token,
+		Link:  fmt.Sprintf("%s/api/kiosk/display?token=%s", os.Getenv("HOST_BE"), url.QueryEscape(token)),
+	})
+}
+
+// GetKioskState provides the current KioskState, given a valid kiosk token via the query parameter "token".
+func (h *ApiHandler) GetKioskState(w http.ResponseWriter, r *http.Request) {
+	if _, err := security.ValidateKioskToken(r.URL.Query().Get("token")); err != nil {
+		httpProblemWithLog(r, w, ErrInvalidToken.Wrap(err))
+		return
+	}
+
+	state, err := h.kioskState()
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	writeJson(w, state)
+}
+
+// GetKioskEvents streams the KioskState as Server-Sent Events of type "state", given a valid kiosk token via the
+// query parameter "token". The state is sent immediately and then whenever it changes, which is checked on every
+// change to the entries and in the configured refresh interval.
+func (h *ApiHandler) GetKioskEvents(w http.ResponseWriter, r *http.Request) {
+	logger := httplog.LogEntry(r.Context())
+
+	// EventSource can't send headers, so the token has to be part of the URL
+	name, err := security.ValidateKioskToken(r.URL.Query().Get("token"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrInvalidToken.Wrap(err))
+		return
+	}
+
+	rc, err := startSseStream(w)
+	if err != nil {
+		logger.Warn(err.Error())
+		return
+	}
+	logger.Info(fmt.Sprintf("Kiosk %s connected", name))
+
+	var last []byte
+	send := func() error {
+		state, err := h.kioskState()
+		if err != nil {
+			// The kiosk simply keeps showing the last state, until the next refresh succeeds
+			logger.Error(err.Error())
+			return writeSseKeepAlive(w, rc)
+		}
+
+		current, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(current, last) {
+			return writeSseKeepAlive(w, rc)
+		}
+		last = current
+
+		return writeSseEvent(w, rc, "state", state)
+	}
+
+	if err := send(); err != nil {
+		return
+	}
+
+	// Changes to the entries are shown immediately, while the refresh covers the passing of time
+	s := h.events.Subscribe(time.Now(), time.Now().AddDate(0, 0, 7))
+	defer h.events.Unsubscribe(s)
+
+	ticker := time.NewTicker(h.config.KioskRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if err := send(); err != nil {
+				return
+			}
+		case _, ok := <-s.events:
+			if !ok {
+				return
+			}
+			if err := send(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// kioskDisplay is the page shown on the kiosk screen, which renders the KioskState streamed by GetKioskEvents.
+var kioskDisplay = template.Must(template.New("kiosk").Parse(`<!DOCTYPE html>
+<html lang="de">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>24/7 Anbetung St. Pölten</title>
+	<style>
+		body { font-family: Arial, sans-serif; color: #2c3e50; background: #fdfdfd; margin: 0; text-align: center; }
+		h1 { font-size: 4vw; margin: 4vh 0 2vh; border-bottom: 0.4vh solid #f1c40f; padding-bottom: 2vh; }
+		.box { margin: 4vh auto; width: 80vw; padding: 3vh; border: 1px solid #eeeeee; border-radius: 1vw; }
+		.label { font-size: 2vw; color: #7f8c8d; }
+		.name { font-size: 5vw; font-weight: bold; margin: 1vh 0; }
+		.time { font-size: 2.5vw; }
+		#event { display: none; background: #fcf3cf; }
+	</style>
+</head>
+<body>
+	<h1>24/7 Anbetung St. Pölten</h1>
+	<div class="box" id="event">
+		<div class="label">Gerade findet statt</div>
+		<div class="name" id="event-name"></div>
+		<div class="time" id="event-time"></div>
+	</div>
+	<div class="box">
+		<div class="label">Jetzt in Anbetung</div>
+		<div class="name" id="now-name"></div>
+		<div class="time" id="now-time"></div>
+	</div>
+	<div class="box">
+		<div class="label">Als Nächstes</div>
+		<div class="name" id="next-name"></div>
+		<div class="time" id="next-time"></div>
+	</div>
+	<script>
+		// The times are stored as UTC, but actually represent the local time of the chapel
+		function formatTime(value) {
+			const date = new Date(value);
+			const pad = (n) => String(n).padStart(2, "0");
+			return pad(date.getUTCHours()) + ":" + pad(date.getUTCMinutes());
+		}
+
+		function show(prefix, slot, fallback) {
+			document.getElementById(prefix + "-name").textContent = slot ? slot.Name || "Besetzt" : fallback;
+			document.getElementById(prefix + "-time").textContent = slot ? formatTime(slot.Start) + " - " + formatTime(slot.End) : "";
+		}
+
+		const source = new EventSource({{.EventsUrl}});
+		source.addEventListener("state", (e) => {
+			const state = JSON.parse(e.data);
+			document.getElementById("event").style.display = state.AdminEvent ? "block" : "none";
+			show("event", state.AdminEvent, "");
+			show("now", state.Now, "Niemand eingetragen");
+			show("next", state.Next, "Niemand eingetragen");
+		});
+	</script>
+</body>
+</html>`))
+
+// GetKioskDisplay provides the page for the kiosk screen, given a valid kiosk token via the query parameter "token".
+func (h *ApiHandler) GetKioskDisplay(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if _, err := security.ValidateKioskToken(token); err != nil {
+		httplog.LogEntry(r.Context()).Warn(err.Error())
+		writeHtmlPage(w, http.StatusUnauthorized, "Keine Berechtigung", "Dieser Link ist ungültig oder abgelaufen.")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = kioskDisplay.Execute(w, map[string]string{
+		"EventsUrl": fmt.Sprintf("%s/api/kiosk/events?token=%s", os.Getenv("PATH_PREFIX"), url.QueryEscape(token)),
+	})
+}
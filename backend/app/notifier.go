@@ -0,0 +1,148 @@
+// Provides the pluggable channels via which empty timeslots are escalated to volunteers and coordinators
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// notifierTimeout limits how long an external notification endpoint may take to respond
+const notifierTimeout = 10 * time.Second
+
+// Recipient is someone to be notified about an empty timeslot. Depending on the channel, only the email address or
+// the phone number is relevant, and either may be empty.
+type Recipient struct {
+	Email string
+	Phone string
+}
+
+// Notifier delivers the alert about an empty timeslot to the recipients of an audience, i.e., either "volunteers" or
+// "coordinators", via a specific channel.
+type Notifier interface {
+	Notify(audience string, recipients []Recipient, slot Interval) error
+}
+
+// EmailNotifier sends the alert via email. Volunteers receive their personal claim links, while coordinators
+// receive an urgent notice.
+type EmailNotifier struct{}
+
+func (n EmailNotifier) Notify(audience string, recipients []Recipient, slot Interval) error {
+	emails := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		if recipient.Email != "" {
+			emails = append(emails, recipient.Email)
+		}
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+
+	if audience == "coordinators" {
+		return sendEscalationEmail(emails, slot.Start, slot.End)
+	}
+
+	links, err := createClaimLinks(emails, slot.Start, slot.End)
+	if err != nil {
+		return err
+	}
+	return sendNotificationEmail(links, slot.Start, slot.End)
+}
+
+// SmsNotifier sends the alert as text message via a generic HTTP gateway, which accepts a JSON body with the
+// fields "to" and "message" and is authenticated via bearer token.
+type SmsNotifier struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewSmsNotifier is the constructor for SmsNotifier.
+func NewSmsNotifier(url, token string) *SmsNotifier {
+	return &SmsNotifier{url: url, token: token, client: &http.Client{Timeout: notifierTimeout}}
+}
+
+func (n *SmsNotifier) Notify(_ string, recipients []Recipient, slot Interval) error {
+	message := fmt.Sprintf("24/7 Anbetung St. Pölten: Der Timeslot am %s von %s bis %s ist noch unbesetzt. Bitte hilf mit, ihn zu füllen: %s",
+		slot.Start.Format("02.01.2006"), slot.Start.Format("15:04"), slot.End.Format("15:04"), createSlotLink(slot.Start))
+
+	for _, recipient := range recipients {
+		if recipient.Phone == "" {
+			continue
+		}
+		body := map[string]string{"to": recipient.Phone, "message": message}
+		if err := postJson(n.client, n.url, n.token, body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WebhookNotifier posts the alert as JSON to a configured URL, e.g. for a chat integration. The recipients themselves
+// are not disclosed, only their number.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier is the constructor for WebhookNotifier.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: notifierTimeout}}
+}
+
+func (n *WebhookNotifier) Notify(audience string, recipients []Recipient, slot Interval) error {
+	body := map[string]any{
+		"audience":   audience,
+		"recipients": len(recipients),
+		"start":      slot.Start,
+		"end":        slot.End,
+		"link":       createSlotLink(slot.Start),
+	}
+	return postJson(n.client, n.url, "", body)
+}
+
+// createNotifiers creates a Notifier for every channel that is configured. Email is always available.
+func createNotifiers(config *Config) map[string]Notifier {
+	notifiers := map[string]Notifier{"email": EmailNotifier{}}
+	if config.SmsGatewayUrl != "" {
+		notifiers["sms"] = NewSmsNotifier(config.SmsGatewayUrl, config.SmsGatewayToken)
+	}
+	if config.WebhookUrl != "" {
+		notifiers["webhook"] = NewWebhookNotifier(config.WebhookUrl)
+	}
+
+	return notifiers
+}
+
+// postJson is a utility method to post any struct as JSON to an external endpoint, optionally with a bearer token.
+func postJson(client *http.Client, url, token string, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, url)
+	}
+
+	return nil
+}
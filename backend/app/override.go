@@ -0,0 +1,160 @@
+// Provides the override mode for admin events, which displaces the entries of participants instead of conflicting with them
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/httplog/v2"
+)
+
+// The override modes of PostEntry and PostSeries, i.e., whether the displacement is only shown or carried out
+const (
+	overridePreview = "preview"
+	overrideConfirm = "confirm"
+)
+
+// displacementSearch limits how far from a displaced entry the free alternatives are searched
+const displacementSearch = 3 * 24 * time.Hour
+
+// displacementAlternatives is the number of free alternatives suggested to a displaced participant
+const displacementAlternatives = 3
+
+// planDisplacements determines how the entries of participants give way to the given entries of an admin event of the
+// given EventType. Conflicts with other admin events can't be overridden, so they are left to the insert.
+func (h *ApiHandler) planDisplacements(eventType EventType, entries []CalendarEntryFull) ([]Displacement, error) {
+	displacements := make([]Displacement, 0)
+	// Admin events that don't block bookings never conflict with participants in the first place
+	if !eventType.BlocksBookings {
+		return displacements, nil
+	}
+
+	overriding := make([]CalendarEntry, len(entries))
+	for i, entry := range entries {
+		overriding[i] = entry.CalendarEntry
+	}
+
+	// An entry of a participant may be overlapped by several entries of a series, but is only displaced once
+	planned := make(map[int]bool)
+	for _, entry := range entries {
+		overlapping, err := h.db.GetOverlappingFullEntries(entry.Start, entry.End)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range overlapping {
+			if o.EventTypeId != nil || planned[o.Id] {
+				continue
+			}
+			planned[o.Id] = true
+			displacements = append(displacements, Displacement{Entry: o, Remains: uncoveredIntervals(o.Start, o.End, overriding)})
+		}
+	}
+
+	return displacements, nil
+}
+
+// postOverride handles the override mode of PostEntry and PostSeries for the given already validated entries of an
+// admin event, which belong to the given Series, if any. In "preview" mode, it merely responds with the Displacement of
+// the entries of participants. In "confirm" mode, it carries them out, inserts the entries, and apologizes to the
+// affected participants via email, suggesting free alternatives. It returns the inserted entries, or nil, if the
+// response was already written.
+func (h *ApiHandler) postOverride(w http.ResponseWriter, r *http.Request, mode string, entries []CalendarEntryFull, series *Series) []CalendarEntryFull {
+	// Only the admin may push participants aside, and only for an admin event
+	if !r.Context().Value("admin").(bool) {
+		httpProblemWithLog(r, w, ErrAdminRequired)
+		return nil
+	}
+	if entries[0].EventTypeId == nil {
+		httpProblemWithLog(r, w, ErrOverrideNotAdmin)
+		return nil
+	}
+	if mode != overridePreview && mode != overrideConfirm {
+		httpProblemWithLog(r, w, ErrInvalidOverrideMode)
+		return nil
+	}
+
+	eventType, err := h.db.GetEventType(*entries[0].EventTypeId)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return nil
+	}
+
+	displacements, err := h.planDisplacements(*eventType, entries)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return nil
+	}
+
+	if mode == overridePreview {
+		writeJson(w, displacements)
+		return nil
+	}
+
+	insertedEntries, remainders, err := h.db.DisplaceEntries(displacements, series, entries)
+	if err != nil {
+		// Either the displaced entries changed since they were planned, or other admin events conflict
+		httpProblemWithLog(r, w, err)
+		return nil
+	}
+
+	shortened := make(map[int]bool)
+	for _, displacement := range displacements {
+		if len(displacement.Remains) == 0 {
+			h.publishEntries(eventDeleted, displacement.Entry)
+		} else {
+			shortened[displacement.Entry.Id] = true
+		}
+	}
+	for _, remainder := range remainders {
+		if shortened[remainder.Id] {
+			h.publishEntries(eventUpdated, remainder)
+		} else {
+			h.publishEntries(eventCreated, remainder)
+		}
+	}
+	h.publishEntries(eventCreated, insertedEntries...)
+
+	// If the timeslots were freed on short notice before, the volunteers don't need to be alerted anymore
+	for _, entry := range insertedEntries {
+		if err := h.db.DeleteCoveredPendingAlerts(entry.Start, entry.End); err != nil {
+			httpProblemWithLog(r, w, err)
+			return nil
+		}
+	}
+
+	// Since sending the emails is irrelevant to the overall request, do this asynchronously
+	go func() {
+		logger := httplog.LogEntry(r.Context())
+
+		types, err := loadEventTypes(h.db)
+		if err != nil {
+			logger.Warn(err.Error())
+			return
+		}
+
+		now := time.Now()
+		for _, displacement := range displacements {
+			entry := displacement.Entry
+			if !isValidEmail(entry.Email) {
+				continue
+			}
+
+			entries, err := h.db.GetAllEntriesForRange(entry.Start.Add(-displacementSearch), entry.End.Add(displacementSearch))
+			if err != nil {
+				logger.Warn(err.Error())
+				continue
+			}
+			alternatives := nearestFreeIntervals(entry.Start, entry.End, now, types.blockingEntries(entries),
+				displacementSearch, displacementAlternatives)
+
+			// The emails are in German, as are all others
+			if err := sendDisplacementEmail(entry.Email, eventType.localizedName("de"), displacement, alternatives); err != nil {
+				logger.Warn("Failed to send displacement email for email " + entry.Email + " with error: " + err.Error())
+			}
+		}
+	}()
+
+	return insertedEntries
+}
@@ -0,0 +1,119 @@
+// Provides minimal HTML pages for endpoints that are directly accessed via links in emails instead of the frontend
+
+package app
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// feedbackPage is a simple HTML page, whose styling follows the emails, that the links originate from
+var feedbackPage = template.Must(template.New("feedback").Parse(`<!DOCTYPE html>
+<html lang="de">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>{{.Title}} - 24/7 Anbetung St. Pölten</title>
+</head>
+<body>
+	<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 40px auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+		<h2 style="color: {{.Color}}; border-bottom: 2px solid {{.Color}}; padding-bottom: 10px;">{{.Title}}</h2>
+		<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+
+		<p style="text-align: justify;">{{.Message}}</p>
+
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="{{.CalendarLink}}" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; text-decoration: none; border-radius: 5px; font-weight: bold; display: inline-block;">Zum Kalender</a>
+		</div>
+	</div>
+</body>
+</html>`))
+
+// writeHtmlPage is a utility method to return a simple feedback page with the given title and message. Success pages
+// are highlighted in green, all others in red.
+func writeHtmlPage(w http.ResponseWriter, code int, title, message string) {
+	color := "#c0392b"
+	if code < http.StatusBadRequest {
+		color = "#27ae60"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	// The header is already written at this point, so an error can't be reported to the caller anymore
+	_ = feedbackPage.Execute(w, map[string]string{
+		"Title":        title,
+		"Message":      message,
+		"Color":        color,
+		"CalendarLink": calendarPageLink(),
+	})
+}
+
+// checkInForm is the page linked by the QR code in the chapel, where participants check in via their email address
+var checkInForm = template.Must(template.New("checkin").Parse(`<!DOCTYPE html>
+<html lang="de">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>Check-in - 24/7 Anbetung St. Pölten</title>
+</head>
+<body>
+	<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 40px auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+		<h2 style="color: #2c3e50; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">Check-in</h2>
+		<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+
+		<p style="text-align: justify;">Schön, dass du da bist! Gib bitte die E-Mail-Adresse an, mit der du dich eingetragen hast.</p>
+
+		<form method="post" action="{{.Action}}" style="text-align: center; margin: 30px 0;">
+			<input type="hidden" name="key" value="{{.Key}}">
+			<input type="email" name="email" required placeholder="E-Mail" style="width: 80%; padding: 10px; margin-bottom: 15px; border: 1px solid #cccccc; border-radius: 5px;">
+			<br>
+			<button type="submit" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; border: none; border-radius: 5px; font-weight: bold; cursor: pointer;">Einchecken</button>
+		</form>
+	</div>
+</body>
+</html>`))
+
+// writeCheckInForm is a utility method to return the check-in form of the chapel, posting to the given action.
+func writeCheckInForm(w http.ResponseWriter, action, key string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = checkInForm.Execute(w, map[string]string{"Action": action, "Key": key})
+}
+
+// confirmPage is the page for links in emails that change something, which only happens once the recipient confirms
+// via the form. Otherwise, mail scanners and link prefetchers would trigger the change just by opening the link.
+var confirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html lang="de">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>{{.Title}} - 24/7 Anbetung St. Pölten</title>
+</head>
+<body>
+	<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333333; max-width: 600px; margin: 40px auto; padding: 20px; border: 1px solid #eeeeee; border-radius: 8px;">
+		<h2 style="color: #2c3e50; border-bottom: 2px solid #f1c40f; padding-bottom: 10px;">{{.Title}}</h2>
+		<p style="font-weight: bold; color: #2c3e50;">24/7 Anbetung St. Pölten</p>
+
+		<p style="text-align: justify;">{{.Message}}</p>
+
+		<form method="post" action="{{.Action}}" style="text-align: center; margin: 30px 0;">
+			{{range $name, $value := .Fields}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+			{{end}}<button type="submit" style="background-color: #2c3e50; color: #ffffff; padding: 15px 25px; border: none; border-radius: 5px; font-weight: bold; cursor: pointer;">{{.Button}}</button>
+		</form>
+	</div>
+</body>
+</html>`))
+
+// writeConfirmPage is a utility method to return a confirmation page, whose button posts the given hidden fields to
+// the given action.
+func writeConfirmPage(w http.ResponseWriter, title, message, action string, fields map[string]string, button string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = confirmPage.Execute(w, map[string]any{
+		"Title":   title,
+		"Message": message,
+		"Action":  action,
+		"Fields":  fields,
+		"Button":  button,
+	})
+}
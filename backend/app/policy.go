@@ -0,0 +1,104 @@
+// Provides the booking policies of the calendar, i.e., the quotas of hours per participant and the rules for
+// cancelling entries, which only restrict the participants but never the admin
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// startOfWeek is a utility method to get the midnight of the Monday of the week of the given time.
+func startOfWeek(t time.Time) time.Time {
+	day := t.Truncate(24 * time.Hour)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// bookedWithin sums up how much of the interval [start, end) the given entries cover.
+func bookedWithin(entries []CalendarEntry, start, end time.Time) time.Duration {
+	booked := time.Duration(0)
+	for _, entry := range entries {
+		overlapStart, overlapEnd := entry.Start, entry.End
+		if overlapStart.Before(start) {
+			overlapStart = start
+		}
+		if overlapEnd.After(end) {
+			overlapEnd = end
+		}
+		if overlapStart.Before(overlapEnd) {
+			booked += overlapEnd.Sub(overlapStart)
+		}
+	}
+
+	return booked
+}
+
+// checkBookingQuotas checks that the participant of the given new entries stays within MaxHoursPerDay and
+// MaxHoursPerWeek, including the entries they booked before. Entries of the participant with one of the given released
+// ids aren't counted, as they are given away in exchange or are the new entries themselves, which merely change their
+// owner. Admin events aren't booked by a participant, thus they are exempt.
+func checkBookingQuotas(db *DBHandler, config *Config, entries []CalendarEntryFull, released ...int) error {
+	if len(entries) == 0 || entries[0].EventTypeId != nil || (config.MaxHoursPerDay == 0 && config.MaxHoursPerWeek == 0) {
+		return nil
+	}
+
+	// The entries are sorted, so the surrounding weeks cover every affected day as well
+	from := startOfWeek(entries[0].Start)
+	to := startOfWeek(entries[len(entries)-1].End).AddDate(0, 0, 7)
+	booked, err := db.GetEmailEntriesForRange(entries[0].Email, from, to)
+	if err != nil {
+		return err
+	}
+	booked = slices.DeleteFunc(booked, func(entry CalendarEntry) bool {
+		return slices.Contains(released, entry.Id)
+	})
+	for _, entry := range entries {
+		booked = append(booked, entry.CalendarEntry)
+	}
+
+	maxPerDay := time.Duration(config.MaxHoursPerDay) * time.Hour
+	maxPerWeek := time.Duration(config.MaxHoursPerWeek) * time.Hour
+	for _, entry := range entries {
+		for day := entry.Start.Truncate(24 * time.Hour); day.Before(entry.End); day = day.AddDate(0, 0, 1) {
+			if maxPerDay > 0 && bookedWithin(booked, day, day.AddDate(0, 0, 1)) > maxPerDay {
+				return ErrDailyQuotaExceeded.Wrap(fmt.Errorf("at most %d hours on %s", config.MaxHoursPerDay, day.Format("2006-01-02")))
+			}
+
+			week := startOfWeek(day)
+			if maxPerWeek > 0 && bookedWithin(booked, week, week.AddDate(0, 0, 7)) > maxPerWeek {
+				return ErrWeeklyQuotaExceeded.Wrap(fmt.Errorf("at most %d hours in the week of %s", config.MaxHoursPerWeek, week.Format("2006-01-02")))
+			}
+		}
+	}
+
+	return nil
+}
+
+// isQuotaExceeded checks whether the given error of checkBookingQuotas stems from an exceeded quota.
+func isQuotaExceeded(err error) bool {
+	return errors.Is(err, ErrDailyQuotaExceeded) || errors.Is(err, ErrWeeklyQuotaExceeded)
+}
+
+// quotaExceededMessage is the explanation of an exceeded quota on the HTML pages of the links sent via email.
+const quotaExceededMessage = "Damit würdest du die maximale Anzahl an Stunden pro Tag oder pro Woche überschreiten."
+
+// cancellationDeadline is the earliest start of an entry that participants may still cancel at the given time.
+func (h *ApiHandler) cancellationDeadline(now time.Time) time.Time {
+	return now.Add(h.config.CancellationCutoff)
+}
+
+// checkCancellation checks that a participant may still cancel an entry with the given start, i.e., that it is
+// neither in the past nor within the CancellationCutoff.
+func (h *ApiHandler) checkCancellation(start time.Time) error {
+	now := time.Now()
+	if !start.After(now) {
+		return ErrEntryInPast
+	}
+	if !start.After(h.cancellationDeadline(now)) {
+		return ErrCancellationTooLate.Wrap(fmt.Errorf("cancellations must be made %s before the start", h.config.CancellationCutoff))
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+// Provides the single mapping layer of errors to the problem responses, which are sent for all failures of the API
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/httplog/v2"
+)
+
+// problemCodeInternal is the Code of all errors that are not a DomainError, whose details must not be exposed
+const problemCodeInternal = "internal"
+
+// newProblem maps an error of the given request to its Problem. Only a DomainError is explained to the caller, while
+// any other error, e.g. of the database, is hidden behind a generic internal one.
+func newProblem(r *http.Request, err error) Problem {
+	var domainErr *DomainError
+	if !errors.As(err, &domainErr) {
+		return Problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(http.StatusInternalServerError),
+			Status:   http.StatusInternalServerError,
+			Detail:   "An unexpected error occurred",
+			Instance: r.URL.Path,
+			Code:     problemCodeInternal,
+		}
+	}
+
+	status := domainErr.status()
+	// The cause of a failing external service is as internal as any other error
+	detail := domainErr.Error()
+	if status >= http.StatusInternalServerError {
+		detail = domainErr.Detail
+	}
+
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     domainErr.Code,
+	}
+
+	// All violated rules are listed individually, so the caller can fix them at once
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		problem.Detail = domainErr.Detail
+		problem.Errors = validationErr.Fields
+	}
+
+	return problem
+}
+
+// writeProblem is a utility method to return a Problem as "application/problem+json". Unlike with writeJson, the
+// status is written first.
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	b, err := json.Marshal(problem)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	_, _ = w.Write(b)
+}
+
+// httpProblemWithLog is a utility method to automatically log an error before returning it to the caller as Problem.
+func httpProblemWithLog(r *http.Request, w http.ResponseWriter, err error) {
+	logger := httplog.LogEntry(r.Context())
+	logger.Error(err.Error())
+	writeProblem(w, newProblem(r, err))
+}
@@ -0,0 +1,232 @@
+// Provides QR codes of the links into the application, as well as a printable poster embedding them
+
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Sakrafux/pray-calendar/backend/qrcode"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxQrScale limits the pixels per module of PNG QR codes, which is plenty for printing on A4
+const maxQrScale = 40
+
+// createVolunteerLink creates the link to the volunteer registration on the home page of the UI.
+func createVolunteerLink() string {
+	return fmt.Sprintf("%s/#volunteer", os.Getenv("HOST_FE"))
+}
+
+// GetQrCode provides the QR code of the link given via the URL parameter "target", which is one of:
+//   - "calendar" for the public calendar page, where one signs up for timeslots
+//   - "volunteer" for the volunteer registration
+//   - "slot" for a single open timeslot, given via the query parameters "date" and "hour"
+//   - "checkin" for the personal check-in link of an entry, given via the query parameter "entryId" (admin only)
+//   - "chapel" for the check-in form in the chapel (admin only)
+//
+// The image is a PNG by default, scaled via the optional query parameter "scale", or an SVG with "format=svg".
+func (h *ApiHandler) GetQrCode(w http.ResponseWriter, r *http.Request) {
+	var link string
+	switch target := chi.URLParam(r, "target"); target {
+	case "calendar":
+		link = calendarPageLink()
+	case "volunteer":
+		link = createVolunteerLink()
+	case "slot":
+		start, err := time.Parse("2006-01-02 15", fmt.Sprintf("%s %s", r.URL.Query().Get("date"), r.URL.Query().Get("hour")))
+		if err != nil {
+			httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+			return
+		}
+
+		entries, err := h.db.GetAllEntriesForRange(start, start.Add(time.Hour))
+		if err != nil {
+			httpProblemWithLog(r, w, err)
+			return
+		}
+		types, err := loadEventTypes(h.db)
+		if err != nil {
+			httpProblemWithLog(r, w, err)
+			return
+		}
+		if len(uncoveredIntervals(start, start.Add(time.Hour), types.coveringEntries(entries))) == 0 {
+			httpProblemWithLog(r, w, ErrTimeslotCovered)
+			return
+		}
+
+		link = createSlotLink(start)
+	case "checkin":
+		// The personal check-in links as well as the chapel key must not be public
+		if !r.Context().Value("admin").(bool) {
+			httpProblemWithLog(r, w, ErrAdminRequired)
+			return
+		}
+
+		entryId, err := strconv.Atoi(r.URL.Query().Get("entryId"))
+		if err != nil {
+			httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+			return
+		}
+
+		entry, err := h.db.GetFullEntry(entryId)
+		if errors.Is(err, sql.ErrNoRows) {
+			httpProblemWithLog(r, w, ErrEntryNotFound)
+			return
+		} else if err != nil {
+			httpProblemWithLog(r, w, err)
+			return
+		}
+
+		link, err = createCheckInLink(entry.Id, entry.End)
+		if err != nil {
+			httpProblemWithLog(r, w, err)
+			return
+		}
+	case "chapel":
+		if !r.Context().Value("admin").(bool) {
+			httpProblemWithLog(r, w, ErrAdminRequired)
+			return
+		}
+		if h.config.ChapelCheckInKey == "" {
+			httpProblemWithLog(r, w, ErrCheckInNotConfigured)
+			return
+		}
+
+		link = createChapelCheckInLink(h.config.ChapelCheckInKey)
+	default:
+		httpProblemWithLog(r, w, ErrUnknownQrTarget.Wrap(errors.New(target)))
+		return
+	}
+
+	code, err := qrcode.Encode(link, qrcode.LevelM)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "svg" {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		_, _ = w.Write([]byte(code.SVG()))
+		return
+	}
+
+	scale := 10
+	if scaleStr := r.URL.Query().Get("scale"); scaleStr != "" {
+		scale, err = strconv.Atoi(scaleStr)
+		if err != nil {
+			httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+			return
+		}
+		if scale < 1 || scale > maxQrScale {
+			httpProblemWithLog(r, w, ErrInvalidScale.Wrap(fmt.Errorf("must be between 1 and %d", maxQrScale)))
+			return
+		}
+	}
+
+	image, err := code.PNG(scale)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(image)
+}
+
+// posterQrCode is a single QR code on the poster with its caption.
+type posterQrCode struct {
+	Title   string
+	Caption string
+	Link    string
+	Svg     template.HTML
+}
+
+// poster is a printable A4 page, whose QR codes are embedded as SVGs, so they stay sharp at any print size.
+var poster = template.Must(template.New("poster").Parse(`<!DOCTYPE html>
+<html lang="de">
+<head>
+	<meta charset="utf-8">
+	<title>24/7 Anbetung St. Pölten</title>
+	<style>
+		@page { size: A4; margin: 15mm; }
+		body { font-family: Arial, sans-serif; color: #2c3e50; margin: 0; }
+		.page { width: 180mm; min-height: 260mm; margin: 0 auto; text-align: center; }
+		h1 { font-size: 32pt; margin: 10mm 0 4mm; border-bottom: 2px solid #f1c40f; padding-bottom: 4mm; }
+		.subtitle { font-size: 16pt; margin-bottom: 12mm; }
+		.codes { display: flex; justify-content: space-around; flex-wrap: wrap; }
+		.code { width: {{.Width}}; margin-bottom: 10mm; }
+		.code h2 { font-size: 18pt; margin: 0 0 3mm; }
+		.code p { font-size: 11pt; margin: 3mm 0 0; }
+		.code .link { font-size: 9pt; color: #7f8c8d; word-break: break-all; }
+	</style>
+</head>
+<body>
+	<div class="page">
+		<h1>24/7 Anbetung St. Pölten</h1>
+		<div class="subtitle">{{.Subtitle}}</div>
+		<div class="codes">
+			{{range .Codes}}
+			<div class="code">
+				<h2>{{.Title}}</h2>
+				{{.Svg}}
+				<p>{{.Caption}}</p>
+				<p class="link">{{.Link}}</p>
+			</div>
+			{{end}}
+		</div>
+	</div>
+</body>
+</html>`))
+
+// GetPoster provides a printable A4 poster with the QR codes for signing up in the calendar and registering as a
+// volunteer. With the query parameter "chapel=true", the poster for the chapel with the check-in QR code is
+// provided instead (admin only).
+func (h *ApiHandler) GetPoster(w http.ResponseWriter, r *http.Request) {
+	subtitle := "Schenke Gott eine Stunde deiner Zeit und trage dich in den Kalender ein!"
+	links := []posterQrCode{
+		{Title: "Kalender", Caption: "Trage dich für einen Timeslot ein", Link: calendarPageLink()},
+		{Title: "Benachrichtigungen", Caption: "Erfahre, wenn kurzfristig ein Timeslot frei wird", Link: createVolunteerLink()},
+	}
+
+	if r.URL.Query().Get("chapel") == "true" {
+		if !r.Context().Value("admin").(bool) {
+			httpProblemWithLog(r, w, ErrAdminRequired)
+			return
+		}
+		if h.config.ChapelCheckInKey == "" {
+			httpProblemWithLog(r, w, ErrCheckInNotConfigured)
+			return
+		}
+
+		subtitle = "Schön, dass du da bist! Bitte checke zu Beginn deines Timeslots ein."
+		links = []posterQrCode{
+			{Title: "Check-in", Caption: "Mit der E-Mail-Adresse deines Eintrags", Link: createChapelCheckInLink(h.config.ChapelCheckInKey)},
+		}
+	}
+
+	for i := range links {
+		code, err := qrcode.Encode(links[i].Link, qrcode.LevelM)
+		if err != nil {
+			httpProblemWithLog(r, w, err)
+			return
+		}
+		links[i].Svg = template.HTML(code.SVG())
+	}
+
+	// A single QR code is printed large, while several are placed side by side
+	width := "120mm"
+	if len(links) > 1 {
+		width = "80mm"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = poster.Execute(w, map[string]any{"Subtitle": subtitle, "Codes": links, "Width": template.CSS(width)})
+}
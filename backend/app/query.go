@@ -0,0 +1,128 @@
+// Provides the range queries of the entries, i.e., the parsing of the date range and the filters, as well as the
+// aggregation per day for the overview screens
+
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxQueryDays limits the date range of a query of the entries, as e.g. a year overview requires at most a year
+const maxQueryDays = 366
+
+// DaySummary aggregates the entries of a single day for the overview screens. FreeMinutes is the time that is neither
+// covered by an entry nor blocked by an admin event. A filter of the entries only restricts which Entries are counted,
+// while the minutes always refer to all entries, so FreeMinutes remains the time that can actually be booked.
+type DaySummary struct {
+	Date           string
+	Entries        int
+	CoveredMinutes int
+	FreeMinutes    int
+}
+
+// entryFilter restricts the queried entries to an EventType and a Series, where each restriction is optional.
+type entryFilter struct {
+	eventTypeId *int
+	// participantsOnly restricts the entries to those of participants, i.e., those without an EventType
+	participantsOnly bool
+	seriesId         *int
+}
+
+// matches checks whether an entry fulfills all restrictions of the entryFilter.
+func (f entryFilter) matches(entry CalendarEntry) bool {
+	if f.participantsOnly && entry.EventTypeId != nil {
+		return false
+	}
+	if f.eventTypeId != nil && (entry.EventTypeId == nil || *entry.EventTypeId != *f.eventTypeId) {
+		return false
+	}
+	if f.seriesId != nil && (entry.SeriesId == nil || *entry.SeriesId != *f.seriesId) {
+		return false
+	}
+
+	return true
+}
+
+// parseEntryRange reads the queried interval [start, end) of days from the query parameters "from" and "to" (both
+// inclusive), or for a week from the query parameter "start".
+func parseEntryRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+	if query.Get("from") == "" && query.Get("to") == "" {
+		start, err := time.Parse("2006-01-02", query.Get("start"))
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrMalformedRequest.Wrap(err)
+		}
+		return start, start.AddDate(0, 0, 7), nil
+	}
+
+	from, err := time.Parse("2006-01-02", query.Get("from"))
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrMalformedRequest.Wrap(err)
+	}
+	to, err := time.Parse("2006-01-02", query.Get("to"))
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrMalformedRequest.Wrap(err)
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, ErrFromAfterTo
+	}
+	if to.Sub(from) > maxQueryDays*24*time.Hour {
+		return time.Time{}, time.Time{}, ErrRangeTooLong
+	}
+
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// parseEntryFilter reads the entryFilter from the query parameters "eventType", i.e., the id of an EventType or "none"
+// for the entries of participants, and "series", i.e., the id of a Series.
+func parseEntryFilter(r *http.Request) (entryFilter, error) {
+	var filter entryFilter
+
+	if eventType := r.URL.Query().Get("eventType"); eventType == "none" {
+		filter.participantsOnly = true
+	} else if eventType != "" {
+		id, err := strconv.Atoi(eventType)
+		if err != nil {
+			return filter, ErrMalformedRequest.Wrap(err)
+		}
+		filter.eventTypeId = &id
+	}
+
+	if series := r.URL.Query().Get("series"); series != "" {
+		id, err := strconv.Atoi(series)
+		if err != nil {
+			return filter, ErrMalformedRequest.Wrap(err)
+		}
+		filter.seriesId = &id
+	}
+
+	return filter, nil
+}
+
+// summarizeDays computes the DaySummary of each day within [start, end) from the given entries, which must be sorted
+// by their start, while only the entries matching the given filter are counted. An entry is counted on the day it
+// starts, while the minutes are counted on each day it touches.
+func summarizeDays(entries []CalendarEntry, filter entryFilter, types eventTypeIndex, start, end time.Time) []DaySummary {
+	coverage := buildCoverageReport(entries, types, start, end.AddDate(0, 0, -1), time.Hour, 0, 0)
+
+	summaries := make([]DaySummary, len(coverage.Days))
+	for i, day := range coverage.Days {
+		summaries[i] = DaySummary{
+			Date:           day.Date,
+			CoveredMinutes: day.Total.CoveredMinutes,
+			FreeMinutes:    day.Total.RequiredMinutes - day.Total.CoveredMinutes,
+		}
+	}
+	for _, entry := range entries {
+		if !filter.matches(entry) {
+			continue
+		}
+		if i := int(entry.Start.Sub(start) / (24 * time.Hour)); !entry.Start.Before(start) && i < len(summaries) {
+			summaries[i].Entries++
+		}
+	}
+
+	return summaries
+}
@@ -0,0 +1,82 @@
+// Provides the reminder emails, which are sent ahead of a booked timeslot
+
+package app
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+)
+
+// ReminderScheduler sends reminder emails for the entries whose owners opted in, at each of the configured offsets
+// before the start of an entry. As the sent reminders are persisted in the database, a restart neither duplicates
+// them nor drops the ones that became due in the meantime. It is supposed to be run periodically as a job of the
+// scheduler.
+type ReminderScheduler struct {
+	db     *DBHandler
+	config *Config
+}
+
+// NewReminderScheduler is the constructor for ReminderScheduler.
+func NewReminderScheduler(db *DBHandler, config *Config) *ReminderScheduler {
+	return &ReminderScheduler{db: db, config: config}
+}
+
+// Run sends the due reminders and conforms to scheduler.JobFunc.
+func (s *ReminderScheduler) Run(_ context.Context, _ string) error {
+	return s.sendDueReminders(time.Now())
+}
+
+// sendDueReminders sends a reminder for every entry that reached at least one of its reminder offsets, which was not
+// yet sent. If several offsets became due at once, e.g. because the entry was booked on short notice, only a single
+// reminder is sent for all of them.
+func (s *ReminderScheduler) sendDueReminders(now time.Time) error {
+	if len(s.config.ReminderOffsets) == 0 {
+		return nil
+	}
+
+	entries, err := s.db.GetReminderCandidates(now, slices.Max(s.config.ReminderOffsets))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		// Claim the due reminders before sending, so they can't be sent twice
+		claimed := make([]time.Duration, 0)
+		for _, offset := range s.config.ReminderOffsets {
+			if entry.Start.Add(-offset).After(now) {
+				continue
+			}
+
+			ok, err := s.db.MarkReminderSent(entry.Id, offset, now)
+			if err != nil {
+				log.Println("[reminders] Failed to mark reminder as sent:", err)
+				continue
+			}
+			if ok {
+				claimed = append(claimed, offset)
+			}
+		}
+		if len(claimed) == 0 {
+			continue
+		}
+
+		checkInLink, err := createCheckInLink(entry.Id, entry.End)
+		if err != nil {
+			log.Printf("[reminders] Failed to create check-in link for entry %d: %s", entry.Id, err)
+		}
+
+		if err := sendReminderEmail(entry.Email, checkInLink, entry.Start, entry.End); err != nil {
+			log.Printf("[reminders] Failed to send reminder for entry %d: %s", entry.Id, err)
+			// Release the claims again, so the reminder is retried on the next run
+			for _, offset := range claimed {
+				if err := s.db.UnmarkReminderSent(entry.Id, offset); err != nil {
+					log.Println("[reminders] Failed to unmark reminder:", err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
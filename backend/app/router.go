@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/Sakrafux/pray-calendar/backend/scheduler"
 	"github.com/Sakrafux/pray-calendar/backend/security"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -17,9 +18,9 @@ import (
 	"github.com/go-chi/httplog/v2"
 )
 
-// CreateRouter creates a go-chi router, distributing application state, i.e., DBHandler and security.AdminData, into
-// the respective api handlers.
-func CreateRouter(db *DBHandler, admin *security.AdminData) http.Handler {
+// CreateRouter creates a go-chi router, distributing application state, i.e., DBHandler, security.AdminData, Config,
+// scheduler.Scheduler and EventBroker, into the respective api handlers.
+func CreateRouter(db *DBHandler, admin *security.AdminData, config *Config, jobs *scheduler.Scheduler, events *EventBroker) http.Handler {
 	// httplog is designed for easy integration with a go-chi router, is based on slog and thus allows for structured logging
 	logger := httplog.NewLogger("prayer-calendar", httplog.Options{
 		LogLevel: slog.LevelInfo,
@@ -34,7 +35,7 @@ func CreateRouter(db *DBHandler, admin *security.AdminData) http.Handler {
 	router.Use(middleware.RealIP)
 	router.Use(httplog.RequestLogger(logger))
 	router.Use(middleware.Recoverer)
-	router.Use(middleware.Timeout(60 * time.Second))
+	router.Use(TimeoutUnlessStream(60 * time.Second))
 	router.Use(middleware.Heartbeat("/health"))
 	// this is a go-chi compatible CORS middleware
 	router.Use(cors.Handler(cors.Options{
@@ -47,7 +48,7 @@ func CreateRouter(db *DBHandler, admin *security.AdminData) http.Handler {
 	// this is custom middleware for injecting authentication information, i.e., an admin flag
 	router.Use(Authentication)
 
-	apiHandler := NewApiHandler(db, admin)
+	apiHandler := NewApiHandler(db, admin, config, jobs, events)
 
 	// all the routes are behind /api to ensure no overlap with the SPA frontend
 	router.Route("/api", func(router chi.Router) {
@@ -56,13 +57,78 @@ func CreateRouter(db *DBHandler, admin *security.AdminData) http.Handler {
 			r.Post("/entries", apiHandler.PostEntry)
 			r.Delete("/entries/{id}", apiHandler.DeleteEntry)
 
+			// the owner of an entry may seek a replacement instead of deleting it, which others can take over
+			r.Get("/entries/{id}/transfer", apiHandler.GetEntryTransfer)
+			r.Post("/entries/{id}/transfer", apiHandler.PostEntryTransfer)
+			r.Delete("/entries/{id}/transfer", apiHandler.DeleteEntryTransfer)
+			r.Get("/transfers", apiHandler.GetTransfers)
+			r.Post("/transfers/{id}/proposals", apiHandler.PostTransferProposal)
+			// these endpoints are accessed via the links in the transfer proposal emails
+			r.Get("/transfers/accept", apiHandler.GetTransferAccept)
+			r.Post("/transfers/accept", apiHandler.PostTransferAccept)
+			r.Get("/transfers/decline", apiHandler.GetTransferDecline)
+			r.Post("/transfers/decline", apiHandler.PostTransferDecline)
+
+			// the slot grid defines where entries may start and end
+			r.Get("/slots", apiHandler.GetSlots)
+
+			// the free/busy information reveals no personal information, e.g. for partner parishes
+			r.Get("/freebusy", apiHandler.GetFreeBusy)
+
+			// the event types define how the admin events are presented
+			r.Get("/event-types", apiHandler.GetEventTypes)
+
 			r.Post("/series", apiHandler.PostSeries)
 			r.Delete("/series/{id}", apiHandler.DeleteSeries)
+
+			r.Post("/groups", apiHandler.PostGroup)
+			r.Get("/groups/{id}", apiHandler.GetGroup)
+			r.Delete("/groups/{id}", apiHandler.DeleteGroup)
+			// these endpoints are accessed via the invite and overview links of the group contact
+			r.Get("/groups/invite", apiHandler.GetGroupInvite)
+			r.Post("/groups/invite", apiHandler.PostGroupInvite)
+			r.Get("/groups/overview", apiHandler.GetGroupOverview)
+
+			// this endpoint streams the changes to the entries, so concurrent users see each other's bookings live
+			r.Get("/events", apiHandler.GetCalendarEvents)
+
+			// these endpoints are accessed via the claim links in the notification emails
+			r.Get("/claim", apiHandler.GetSlotClaim)
+			r.Post("/claim", apiHandler.PostSlotClaim)
+
+			r.Post("/waitlist", apiHandler.PostWaitlistEntry)
+			r.Delete("/waitlist/{id}", apiHandler.DeleteWaitlistEntry)
+			// these endpoints are accessed via the offer links in the waitlist emails
+			r.Get("/waitlist/claim", apiHandler.GetWaitlistClaim)
+			r.Post("/waitlist/claim", apiHandler.PostWaitlistClaim)
 		})
 
 		router.Route("/volunteer", func(r chi.Router) {
 			r.Post("/", apiHandler.PostVolunteerRegistration)
 			r.Get("/confirmation", apiHandler.GetVolunteerConfirmation)
+			r.Get("/digest", apiHandler.GetDigestPreference)
+			r.Post("/digest", apiHandler.PostDigestPreference)
+		})
+
+		// the QR codes are public, except for the personal and chapel check-in links, which are checked individually
+		router.Route("/qr", func(r chi.Router) {
+			r.Get("/poster", apiHandler.GetPoster)
+			r.Get("/{target}", apiHandler.GetQrCode)
+		})
+
+		// the kiosk screen authenticates via its own read-only token instead of the admin login
+		router.Route("/kiosk", func(r chi.Router) {
+			r.Get("/", apiHandler.GetKioskState)
+			r.Get("/events", apiHandler.GetKioskEvents)
+			r.Get("/display", apiHandler.GetKioskDisplay)
+		})
+
+		// these endpoints are accessed via the links in the reminder emails and the QR code in the chapel
+		router.Route("/checkin", func(r chi.Router) {
+			r.Get("/", apiHandler.GetCheckIn)
+			r.Post("/", apiHandler.PostCheckIn)
+			r.Get("/chapel", apiHandler.GetChapelCheckIn)
+			r.Post("/chapel", apiHandler.PostChapelCheckIn)
 		})
 
 		router.Route("/admin", func(r chi.Router) {
@@ -79,6 +145,20 @@ func CreateRouter(db *DBHandler, admin *security.AdminData) http.Handler {
 
 				r.Get("/volunteer", apiHandler.DownloadVolunteerEmails)
 				r.Delete("/volunteer", apiHandler.DeleteVolunteer)
+
+				r.Get("/coverage", apiHandler.GetCoverage)
+				r.Get("/attendance", apiHandler.GetAttendance)
+				r.Get("/cancellations", apiHandler.GetLateCancellations)
+
+				r.Get("/jobs", apiHandler.GetJobs)
+
+				r.Post("/stream-token", apiHandler.PostStreamToken)
+
+				r.Post("/kiosk", apiHandler.PostKioskToken)
+
+				r.Post("/event-types", apiHandler.PostEventType)
+				r.Put("/event-types/{id}", apiHandler.PutEventType)
+				r.Delete("/event-types/{id}", apiHandler.DeleteEventType)
 			})
 		})
 	})
@@ -104,7 +184,7 @@ func Authentication(next http.Handler) http.Handler {
 		}
 
 		if len(auth) <= 7 || auth[:7] != "Bearer " {
-			http.Error(w, "Unauthorized: Missing or invalid token format", http.StatusUnauthorized)
+			httpProblemWithLog(r, w, ErrMissingToken)
 			return
 		}
 
@@ -112,7 +192,7 @@ func Authentication(next http.Handler) http.Handler {
 
 		_, err := security.ValidateAccessToken(tokenString)
 		if err != nil {
-			http.Error(w, "Unauthorized: Invalid access token", http.StatusUnauthorized)
+			httpProblemWithLog(r, w, ErrInvalidToken.Wrap(err))
 			return
 		}
 
@@ -128,7 +208,7 @@ func Authentication(next http.Handler) http.Handler {
 func AdminOnly(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !r.Context().Value("admin").(bool) {
-			http.Error(w, "Unauthorized: Admin permissions required", http.StatusUnauthorized)
+			httpProblemWithLog(r, w, ErrAdminRequired)
 			return
 		}
 
@@ -168,3 +248,19 @@ func (h FrontendSpaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	h.fileServer.ServeHTTP(w, r)
 }
+
+// TimeoutUnlessStream applies the timeout middleware to all requests except for Server-Sent Events, which are
+// supposed to stay open indefinitely.
+func TimeoutUnlessStream(timeout time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withTimeout := middleware.Timeout(timeout)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Accept") == "text/event-stream" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			withTimeout.ServeHTTP(w, r)
+		})
+	}
+}
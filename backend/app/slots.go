@@ -0,0 +1,98 @@
+// Provides the slot grid of the calendar, which is defined per time of day by the SlotTemplate of the Config, and
+// which all entries have to be aligned to
+
+package app
+
+import (
+	"net/http"
+	"time"
+)
+
+// timeOfDay is a utility method to get the offset of a time from its midnight. As the times are wall times stored as
+// UTC, this is the time of day in the timezone of the calendar.
+func timeOfDay(t time.Time) time.Duration {
+	return t.Sub(t.Truncate(24 * time.Hour))
+}
+
+// slotTemplateAt finds the SlotTemplate the given time lies within.
+func (c *Config) slotTemplateAt(t time.Time) SlotTemplate {
+	offset := timeOfDay(t)
+	for _, template := range c.SlotTemplates {
+		if offset >= template.From && offset < template.To {
+			return template
+		}
+	}
+
+	// As the templates cover the whole day, this is unreachable
+	return c.SlotTemplates[len(c.SlotTemplates)-1]
+}
+
+// isOnSlotGrid checks whether the given time is a boundary between two slots. This includes the boundaries of the
+// SlotTemplate themselves, as e.g. an entry may end where the next template starts.
+func (c *Config) isOnSlotGrid(t time.Time) bool {
+	offset := timeOfDay(t)
+	for _, template := range c.SlotTemplates {
+		if offset >= template.From && offset <= template.To && (offset-template.From)%template.Length == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// slotGrid provides all slots of the day of the given time.
+func (c *Config) slotGrid(day time.Time) []Interval {
+	midnight := day.Truncate(24 * time.Hour)
+
+	slots := make([]Interval, 0)
+	for _, template := range c.SlotTemplates {
+		for offset := template.From; offset < template.To; offset += template.Length {
+			slots = append(slots, Interval{Start: midnight.Add(offset), End: midnight.Add(offset + template.Length)})
+		}
+	}
+
+	return slots
+}
+
+// slotsBetween provides all slots within the interval [start, end), e.g. to split a block into single entries.
+func (c *Config) slotsBetween(start, end time.Time) []Interval {
+	slots := make([]Interval, 0)
+	for day := start.Truncate(24 * time.Hour); day.Before(end); day = day.AddDate(0, 0, 1) {
+		for _, slot := range c.slotGrid(day) {
+			if !slot.Start.Before(start) && !slot.End.After(end) {
+				slots = append(slots, slot)
+			}
+		}
+	}
+
+	return slots
+}
+
+// GetSlots provides the slot grid for the day given via query parameter "date", with each Slot listing the
+// CalendarEntry that occupy it.
+func (h *ApiHandler) GetSlots(w http.ResponseWriter, r *http.Request) {
+	day, err := time.Parse("2006-01-02", r.URL.Query().Get("date"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	entries, err := h.db.GetAllEntriesForRange(day, day.AddDate(0, 0, 1))
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	slots := make([]Slot, 0)
+	for _, interval := range h.config.slotGrid(day) {
+		slot := Slot{Interval: interval, Entries: make([]CalendarEntry, 0)}
+		for _, entry := range entries {
+			if entry.Start.Before(interval.End) && interval.Start.Before(entry.End) {
+				slot.Entries = append(slot.Entries, entry)
+			}
+		}
+		slots = append(slots, slot)
+	}
+
+	writeJson(w, slots)
+}
@@ -0,0 +1,50 @@
+// Provides utility functions for streaming Server-Sent Events, which keep screens and clients up to date without polling
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// sseRetryMillis is how long a client waits before reconnecting after the stream was interrupted
+const sseRetryMillis = 3000
+
+// startSseStream prepares the response for streaming Server-Sent Events. The returned controller is required for
+// flushing, as the response is otherwise buffered.
+func startSseStream(w http.ResponseWriter) (*http.ResponseController, error) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// reverse proxies like nginx would otherwise buffer the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetryMillis); err != nil {
+		return nil, err
+	}
+	return rc, rc.Flush()
+}
+
+// writeSseEvent writes a single event with the given data encoded as JSON and flushes it to the client.
+func writeSseEvent(w http.ResponseWriter, rc *http.ResponseController, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+// writeSseKeepAlive writes a comment, which clients ignore, so idle connections are not closed by proxies.
+func writeSseKeepAlive(w http.ResponseWriter, rc *http.ResponseController) error {
+	if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
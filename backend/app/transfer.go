@@ -0,0 +1,446 @@
+// Provides the marketplace for entries, whose owners seek a replacement, so they don't have to free their timeslot
+
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sakrafux/pray-calendar/backend/security"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httplog/v2"
+)
+
+// GetEntryTransfer provides the open Transfer of the entry given via the URL parameter "id", if it seeks a replacement.
+func (h *ApiHandler) GetEntryTransfer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	transfer, err := h.db.GetOpenTransferForEntry(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpProblemWithLog(r, w, ErrNoOpenTransfer)
+		return
+	} else if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	writeJson(w, transfer)
+}
+
+// PostEntryTransfer marks the entry given via the URL parameter "id" as seeking a replacement, given that the user
+// provided the correct email address via query parameter, analogous to DeleteEntry. In contrast to deleting it, the
+// entry stays owned and covered until another participant takes it over, so no gap arises.
+func (h *ApiHandler) PostEntryTransfer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	// A wrong email address is treated like a missing entry, so the owner of an entry can't be guessed
+	entry, err := h.db.GetFullEntry(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && entry.Email != r.URL.Query().Get("email")) {
+		httpProblemWithLog(r, w, ErrEntryNotFound)
+		return
+	} else if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	if entry.EventTypeId != nil {
+		httpProblemWithLog(r, w, ErrAdminEventTransfer)
+		return
+	}
+
+	now := time.Now()
+	if !entry.Start.After(now) {
+		httpProblemWithLog(r, w, ErrStartInPast)
+		return
+	}
+
+	transfer, err := h.db.InsertTransfer(entry.Id, entry.Email, now)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	writeJsonStatus(w, http.StatusCreated, transfer)
+}
+
+// DeleteEntryTransfer withdraws the search for a replacement of the entry given via the URL parameter "id", given
+// that the user provided the correct email address via query parameter. All pending proposals are declined.
+func (h *ApiHandler) DeleteEntryTransfer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	if err := h.db.CloseTransfer(id, r.URL.Query().Get("email"), time.Now()); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTransfers provides all open Transfer of the week starting at a date given via query parameter "start", i.e.,
+// the entries seeking a replacement, which other participants may take over.
+func (h *ApiHandler) GetTransfers(w http.ResponseWriter, r *http.Request) {
+	start, err := time.Parse("2006-01-02", r.URL.Query().Get("start"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	transfers, err := h.db.GetOpenTransfersForRange(start, start.AddDate(0, 0, 7))
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	writeJson(w, transfers)
+}
+
+// PostTransferProposal proposes to take over the entry of the Transfer given via the URL parameter "id", with the
+// participant information provided via the request body. If it contains a SwapEntryId, the participant proposes to
+// swap it with one of their own entries instead, which they have to own with the same email address. The owner is
+// asked via email to confirm or decline the proposal, and only their confirmation changes the ownership.
+func (h *ApiHandler) PostTransferProposal(w http.ResponseWriter, r *http.Request) {
+	logger := httplog.LogEntry(r.Context())
+
+	transferId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	var proposal TransferProposal
+	if err := json.NewDecoder(r.Body).Decode(&proposal); err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	if strings.TrimSpace(proposal.FirstName) == "" || strings.TrimSpace(proposal.LastName) == "" {
+		httpProblemWithLog(r, w, ErrNameMissing)
+		return
+	}
+
+	// The owner's decision is sent via email
+	if !isValidEmail(proposal.Email) {
+		httpProblemWithLog(r, w, ErrMalformedEmail)
+		return
+	}
+
+	transfer, err := h.db.GetOpenTransfer(transferId)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpProblemWithLog(r, w, ErrTransferNotFound)
+		return
+	} else if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	now := time.Now()
+	if !transfer.Entry.Start.After(now) {
+		httpProblemWithLog(r, w, ErrStartInPast)
+		return
+	}
+
+	entry, err := h.db.GetFullEntry(transfer.EntryId)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+	if entry.Email == proposal.Email {
+		httpProblemWithLog(r, w, ErrEntryAlreadyOwned)
+		return
+	}
+
+	var swap *CalendarEntryFull
+	if proposal.SwapEntryId != nil {
+		// Same as for the owner, a wrong email address is treated like a missing entry
+		swap, err = h.db.GetFullEntry(*proposal.SwapEntryId)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && swap.Email != proposal.Email) {
+			httpProblemWithLog(r, w, ErrSwapEntryNotFound)
+			return
+		} else if err != nil {
+			httpProblemWithLog(r, w, err)
+			return
+		}
+
+		if swap.EventTypeId != nil || !swap.Start.After(now) {
+			httpProblemWithLog(r, w, ErrSwapEntryInPast)
+			return
+		}
+	}
+
+	// The proposer is bound to the quotas right away, which are checked again once the owner accepts
+	taken := CalendarEntryFull{CalendarEntry: CalendarEntry{Start: entry.Start, End: entry.End}, Email: proposal.Email}
+	released := make([]int, 0, 1)
+	if swap != nil {
+		released = append(released, swap.Id)
+	}
+	if err := checkBookingQuotas(h.db, h.config, []CalendarEntryFull{taken}, released...); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	proposal.TransferId = transfer.Id
+	proposal.CreatedAt = now
+	insertedProposal, err := h.db.InsertTransferProposal(proposal)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	// The owner may decide until the entry starts, after which a transfer is pointless
+	acceptLink, declineLink, err := createTransferLinks(insertedProposal.Id, entry.Start)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	proposer := fmt.Sprintf("%s %s", proposal.FirstName, proposal.LastName)
+	if err := sendTransferProposalEmail(entry.Email, acceptLink, declineLink, proposer, entry.Start, entry.End, swap); err != nil {
+		logger.Warn("Failed to send transfer proposal for email " + entry.Email + " with error: " + err.Error())
+	}
+
+	writeJsonStatus(w, http.StatusCreated, insertedProposal)
+}
+
+// createTransferLinks creates the links for the owner of an entry to confirm or decline the given TransferProposal.
+func createTransferLinks(proposalId int, expiresAt time.Time) (string, string, error) {
+	token, err := security.CreateTransferToken(proposalId, expiresAt)
+	if err != nil {
+		return "", "", err
+	}
+
+	base := fmt.Sprintf("%s/api/calendar/transfers", os.Getenv("HOST_BE"))
+	return fmt.Sprintf("%s/accept?token=%s", base, url.QueryEscape(token)),
+		fmt.Sprintf("%s/decline?token=%s", base, url.QueryEscape(token)), nil
+}
+
+// writeTransferConfirmPage asks the owner of an entry to confirm the decision about the TransferProposal of the given
+// token, which is only carried out by the posted form. Thereby, merely opening a link, e.g., by a mail scanner, changes
+// nothing.
+func (h *ApiHandler) writeTransferConfirmPage(w http.ResponseWriter, r *http.Request, decision string) {
+	logger := httplog.LogEntry(r.Context())
+
+	token := r.URL.Query().Get("token")
+	proposalId, err := security.ValidateTransferToken(token)
+	if err != nil {
+		logger.Warn(err.Error())
+		writeHtmlPage(w, http.StatusGone, "Link abgelaufen", "Dieser Link ist ungültig oder bereits abgelaufen.")
+		return
+	}
+
+	proposal, err := h.db.GetTransferProposal(proposalId)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeHtmlPage(w, http.StatusGone, "Link abgelaufen", "Dieser Vorschlag existiert nicht mehr.")
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	if proposal.Status != "pending" {
+		writeHtmlPage(w, http.StatusConflict, "Bereits entschieden", "Über diesen Vorschlag wurde bereits entschieden.")
+		return
+	}
+
+	transfer, err := h.db.GetOpenTransfer(proposal.TransferId)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeHtmlPage(w, http.StatusConflict, "Bereits entschieden", "Über diesen Vorschlag wurde bereits entschieden.")
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	proposer := fmt.Sprintf("%s %s", proposal.FirstName, proposal.LastName)
+	dateStr := transfer.Entry.Start.Format("02.01.2006")
+	startTimeStr := transfer.Entry.Start.Format("15:04")
+	endTimeStr := transfer.Entry.End.Format("15:04")
+	action := os.Getenv("PATH_PREFIX") + "/api/calendar/transfers/" + decision
+	fields := map[string]string{"token": token}
+
+	if decision == "accept" {
+		writeConfirmPage(w, "Timeslot übergeben",
+			fmt.Sprintf("Möchtest du deinen Timeslot am %s für %s bis %s an %s übergeben?", dateStr, startTimeStr, endTimeStr, proposer),
+			action, fields, "Übergeben")
+		return
+	}
+	writeConfirmPage(w, "Vorschlag ablehnen",
+		fmt.Sprintf("Möchtest du den Vorschlag von %s für deinen Timeslot am %s für %s bis %s ablehnen?", proposer, dateStr, startTimeStr, endTimeStr),
+		action, fields, "Ablehnen")
+}
+
+// GetTransferAccept asks the owner to confirm a TransferProposal via PostTransferAccept. This method is supposed to be
+// directly accessed via a link, thus it responds with simple HTML pages.
+func (h *ApiHandler) GetTransferAccept(w http.ResponseWriter, r *http.Request) {
+	h.writeTransferConfirmPage(w, r, "accept")
+}
+
+// GetTransferDecline asks the owner to confirm declining a TransferProposal via PostTransferDecline. This method is
+// supposed to be directly accessed via a link, thus it responds with simple HTML pages.
+func (h *ApiHandler) GetTransferDecline(w http.ResponseWriter, r *http.Request) {
+	h.writeTransferConfirmPage(w, r, "decline")
+}
+
+// PostTransferAccept confirms a TransferProposal on behalf of the owner, whereby the ownership of the entries changes
+// atomically, based on the form of GetTransferAccept.
+func (h *ApiHandler) PostTransferAccept(w http.ResponseWriter, r *http.Request) {
+	logger := httplog.LogEntry(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		writeHtmlPage(w, http.StatusBadRequest, "Fehler", "Die Eingabe konnte nicht verarbeitet werden.")
+		return
+	}
+
+	proposalId, err := security.ValidateTransferToken(r.PostForm.Get("token"))
+	if err != nil {
+		logger.Warn(err.Error())
+		writeHtmlPage(w, http.StatusGone, "Link abgelaufen", "Dieser Link ist ungültig oder bereits abgelaufen.")
+		return
+	}
+
+	// Taking over an entry is no way around the quotas of the participants, neither for the proposer nor, when
+	// swapping, for the owner
+	if err := h.checkTransferQuotas(proposalId); isQuotaExceeded(err) {
+		writeHtmlPage(w, http.StatusConflict, "Nicht möglich",
+			"Diese Übergabe ist nicht möglich, da damit die maximale Anzahl an Stunden pro Tag oder pro Woche überschritten würde.")
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	entries, err := h.db.CompleteTransfer(proposalId, time.Now())
+	if err != nil {
+		if errors.Is(err, ErrTransferNotAvailable) {
+			writeHtmlPage(w, http.StatusConflict, "Nicht mehr möglich",
+				"Diese Übergabe ist nicht mehr möglich, da sie bereits entschieden wurde oder sich die Einträge inzwischen geändert haben.")
+			return
+		}
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+	h.publishEntries(eventUpdated, entries...)
+
+	// Both sides receive the entry they now own
+	for _, entry := range entries {
+		if err := sendEntryConfirmationEmail(entry.Email, entry.Start, entry.End); err != nil {
+			logger.Warn("Failed to send email confirmation for email " + entry.Email + " with error: " + err.Error())
+		}
+	}
+
+	entry := entries[0]
+	writeHtmlPage(w, http.StatusOK, "Timeslot übergeben",
+		fmt.Sprintf("Vielen Dank! Der Timeslot am %s für %s bis %s wurde an %s übergeben.",
+			entry.Start.Format("02.01.2006"), entry.Start.Format("15:04"), entry.End.Format("15:04"), entry.FirstName))
+}
+
+// checkTransferQuotas checks the quotas of both sides of the given TransferProposal, as if it was completed. A proposal
+// that is no longer available passes, since CompleteTransfer rejects it anyway.
+func (h *ApiHandler) checkTransferQuotas(proposalId int) error {
+	proposal, err := h.db.GetTransferProposal(proposalId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	transfer, err := h.db.GetOpenTransfer(proposal.TransferId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	owned, err := h.db.GetFullEntry(transfer.EntryId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	taken := CalendarEntryFull{CalendarEntry: CalendarEntry{Start: owned.Start, End: owned.End}, Email: proposal.Email}
+	if proposal.SwapEntryId == nil {
+		return checkBookingQuotas(h.db, h.config, []CalendarEntryFull{taken})
+	}
+	if err := checkBookingQuotas(h.db, h.config, []CalendarEntryFull{taken}, *proposal.SwapEntryId); err != nil {
+		return err
+	}
+
+	swap, err := h.db.GetFullEntry(*proposal.SwapEntryId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	given := CalendarEntryFull{CalendarEntry: CalendarEntry{Start: swap.Start, End: swap.End}, Email: owned.Email}
+	return checkBookingQuotas(h.db, h.config, []CalendarEntryFull{given}, owned.Id)
+}
+
+// PostTransferDecline declines a TransferProposal on behalf of the owner, who keeps seeking a replacement, based on
+// the form of GetTransferDecline.
+func (h *ApiHandler) PostTransferDecline(w http.ResponseWriter, r *http.Request) {
+	logger := httplog.LogEntry(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		writeHtmlPage(w, http.StatusBadRequest, "Fehler", "Die Eingabe konnte nicht verarbeitet werden.")
+		return
+	}
+
+	proposalId, err := security.ValidateTransferToken(r.PostForm.Get("token"))
+	if err != nil {
+		logger.Warn(err.Error())
+		writeHtmlPage(w, http.StatusGone, "Link abgelaufen", "Dieser Link ist ungültig oder bereits abgelaufen.")
+		return
+	}
+
+	proposal, err := h.db.GetTransferProposal(proposalId)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeHtmlPage(w, http.StatusGone, "Link abgelaufen", "Dieser Vorschlag existiert nicht mehr.")
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	transfer, err := h.db.GetOpenTransfer(proposal.TransferId)
+	if err == nil {
+		err = h.db.DeclineTransferProposal(proposal.Id)
+	}
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, ErrProposalNotDeclined) {
+		writeHtmlPage(w, http.StatusConflict, "Bereits entschieden", "Über diesen Vorschlag wurde bereits entschieden.")
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	if err := sendTransferDeclinedEmail(proposal.Email, transfer.Entry.Start, transfer.Entry.End); err != nil {
+		logger.Warn("Failed to send transfer decline for email " + proposal.Email + " with error: " + err.Error())
+	}
+
+	writeHtmlPage(w, http.StatusOK, "Vorschlag abgelehnt",
+		"Der Vorschlag wurde abgelehnt. Dein Timeslot bleibt auf dich eingetragen und sucht weiterhin Ersatz.")
+}
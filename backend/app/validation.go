@@ -0,0 +1,144 @@
+// Provides the validation of the requests, which checks all "business rules" at once and reports every violated one
+// as FieldError instead of only the first
+
+package app
+
+import (
+	"strings"
+	"time"
+)
+
+// ValidationError collects all FieldError of a single request. It is reported as the Problem of ErrInvalidFields.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + ": " + field.Detail
+	}
+	return ErrInvalidFields.Detail + ": " + strings.Join(messages, "; ")
+}
+
+// Unwrap exposes ErrInvalidFields, so the ValidationError is mapped like any other DomainError.
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidFields
+}
+
+// validator accumulates the FieldError of a request, reusing the Code and Detail of the DomainError of each rule.
+type validator struct {
+	fields []FieldError
+}
+
+// check records the DomainError for the given field, unless the rule is fulfilled.
+func (v *validator) check(ok bool, field string, err *DomainError) {
+	if !ok {
+		v.fields = append(v.fields, FieldError{Field: field, Code: err.Code, Detail: err.Detail})
+	}
+}
+
+// err provides the ValidationError of all recorded FieldError, or nil if the request is valid.
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+// email checks a required email address, e.g. of a participant or volunteer.
+func (v *validator) email(field string, email string) {
+	v.check(email != "", field, ErrFieldRequired)
+	v.check(email == "" || isValidEmail(email), field, ErrMalformedEmail)
+}
+
+// entry checks a CalendarEntryFull, whose fields are reported below the given prefix. Admin events don't contain any
+// personal information, thus only the entries of participants require it.
+func (v *validator) entry(prefix string, entry CalendarEntryFull, config *Config, now time.Time, admin bool) {
+	if entry.EventTypeId == nil {
+		v.check(strings.TrimSpace(entry.FirstName) != "", prefix+"FirstName", ErrFieldRequired)
+		v.check(strings.TrimSpace(entry.LastName) != "", prefix+"LastName", ErrFieldRequired)
+		v.email(prefix+"Email", entry.Email)
+	}
+
+	if !v.timeslot(prefix, entry.Start, entry.End, config, now, admin) {
+		return
+	}
+
+	// The bounds of the duration depend on the part of the day the entry starts in
+	template := config.slotTemplateAt(entry.Start)
+	duration := entry.End.Sub(entry.Start)
+	v.check(duration >= template.MinDuration, prefix+"End", ErrDurationTooShort)
+	v.check(duration <= template.MaxDuration, prefix+"End", ErrDurationTooLong)
+}
+
+// timeslot checks the start and end of a booking against the slot grid and, for participants, the booking horizon.
+// It reports whether they are properly ordered, as only then the duration is meaningful.
+func (v *validator) timeslot(prefix string, start, end time.Time, config *Config, now time.Time, admin bool) bool {
+	v.check(!start.Before(now), prefix+"Start", ErrStartInPast)
+	// Participants may only book up to the booking horizon, while the admin may plan without limit
+	v.check(admin || !start.After(now.Add(config.BookingHorizon)), prefix+"Start", ErrBeyondHorizon)
+	v.check(config.isOnSlotGrid(start), prefix+"Start", ErrSlotMisaligned)
+	v.check(config.isOnSlotGrid(end), prefix+"End", ErrSlotMisaligned)
+
+	v.check(start.Before(end), prefix+"End", ErrStartAfterEnd)
+	return start.Before(end)
+}
+
+// validateEntry checks a CalendarEntryFull against the limits of the Config.
+func validateEntry(entry CalendarEntryFull, config *Config, admin bool) error {
+	v := validator{}
+	v.entry("", entry, config, time.Now(), admin)
+	return v.err()
+}
+
+// validateWaitlistEntry checks a WaitlistEntry against the same limits as the entry it eventually becomes. Even the
+// admin queues like any participant, as the entry is booked without any further checks.
+func validateWaitlistEntry(entry WaitlistEntry, config *Config) error {
+	v := validator{}
+	v.entry("", entry.toCalendarEntry(), config, time.Now(), false)
+	return v.err()
+}
+
+// validateSeriesRequest checks a SeriesRequest against the limits of the Config. Besides its first entry, the last
+// occurrence must be within the booking horizon as well.
+func validateSeriesRequest(seriesReq SeriesRequest, config *Config, admin bool) error {
+	now := time.Now()
+	v := validator{}
+	v.entry("Entry.", seriesReq.Entry, config, now, admin)
+
+	_, validInterval := seriesSteps[seriesReq.Series.Interval]
+	v.check(validInterval, "Series.Interval", ErrInvalidInterval)
+
+	repetitions := seriesReq.Series.Repetitions
+	validRepetitions := repetitions >= 1 && repetitions <= config.MaxSeriesRepetitions
+	v.check(validRepetitions, "Series.Repetitions", ErrInvalidRepetitions)
+
+	if validInterval && validRepetitions {
+		entries := repeatEntry(seriesReq.Entry, seriesReq.Series)
+		last := entries[len(entries)-1]
+		v.check(admin || !last.Start.After(now.Add(config.BookingHorizon)), "Series.Repetitions", ErrBeyondHorizon)
+	}
+
+	return v.err()
+}
+
+// validateGroup checks a Group, whose block is split along the slot grid, thus only its total duration is bounded.
+func validateGroup(group Group, config *Config, admin bool) error {
+	v := validator{}
+	v.check(strings.TrimSpace(group.Name) != "", "Name", ErrGroupNameMissing)
+	// The links are sent to the contact via email
+	v.email("Email", group.Email)
+	if v.timeslot("", group.Start, group.End, config, time.Now(), admin) {
+		v.check(group.End.Sub(group.Start) <= maxGroupDuration, "End", ErrDurationTooLong)
+	}
+
+	return v.err()
+}
+
+// validateVolunteerEmail checks the email address of a volunteer, which is given as query parameter.
+func validateVolunteerEmail(email string) error {
+	v := validator{}
+	v.email("email", email)
+	return v.err()
+}
@@ -0,0 +1,407 @@
+// Provides the waitlist for occupied timeslots, whose first user gets the timeslot once it is freed
+
+package app
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Sakrafux/pray-calendar/backend/security"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httplog/v2"
+)
+
+// The waitlist policies, i.e., what happens to the first user on the waitlist of a freed timeslot
+const (
+	waitlistPolicyOffer  = "offer"
+	waitlistPolicyAssign = "assign"
+)
+
+// PostWaitlistEntry queues the user for an occupied timeslot, which is provided via the request body. It adheres to
+// the same rules as PostEntry, except that the timeslot must not be free, as it could be booked directly otherwise.
+func (h *ApiHandler) PostWaitlistEntry(w http.ResponseWriter, r *http.Request) {
+	var entry WaitlistEntry
+	err := json.NewDecoder(r.Body).Decode(&entry)
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	// The user has to be reachable, as the offer is sent via email
+	if err := validateWaitlistEntry(entry, h.config); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	entries, err := h.db.GetAllEntriesForRange(entry.Start, entry.End)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+	types, err := loadEventTypes(h.db)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+	if isFree(entry.Start, entry.End, types.blockingEntries(entries)) {
+		httpProblemWithLog(r, w, ErrTimeslotFree)
+		return
+	}
+
+	entry.CreatedAt = time.Now()
+	entry.OfferExpiresAt = nil
+	insertedEntry, err := h.db.InsertWaitlistEntry(entry)
+	if err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	writeJsonStatus(w, http.StatusCreated, insertedEntry)
+}
+
+// DeleteWaitlistEntry removes the user from a waitlist, given that they provided the email address they queued with.
+func (h *ApiHandler) DeleteWaitlistEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpProblemWithLog(r, w, ErrMalformedRequest.Wrap(err))
+		return
+	}
+
+	if err := h.db.DeleteWaitlistEntry(id, r.URL.Query().Get("email")); err != nil {
+		httpProblemWithLog(r, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWaitlistClaim provides the page for the user on the waitlist to confirm claiming the timeslot offered via their
+// claim link. The timeslot is only booked via PostWaitlistClaim, as mail scanners and link prefetchers open links on
+// their own. This method is supposed to be directly accessed via a link, thus it responds with simple HTML pages.
+func (h *ApiHandler) GetWaitlistClaim(w http.ResponseWriter, r *http.Request) {
+	logger := httplog.LogEntry(r.Context())
+
+	// An expired token implies that the timeslot was already offered to the next user on the waitlist
+	token := r.URL.Query().Get("token")
+	id, err := security.ValidateWaitlistOfferToken(token)
+	if err != nil {
+		logger.Warn(err.Error())
+		writeHtmlPage(w, http.StatusGone, "Angebot abgelaufen", "Dieser Link ist ungültig oder das Angebot ist bereits abgelaufen.")
+		return
+	}
+
+	waitlistEntry, err := h.db.GetWaitlistEntry(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeHtmlPage(w, http.StatusGone, "Angebot abgelaufen", "Dieses Angebot ist nicht mehr gültig.")
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	writeConfirmPage(w, "Timeslot übernehmen",
+		fmt.Sprintf("Möchtest du den Timeslot am %s für %s bis %s übernehmen?", waitlistEntry.Start.Format("02.01.2006"),
+			waitlistEntry.Start.Format("15:04"), waitlistEntry.End.Format("15:04")),
+		os.Getenv("PATH_PREFIX")+"/api/calendar/waitlist/claim", map[string]string{"token": token}, "Übernehmen")
+}
+
+// PostWaitlistClaim books an offered timeslot for the user on the waitlist, whose claim link was confirmed via
+// GetWaitlistClaim.
+func (h *ApiHandler) PostWaitlistClaim(w http.ResponseWriter, r *http.Request) {
+	logger := httplog.LogEntry(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		writeHtmlPage(w, http.StatusBadRequest, "Fehler", "Die Eingabe konnte nicht verarbeitet werden.")
+		return
+	}
+
+	id, err := security.ValidateWaitlistOfferToken(r.PostForm.Get("token"))
+	if err != nil {
+		logger.Warn(err.Error())
+		writeHtmlPage(w, http.StatusGone, "Angebot abgelaufen", "Dieser Link ist ungültig oder das Angebot ist bereits abgelaufen.")
+		return
+	}
+
+	waitlistEntry, err := h.db.GetWaitlistEntry(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeHtmlPage(w, http.StatusGone, "Angebot abgelaufen", "Dieses Angebot ist nicht mehr gültig.")
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	dateStr := waitlistEntry.Start.Format("02.01.2006")
+	startTimeStr := waitlistEntry.Start.Format("15:04")
+	endTimeStr := waitlistEntry.End.Format("15:04")
+
+	// Confirming twice should not be reported as a failure
+	if owned, err := h.db.HasEntry(waitlistEntry.Email, waitlistEntry.Start, waitlistEntry.End); err == nil && owned {
+		writeHtmlPage(w, http.StatusOK, "Timeslot übernommen",
+			fmt.Sprintf("Du hast den Timeslot am %s für %s bis %s bereits übernommen.", dateStr, startTimeStr, endTimeStr))
+		return
+	}
+
+	// The waitlist is no way around the quotas of the participants
+	if err := checkBookingQuotas(h.db, h.config, []CalendarEntryFull{waitlistEntry.toCalendarEntry()}); isQuotaExceeded(err) {
+		writeHtmlPage(w, http.StatusConflict, "Nicht möglich", quotaExceededMessage)
+		return
+	} else if err != nil {
+		logger.Error(err.Error())
+		writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+		return
+	}
+
+	insertedEntry, err := h.db.InsertEntry(waitlistEntry.toCalendarEntry())
+	if err != nil {
+		if !errors.Is(err, ErrEntryNotInserted) {
+			logger.Error(err.Error())
+			writeHtmlPage(w, http.StatusInternalServerError, "Fehler", "Es ist ein unerwarteter Fehler aufgetreten.")
+			return
+		}
+
+		writeHtmlPage(w, http.StatusConflict, "Bereits vergeben",
+			fmt.Sprintf("Der Timeslot am %s für %s bis %s ist leider bereits vergeben.", dateStr, startTimeStr, endTimeStr))
+		return
+	}
+	h.publishEntries(eventCreated, *insertedEntry)
+
+	if err := h.db.RemoveWaitlistEntry(waitlistEntry.Id); err != nil {
+		logger.Warn(err.Error())
+	}
+	if err := h.db.DeleteCoveredPendingAlerts(waitlistEntry.Start, waitlistEntry.End); err != nil {
+		logger.Warn(err.Error())
+	}
+
+	if err := sendEntryConfirmationEmail(waitlistEntry.Email, waitlistEntry.Start, waitlistEntry.End); err != nil {
+		logger.Warn("Failed to send email confirmation for email " + waitlistEntry.Email + " with error: " + err.Error())
+	}
+
+	writeHtmlPage(w, http.StatusCreated, "Timeslot übernommen",
+		fmt.Sprintf("Vielen Dank! Du hast den Timeslot am %s für %s bis %s übernommen.", dateStr, startTimeStr, endTimeStr))
+}
+
+// toCalendarEntry converts the WaitlistEntry into the CalendarEntryFull to be booked for it.
+func (e WaitlistEntry) toCalendarEntry() CalendarEntryFull {
+	return CalendarEntryFull{
+		CalendarEntry: CalendarEntry{FirstName: e.FirstName, Start: e.Start, End: e.End},
+		LastName:      e.LastName,
+		Email:         e.Email,
+	}
+}
+
+// offerReservations converts the given active offers into the entries reserving their timeslots against the given new
+// entry. An offer reserves its timeslot against everyone but the user it was offered to, as otherwise anyone could
+// take it while the claim link is still valid. Admin events whose EventType doesn't block bookings don't take the
+// timeslot away, thus they are never prevented.
+func (i eventTypeIndex) offerReservations(entry CalendarEntryFull, offers []WaitlistEntry) []CalendarEntry {
+	reservations := make([]CalendarEntry, 0)
+	if !i.blocksBookings(entry.CalendarEntry) {
+		return reservations
+	}
+
+	for _, offer := range offers {
+		if offer.Email != entry.Email {
+			reservations = append(reservations, CalendarEntry{Start: offer.Start, End: offer.End})
+		}
+	}
+
+	return reservations
+}
+
+// offeredEntries filters the given new entries, whose timeslots are currently reserved by an offer to the waitlist.
+func (h *ApiHandler) offeredEntries(entries []CalendarEntryFull) ([]CalendarEntryFull, error) {
+	offered := make([]CalendarEntryFull, 0)
+	if len(entries) == 0 {
+		return offered, nil
+	}
+
+	start, end := entries[0].Start, entries[0].End
+	for _, entry := range entries {
+		if entry.Start.Before(start) {
+			start = entry.Start
+		}
+		if entry.End.After(end) {
+			end = entry.End
+		}
+	}
+
+	offers, err := h.db.GetActiveWaitlistOffers(start, end, time.Now())
+	if err != nil || len(offers) == 0 {
+		return offered, err
+	}
+	types, err := loadEventTypes(h.db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !isFree(entry.Start, entry.End, types.offerReservations(entry, offers)) {
+			offered = append(offered, entry)
+		}
+	}
+
+	return offered, nil
+}
+
+// isFree checks whether the interval between start and end is entirely free of the given entries.
+func isFree(start, end time.Time, entries []CalendarEntry) bool {
+	free := uncoveredIntervals(start, end, entries)
+	return len(free) == 1 && free[0].Start.Equal(start) && free[0].End.Equal(end)
+}
+
+// offerToWaitlist hands the timeslots of the given deleted entries to their waitlists.
+func (h *ApiHandler) offerToWaitlist(entries ...CalendarEntry) error {
+	now := time.Now()
+	for _, entry := range entries {
+		if err := processWaitlist(h.db, h.config, h.events, entry.Start, entry.End, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processWaitlist hands the freed interval between start and end to the users waiting for a timeslot within it, in
+// the order they were queued. Depending on the waitlist policy, the timeslot is either offered to the user for a
+// limited time, during which the volunteers are not yet alerted, or directly booked for them. Timeslots that are
+// occupied or already offered to someone else are skipped, as well as users who would exceed their quotas, who remain
+// on the waitlist though.
+func processWaitlist(db *DBHandler, config *Config, events *EventBroker, start, end, now time.Time) error {
+	candidates, err := db.GetWaitlistCandidates(start, end)
+	if err != nil || len(candidates) == 0 {
+		return err
+	}
+
+	offers, err := db.GetActiveWaitlistOffers(start, end, now)
+	if err != nil {
+		return err
+	}
+	reserved := make([]CalendarEntry, 0, len(offers))
+	for _, offer := range offers {
+		reserved = append(reserved, CalendarEntry{Start: offer.Start, End: offer.End})
+	}
+
+	for _, candidate := range candidates {
+		if !candidate.Start.After(now) || !isFree(candidate.Start, candidate.End, reserved) {
+			continue
+		}
+		if err := checkBookingQuotas(db, config, []CalendarEntryFull{candidate.toCalendarEntry()}); isQuotaExceeded(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if config.WaitlistPolicy == waitlistPolicyAssign {
+			// The insert only succeeds if the timeslot is still free
+			insertedEntry, err := db.InsertEntry(candidate.toCalendarEntry())
+			if err != nil {
+				if errors.Is(err, ErrEntryNotInserted) {
+					continue
+				}
+				return err
+			}
+			events.Publish(CalendarEvent{Type: eventCreated, Entry: *insertedEntry})
+
+			if err := db.RemoveWaitlistEntry(candidate.Id); err != nil {
+				return err
+			}
+			if err := sendWaitlistAssignedEmail(candidate.Email, candidate.Start, candidate.End); err != nil {
+				log.Println("[waitlist] Failed to send assignment email:", err)
+			}
+		} else {
+			entries, err := db.GetAllEntriesForRange(candidate.Start, candidate.End)
+			if err != nil {
+				return err
+			}
+			types, err := loadEventTypes(db)
+			if err != nil {
+				return err
+			}
+			if !isFree(candidate.Start, candidate.End, types.blockingEntries(entries)) {
+				continue
+			}
+
+			// The offer must not outlast the start of the timeslot itself
+			expiresAt := now.Add(config.WaitlistOfferTimeout)
+			if expiresAt.After(candidate.Start) {
+				expiresAt = candidate.Start
+			}
+
+			claimLink, err := createWaitlistClaimLink(candidate.Id, expiresAt)
+			if err != nil {
+				return err
+			}
+			if err := db.SetWaitlistOffer(candidate.Id, expiresAt); err != nil {
+				return err
+			}
+			if err := sendWaitlistOfferEmail(candidate.Email, claimLink, candidate.Start, candidate.End, expiresAt); err != nil {
+				log.Println("[waitlist] Failed to send offer email:", err)
+			}
+		}
+
+		reserved = append(reserved, CalendarEntry{Start: candidate.Start, End: candidate.End})
+	}
+
+	return nil
+}
+
+// createWaitlistClaimLink creates the link for claiming the timeslot offered to a WaitlistEntry via GetWaitlistClaim.
+func createWaitlistClaimLink(waitlistId int, expiresAt time.Time) (string, error) {
+	token, err := security.CreateWaitlistOfferToken(waitlistId, expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/api/calendar/waitlist/claim?token=%s", os.Getenv("HOST_BE"), url.QueryEscape(token)), nil
+}
+
+// WaitlistMonitor passes the timeslots of expired offers on to the next user on the waitlist and removes the
+// waitlist entries of timeslots that already started. It is supposed to be run periodically as a job of the scheduler.
+type WaitlistMonitor struct {
+	db     *DBHandler
+	config *Config
+	events *EventBroker
+}
+
+// NewWaitlistMonitor is the constructor for WaitlistMonitor.
+func NewWaitlistMonitor(db *DBHandler, config *Config, events *EventBroker) *WaitlistMonitor {
+	return &WaitlistMonitor{db: db, config: config, events: events}
+}
+
+// Run processes the expired offers and conforms to scheduler.JobFunc.
+func (m *WaitlistMonitor) Run(_ context.Context, _ string) error {
+	now := time.Now()
+
+	if err := m.db.DeletePastWaitlistEntries(now); err != nil {
+		return err
+	}
+
+	expired, err := m.db.GetExpiredWaitlistOffers(now)
+	if err != nil {
+		return err
+	}
+
+	for _, offer := range expired {
+		// The user had their chance, so they leave the waitlist
+		if err := m.db.RemoveWaitlistEntry(offer.Id); err != nil {
+			return err
+		}
+		if err := processWaitlist(m.db, m.config, m.events, offer.Start, offer.End, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	// the runtime image doesn't necessarily provide timezone information
+	_ "time/tzdata"
 
 	"github.com/Sakrafux/pray-calendar/backend/app"
+	"github.com/Sakrafux/pray-calendar/backend/scheduler"
 	"github.com/Sakrafux/pray-calendar/backend/security"
 	"github.com/joho/godotenv"
 )
@@ -22,13 +30,46 @@ func main() {
 	defer db.Close()
 	db.Setup()
 
+	config := app.LoadConfig()
 	admin := &security.AdminData{Username: adminName, Password: adminPassword}
 
+	// The application is shut down gracefully on interruption, so running jobs and requests can finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	events := app.NewEventBroker()
+
+	jobs := scheduler.New(db.Conn(), config.Timezone)
+	jobs.Setup()
+	if err := app.RegisterJobs(jobs, db, config, events); err != nil {
+		log.Fatal(err)
+	}
+	jobs.Start(ctx)
+
 	server := http.Server{
 		Addr:    ":" + port,
-		Handler: app.CreateRouter(db, admin),
+		Handler: app.CreateRouter(db, admin, config, jobs, events),
 	}
+	// Open event streams would otherwise block the graceful shutdown until its timeout
+	server.RegisterOnShutdown(events.Close)
+
+	go func() {
+		log.Println("Listening on " + port + "...")
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
 
-	log.Println("Listening on " + port + "...")
-	log.Fatal(server.ListenAndServe())
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Failed to shut down server:", err)
+	}
+	if err := jobs.Stop(shutdownCtx); err != nil {
+		log.Println("Failed to stop scheduler:", err)
+	}
 }
@@ -0,0 +1,143 @@
+package qrcode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// matrix serializes the modules of a code row by row, with "1" for dark and "0" for light modules.
+func matrix(c *Code) []byte {
+	var b bytes.Buffer
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Dark(x, y) {
+				b.WriteByte('1')
+			} else {
+				b.WriteByte('0')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// TestEncodeGolden compares the module matrix of known payloads against golden hashes. The golden matrices were
+// cross-checked against the reference encoder rsc.io/qr (byte mode, same version, level and mask), so they cover the
+// data encoding, the Reed-Solomon blocks and their interleaving, the function patterns, the format and version
+// information, as well as the masking.
+func TestEncodeGolden(t *testing.T) {
+	tests := []struct {
+		content string
+		level   Level
+		version int
+		sha256  string
+	}{
+		{"Hello, World!", LevelL, 1, "04b08726d9682da4de338d3a79fb7bcb6dca2a3a594db9c12cddb97f1a83358b"},
+		{"Hello, World!", LevelM, 1, "69de1c7ac7780393e82682e70de7bbd59e46d26ee8284b65365c3c3cbd26579b"},
+		{"https://anbetung-stp.at/calendar", LevelL, 2, "b2fd80427bbae4e9cf90bd4dd0324e22e81766df6340552e74ad39cc7ac5cc47"},
+		{"https://anbetung-stp.at/calendar", LevelM, 3, "f8bf7fb25155b9fae2ecf9e007af8331a97714e26fb62067652e4a56e98eea57"},
+		{"https://anbetung-stp.at/api/checkin/chapel?key=0123456789abcdef", LevelL, 4, "382ead8f844dc3ae741700e2e32cc3751704982507cb3aaba78133ad9fc0a271"},
+		{"https://anbetung-stp.at/api/checkin/chapel?key=0123456789abcdef", LevelM, 5, "729b6ab3b5eceb1d8851a1959fa5d8cb06779aef2e54cfe11adc08e8352c5c55"},
+		// From version 7 on, the code contains the version information
+		{strings.Repeat("https://example.org/", 10), LevelL, 9, "9c663ded8b77ad84e96190dedabf475b12563b1cd54d017405d6a2ae7d0038ca"},
+		// From version 10 on, the character count indicator is longer
+		{strings.Repeat("https://example.org/", 10), LevelM, 10, "3e8fbe7e1d7f01dfd07683e01e93d2cd976df495c395e8a5f1eaa26e59dba2aa"},
+		{strings.Repeat("x", 300), LevelL, 11, "d37068c5d5f987134150b328de99694427c5d14da3780c4dffd5c5d6885abfaf"},
+		{strings.Repeat("x", 300), LevelM, 13, "3efd2aaeab519e378ead906863b11e2d7ccf83e38979eaf960adfb579131e3bf"},
+	}
+
+	for _, tt := range tests {
+		c, err := Encode(tt.content, tt.level)
+		if err != nil {
+			t.Fatalf("Encode(%q, %d): %v", tt.content, tt.level, err)
+		}
+		if c.Version != tt.version || c.Size != tt.version*4+17 {
+			t.Errorf("Encode(%q, %d): version %d with size %d, want version %d", tt.content, tt.level, c.Version, c.Size, tt.version)
+			continue
+		}
+
+		sum := sha256.Sum256(matrix(c))
+		if got := hex.EncodeToString(sum[:]); got != tt.sha256 {
+			t.Errorf("Encode(%q, %d): matrix hash %s, want %s", tt.content, tt.level, got, tt.sha256)
+		}
+	}
+}
+
+// TestEncodeCapacity checks that the version is chosen exactly at the byte mode capacities of ISO/IEC 18004, i.e.,
+// a payload that fills a version completely still fits, while a single additional byte requires the next version.
+func TestEncodeCapacity(t *testing.T) {
+	tests := []struct {
+		level    Level
+		version  int
+		capacity int
+	}{
+		{LevelL, 1, 17},
+		{LevelL, 2, 32},
+		{LevelL, 9, 230},
+		{LevelL, 10, 271},
+		{LevelL, 25, 1273},
+		{LevelM, 1, 14},
+		{LevelM, 2, 26},
+		{LevelM, 9, 180},
+		{LevelM, 10, 213},
+		{LevelM, 25, 997},
+	}
+
+	for _, tt := range tests {
+		c, err := Encode(strings.Repeat("a", tt.capacity), tt.level)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes, %d): %v", tt.capacity, tt.level, err)
+		}
+		if c.Version != tt.version {
+			t.Errorf("Encode(%d bytes, %d): version %d, want %d", tt.capacity, tt.level, c.Version, tt.version)
+		}
+
+		c, err = Encode(strings.Repeat("a", tt.capacity+1), tt.level)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes, %d): %v", tt.capacity+1, tt.level, err)
+		}
+		if c.Version != tt.version+1 {
+			t.Errorf("Encode(%d bytes, %d): version %d, want %d", tt.capacity+1, tt.level, c.Version, tt.version+1)
+		}
+	}
+}
+
+// TestEncodeTooLong checks the capacity of the largest version, beyond which ErrTooLong is returned.
+func TestEncodeTooLong(t *testing.T) {
+	tests := []struct {
+		level    Level
+		capacity int
+	}{
+		{LevelL, 2953},
+		{LevelM, 2331},
+	}
+
+	for _, tt := range tests {
+		c, err := Encode(strings.Repeat("a", tt.capacity), tt.level)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes, %d): %v", tt.capacity, tt.level, err)
+		}
+		if c.Version != 40 {
+			t.Errorf("Encode(%d bytes, %d): version %d, want 40", tt.capacity, tt.level, c.Version)
+		}
+
+		if _, err := Encode(strings.Repeat("a", tt.capacity+1), tt.level); !errors.Is(err, ErrTooLong) {
+			t.Errorf("Encode(%d bytes, %d): error %v, want %v", tt.capacity+1, tt.level, err, ErrTooLong)
+		}
+	}
+}
+
+// TestReedSolomon checks the error correction codewords against the well-known example of "HELLO WORLD" as
+// version 1-M in alphanumeric mode.
+func TestReedSolomon(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Errorf("reedSolomonRemainder: %v, want %v", got, want)
+	}
+}
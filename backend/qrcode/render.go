@@ -0,0 +1,63 @@
+// Provides the rendering of QR codes as PNG and SVG images
+
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// QuietZone is the number of light modules surrounding the code, as required by the standard
+const QuietZone = 4
+
+// PNG renders the code as a black and white PNG image, where each module is scale pixels wide.
+func (c *Code) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		scale = 1
+	}
+	size := (c.Size + 2*QuietZone) * scale
+
+	palette := color.Palette{color.White, color.Black}
+	img := image.NewPaletted(image.Rect(0, 0, size, size), palette)
+	for py := 0; py < size; py++ {
+		for px := 0; px < size; px++ {
+			if c.Dark(px/scale-QuietZone, py/scale-QuietZone) {
+				img.SetColorIndex(px, py, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders the code as a scalable SVG image, where one unit of the view box is one module. Without a width and
+// height, the image fills its container, which suits embedding it into HTML.
+func (c *Code) SVG() string {
+	size := c.Size + 2*QuietZone
+
+	// A single path keeps the output small, with adjacent dark modules of a row merged into one rectangle
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+			start := x
+			for x+1 < c.Size && c.Dark(x+1, y) {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start+QuietZone, y+QuietZone, x-start+1, x-start+1)
+		}
+	}
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="100%%" height="100%%" fill="#ffffff"/><path d="%s" fill="#000000"/></svg>`, size, size, path.String())
+}
@@ -0,0 +1,39 @@
+// Defines structs that conform to the database schema of the scheduler or are necessary for its consumers
+
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// JobFunc is the function executed for a job. The payload is only relevant for one-shot jobs, which may carry
+// arbitrary (e.g. JSON encoded) data from the point where they were scheduled. The context is cancelled on shutdown.
+type JobFunc func(ctx context.Context, payload string) error
+
+// Job corresponds to the table "scheduler_jobs". A job is either recurring, i.e., it has a Schedule and is re-scheduled
+// after every run, or one-shot, i.e., it is run once at NextRun and then removed.
+type Job struct {
+	Id   int
+	Name string
+	// Schedule is the cron expression of a recurring job, and empty for a one-shot job
+	Schedule string
+	Payload  string
+	NextRun  time.Time
+	// Attempts counts the failed runs of a one-shot job, which are retried up to maxAttempts times
+	Attempts    int
+	LockedUntil *time.Time
+}
+
+// Run corresponds to the table "scheduler_runs" and records a single execution of a Job for traceability.
+type Run struct {
+	Id        int
+	JobId     int
+	JobName   string
+	StartedAt time.Time
+	// FinishedAt is nil while the job is still running
+	FinishedAt *time.Time
+	// Status is either "running", "succeeded" or "failed"
+	Status string
+	Error  *string
+}
@@ -0,0 +1,221 @@
+// Provides the parsing of cron-like schedule expressions and the computation of their next activation
+
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes the next activation of a recurring job.
+type Schedule interface {
+	// Next returns the first activation strictly after the given time.
+	Next(after time.Time) time.Time
+}
+
+// everySchedule is a simple fixed-interval schedule, e.g. "@every 30s".
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(after time.Time) time.Time {
+	return after.Add(s.interval)
+}
+
+// cronSchedule is a classic five-field cron schedule, i.e., "minute hour day-of-month month day-of-week". Each field
+// is represented by the set of matching values.
+type cronSchedule struct {
+	minute, hour, dom, month, dow map[int]bool
+	// domAny and dowAny record whether the day fields are unrestricted, since cron matches days differently then
+	domAny, dowAny bool
+	// hourAny records whether the hour field is unrestricted, since only fixed hours are adjusted for DST transitions
+	hourAny bool
+}
+
+// Next iterates from the given time onward and skips whole months, days or hours if they don't match, which keeps
+// the number of iterations small even for sparse schedules. Like in classic cron, a job at a fixed hour runs once
+// across DST transitions, i.e., a skipped hour is caught up right afterward and a repeated hour isn't run twice.
+func (s cronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// A schedule like "0 0 30 2 *" never matches, so the search has to be bounded
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !s.month[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.hour[t.Hour()] {
+			// The hour is advanced in absolute time, as the wall time of the next hour is ambiguous when the clocks
+			// are set back
+			next := t.Add(-time.Duration(t.Minute()) * time.Minute).Add(time.Hour)
+			if s.skipsHour(t, next) {
+				return next
+			}
+			t = next
+			continue
+		}
+		if !s.minute[t.Minute()] {
+			next := t.Add(time.Minute)
+			if s.skipsHour(t, next) {
+				return next
+			}
+			t = next
+			continue
+		}
+		if !s.hourAny && !wallClock(t).After(wallClock(after)) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// skipsHour checks whether advancing from t to next skips a matching hour entirely, which happens when the clocks are
+// set forward. If every hour matches, the hour after the skipped one matches anyway.
+func (s cronSchedule) skipsHour(t, next time.Time) bool {
+	if s.hourAny || next.Day() != t.Day() {
+		return false
+	}
+	for hour := t.Hour() + 1; hour < next.Hour(); hour++ {
+		if s.hour[hour] {
+			return true
+		}
+	}
+	return false
+}
+
+// wallClock is a utility method to get the wall time of t regardless of its offset, which tells apart the repeated
+// hour when the clocks are set back.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// dayMatches follows the cron convention, that if both day fields are restricted, either of them has to match.
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom[t.Day()]
+	dowMatch := s.dow[int(t.Weekday())]
+	if !s.domAny && !s.dowAny {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// ParseSchedule parses a schedule expression, which is either a five-field cron expression (e.g. "0 18 * * *"),
+// one of the shorthands "@hourly", "@daily", "@weekly" and "@monthly", or a fixed interval like "@every 30s".
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	}
+
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		duration, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, err
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("interval must be positive: %s", spec)
+		}
+		return everySchedule{interval: duration}, nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in cron expression: %s", spec)
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	// Sunday may be given as both 0 and 7
+	if s.dow[7] {
+		s.dow[0] = true
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	s.hourAny = fields[1] == "*"
+
+	return s, nil
+}
+
+// parseField parses a single cron field, which is a comma-separated list of "*", single values, or ranges "a-b",
+// each optionally followed by a step "/n".
+func parseField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in cron field: %s", field)
+			}
+		}
+
+		var from, to int
+		if rangePart == "*" {
+			from, to = min, max
+		} else if a, b, isRange := strings.Cut(rangePart, "-"); isRange {
+			var errA, errB error
+			from, errA = strconv.Atoi(a)
+			to, errB = strconv.Atoi(b)
+			if errA != nil || errB != nil {
+				return nil, fmt.Errorf("invalid range in cron field: %s", field)
+			}
+		} else {
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in cron field: %s", field)
+			}
+			from, to = value, value
+			// "5/15" means starting at 5 until the end of the range
+			if hasStep {
+				to = max
+			}
+		}
+
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("value out of range in cron field: %s", field)
+		}
+
+		for v := from; v <= to; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+// vienna is the timezone the application operates in by default, whose DST transitions in 2026 are on March 29 at
+// 02:00 (set forward to 03:00) and on October 25 at 03:00 (set back to 02:00).
+func vienna(t *testing.T) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation("Europe/Vienna")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	return location
+}
+
+// TestScheduleNext checks the next activation of the schedule forms the application registers, i.e., fixed intervals
+// for the frequent jobs and cron expressions for the configurable digest.
+func TestScheduleNext(t *testing.T) {
+	loc := vienna(t)
+	at := func(year int, month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, loc)
+	}
+	// Within a repeated hour, the wall time is ambiguous, so such times are given in UTC
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{"every seconds", "@every 30s", at(2026, 10, 19, 10, 0, 15), at(2026, 10, 19, 10, 0, 45)},
+		{"every minute", "@every 1m", at(2026, 10, 19, 10, 0, 15), at(2026, 10, 19, 10, 1, 15)},
+		{"daily later today", "0 18 * * *", at(2026, 10, 19, 17, 59, 30), at(2026, 10, 19, 18, 0, 0)},
+		{"daily strictly after", "0 18 * * *", at(2026, 10, 19, 18, 0, 0), at(2026, 10, 20, 18, 0, 0)},
+		{"range and step over weekend", "*/15 9-17 * * 1-5", at(2026, 10, 23, 17, 50, 0), at(2026, 10, 26, 9, 0, 0)},
+		{"step from offset", "5/15 * * * *", at(2026, 10, 19, 10, 50, 0), at(2026, 10, 19, 11, 5, 0)},
+		{"list and ranged step", "0,30 8-12/2 * * *", at(2026, 10, 19, 8, 30, 0), at(2026, 10, 19, 10, 0, 0)},
+		{"day of month or week matches weekday", "0 12 13 * 5", at(2026, 10, 19, 0, 0, 0), at(2026, 10, 23, 12, 0, 0)},
+		{"day of month or week matches day", "0 12 13 * 5", at(2027, 1, 9, 0, 0, 0), at(2027, 1, 13, 12, 0, 0)},
+		{"day of month skips short months", "0 0 31 * *", at(2026, 10, 31, 0, 0, 0), at(2026, 12, 31, 0, 0, 0)},
+		{"day of week within month", "0 0 * 2 1", at(2026, 10, 19, 0, 0, 0), at(2027, 2, 1, 0, 0, 0)},
+		{"sunday as 7", "0 8 * * 7", at(2026, 10, 19, 0, 0, 0), at(2026, 10, 25, 8, 0, 0)},
+		{"weekly", "@weekly", at(2026, 10, 19, 0, 0, 0), at(2026, 10, 25, 0, 0, 0)},
+		{"monthly", "@monthly", at(2026, 10, 19, 0, 0, 0), at(2026, 11, 1, 0, 0, 0)},
+		{"never", "0 0 30 2 *", at(2026, 10, 19, 0, 0, 0), time.Time{}},
+		// A day with a DST transition is 25 or 23 hours long, but the wall time stays the same
+		{"daily across set back", "0 18 * * *", at(2026, 10, 24, 18, 0, 0), at(2026, 10, 25, 18, 0, 0)},
+		{"daily across set forward", "0 18 * * *", at(2026, 3, 28, 18, 0, 0), at(2026, 3, 29, 18, 0, 0)},
+		// 02:30 occurs twice on October 25, but a fixed hour is only run the first time
+		{"fixed hour before repeated hour", "30 2 * * *", at(2026, 10, 25, 1, 0, 0), utc(2026, 10, 25, 0, 30)},
+		{"fixed hour within repeated hour", "30 2 * * *", utc(2026, 10, 25, 0, 30), at(2026, 10, 26, 2, 30, 0)},
+		{"unrestricted hour within repeated hour", "*/30 * * * *", utc(2026, 10, 25, 0, 30), utc(2026, 10, 25, 1, 0)},
+		// 02:30 doesn't exist on March 29, so a fixed hour is caught up right after the skipped hour
+		{"fixed hour within skipped hour", "30 2 * * *", at(2026, 3, 29, 1, 0, 0), at(2026, 3, 29, 3, 0, 0)},
+		{"fixed hours around skipped hour", "45 1,2 * * *", at(2026, 3, 29, 1, 45, 0), at(2026, 3, 29, 3, 0, 0)},
+		{"unrestricted hour across skipped hour", "0 * * * *", at(2026, 3, 29, 1, 30, 0), at(2026, 3, 29, 3, 0, 0)},
+		{"every across set back", "@every 1m", utc(2026, 10, 25, 0, 59), utc(2026, 10, 25, 1, 0)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("%s: ParseSchedule(%q): %v", tt.name, tt.spec, err)
+		}
+		if got := schedule.Next(tt.after.In(loc)); !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%s) of %q = %s, want %s", tt.name, tt.after.In(loc), tt.spec, got, tt.want.In(loc))
+		}
+	}
+}
+
+// TestNextRun checks that the next run is computed in the configured timezone, but stored in UTC.
+func TestNextRun(t *testing.T) {
+	s := New(nil, vienna(t))
+	schedule, err := ParseSchedule("0 18 * * *")
+	if err != nil {
+		t.Fatalf("ParseSchedule: %v", err)
+	}
+
+	tests := []struct {
+		after time.Time
+		want  time.Time
+	}{
+		{time.Date(2026, 10, 24, 12, 0, 0, 0, time.UTC), time.Date(2026, 10, 24, 16, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 10, 25, 12, 0, 0, 0, time.UTC), time.Date(2026, 10, 25, 17, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got := s.nextRun(schedule, tt.after)
+		if got.Location() != time.UTC || !got.Equal(tt.want) {
+			t.Errorf("nextRun(%s) = %s, want %s", tt.after, got, tt.want)
+		}
+	}
+
+	never, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseSchedule: %v", err)
+	}
+	if got := s.nextRun(never, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); got.Year() != 9999 {
+		t.Errorf("nextRun of an unmatchable schedule = %s, want the year 9999", got)
+	}
+}
+
+// TestParseScheduleInvalid checks that malformed expressions are rejected instead of silently never running.
+func TestParseScheduleInvalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-a * * * *",
+		"@every",
+		"@every x",
+		"@every -1s",
+		"@yearly",
+	}
+
+	for _, spec := range specs {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+// TestParseField checks the values of the single cron fields.
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     []int
+	}{
+		{"*", 0, 6, []int{0, 1, 2, 3, 4, 5, 6}},
+		{"*/20", 0, 59, []int{0, 20, 40}},
+		{"5/20", 0, 59, []int{5, 25, 45}},
+		{"1-10/3", 0, 59, []int{1, 4, 7, 10}},
+		{"1,3-4,4", 1, 31, []int{1, 3, 4}},
+		{"7", 0, 7, []int{7}},
+	}
+
+	for _, tt := range tests {
+		values, err := parseField(tt.field, tt.min, tt.max)
+		if err != nil {
+			t.Fatalf("parseField(%q): %v", tt.field, err)
+		}
+		if got := slices.Sorted(maps.Keys(values)); !slices.Equal(got, tt.want) {
+			t.Errorf("parseField(%q) = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+}
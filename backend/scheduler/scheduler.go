@@ -0,0 +1,460 @@
+// Provides a small persistent in-process job scheduler, which runs recurring and one-shot jobs stored in sqlite
+
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// pollInterval is the interval in which the scheduler checks for due jobs
+	pollInterval = 5 * time.Second
+	// lockLease is the time a job stays locked by a run. If the process dies during a run, the job becomes available
+	// again after this time.
+	lockLease = 10 * time.Minute
+	// leaseRenewal is the interval in which a running job renews its lock, so long runs don't outlast the lease
+	leaseRenewal = lockLease / 3
+	// runRetention is how long the history of runs is kept, as the frequent jobs would otherwise let it grow forever
+	runRetention = 7 * 24 * time.Hour
+	// pruneInterval is the interval in which the history of runs is pruned
+	pruneInterval = time.Hour
+	// maxAttempts is the number of times a failing one-shot job is run before it is given up
+	maxAttempts = 3
+	// retryBackoff is the delay before a failed one-shot job is retried, multiplied by the number of attempts
+	retryBackoff = time.Minute
+)
+
+// Scheduler runs jobs persisted in the database. Recurring jobs are registered in code on every startup, while
+// their next run is persisted, so runs missed during downtime are caught up once. One-shot jobs are scheduled at
+// runtime and persisted entirely, including their payload.
+//
+// Each job run locks its job in the database, so a job is never run concurrently, not even by several instances
+// sharing the same database. Every run is recorded as history, which is kept for a limited time.
+type Scheduler struct {
+	db *sql.DB
+	// instance identifies this process as the owner of a lock
+	instance string
+	// location is the timezone in which cron expressions are evaluated
+	location *time.Location
+
+	mu        sync.Mutex
+	handlers  map[string]JobFunc
+	schedules map[string]Schedule
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New is the constructor for Scheduler. Cron expressions are evaluated in the given timezone.
+func New(db *sql.DB, location *time.Location) *Scheduler {
+	return &Scheduler{
+		db:        db,
+		instance:  uuid.New().String(),
+		location:  location,
+		handlers:  make(map[string]JobFunc),
+		schedules: make(map[string]Schedule),
+	}
+}
+
+// Setup creates the tables of the scheduler.
+func (s *Scheduler) Setup() {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS scheduler_jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			schedule TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			next_run DATETIME NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			locked_until DATETIME,
+			locked_by TEXT
+		);
+
+		CREATE INDEX IF NOT EXISTS scheduler_jobs_next_run ON scheduler_jobs (next_run);
+
+		CREATE TABLE IF NOT EXISTS scheduler_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			job_id INTEGER NOT NULL,
+			job_name TEXT NOT NULL,
+			started_at DATETIME NOT NULL,
+			finished_at DATETIME,
+			status TEXT NOT NULL,
+			error TEXT
+		);
+	`)
+	// an error during table creation is not recoverable
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Every registers a recurring job with the given name, which is run according to the given schedule expression (see
+// ParseSchedule). If the job already exists in the database, its pending next run is kept, unless the schedule changed.
+func (s *Scheduler) Every(name, spec string, fn JobFunc) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.handlers[name] = fn
+	s.schedules[name] = schedule
+	s.mu.Unlock()
+
+	var existingSpec string
+	err = s.db.QueryRow("SELECT schedule FROM scheduler_jobs WHERE name = $1 AND schedule <> ''", name).Scan(&existingSpec)
+	if err == sql.ErrNoRows {
+		_, err = s.db.Exec(`
+			INSERT INTO scheduler_jobs (name, schedule, payload, next_run)
+			SELECT $1, $2, '', $3
+		`, name, spec, s.nextRun(schedule, time.Now()))
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if existingSpec != spec {
+		_, err = s.db.Exec("UPDATE scheduler_jobs SET schedule = $1, next_run = $2 WHERE name = $3 AND schedule <> ''",
+			spec, s.nextRun(schedule, time.Now()), name)
+	}
+	return err
+}
+
+// Handle registers the function executing one-shot jobs of the given name.
+func (s *Scheduler) Handle(name string, fn JobFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[name] = fn
+}
+
+// ScheduleOnce persists a one-shot job of the given name, which is run once at the given time with the given payload.
+// A handler for the name must be registered via Handle.
+func (s *Scheduler) ScheduleOnce(name, payload string, at time.Time) (int, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO scheduler_jobs (name, schedule, payload, next_run)
+		SELECT $1, '', $2, $3
+	`, name, payload, at.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// CancelOnce removes all pending one-shot jobs of the given name and payload, which are not currently running.
+func (s *Scheduler) CancelOnce(name, payload string) error {
+	_, err := s.db.Exec(`
+		DELETE FROM scheduler_jobs
+		WHERE name = $1 AND payload = $2 AND schedule = '' AND (locked_until IS NULL OR locked_until < $3)
+	`, name, payload, time.Now().UTC())
+	return err
+}
+
+// Start starts polling for due jobs in the background. Running jobs receive a context that is cancelled once the
+// given context is cancelled or Stop is called.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		pruneTicker := time.NewTicker(pruneInterval)
+		defer pruneTicker.Stop()
+
+		s.pruneRuns()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runDueJobs(ctx)
+			case <-pruneTicker.C:
+				s.pruneRuns()
+			}
+		}
+	}()
+
+	log.Println("[scheduler] Started")
+}
+
+// Stop stops polling for due jobs, cancels the running jobs and waits for them to finish, or until the given context
+// expires.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("[scheduler] Stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetJobs queries all jobs currently persisted, i.e., all recurring and all pending one-shot jobs.
+func (s *Scheduler) GetJobs() ([]Job, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, schedule, payload, next_run, attempts, locked_until FROM scheduler_jobs
+		ORDER BY next_run ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]Job, 0)
+	for rows.Next() {
+		var job Job
+		if err := rows.Scan(&job.Id, &job.Name, &job.Schedule, &job.Payload, &job.NextRun, &job.Attempts, &job.LockedUntil); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// GetRuns queries the most recent runs of all jobs, limited to the given number.
+func (s *Scheduler) GetRuns(limit int) ([]Run, error) {
+	rows, err := s.db.Query(`
+		SELECT id, job_id, job_name, started_at, finished_at, status, error FROM scheduler_runs
+		ORDER BY id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]Run, 0)
+	for rows.Next() {
+		var run Run
+		if err := rows.Scan(&run.Id, &run.JobId, &run.JobName, &run.StartedAt, &run.FinishedAt, &run.Status, &run.Error); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+// runDueJobs locks and starts all jobs that are due and not locked by another run.
+func (s *Scheduler) runDueJobs(ctx context.Context) {
+	now := time.Now().UTC()
+
+	rows, err := s.db.Query(`
+		SELECT id, name, schedule, payload, next_run, attempts FROM scheduler_jobs
+		WHERE next_run <= $1 AND (locked_until IS NULL OR locked_until < $1)
+		ORDER BY next_run ASC
+	`, now)
+	if err != nil {
+		log.Println("[scheduler] Failed to query due jobs:", err)
+		return
+	}
+
+	// The rows are collected first, so the connection is released before the jobs start using the database
+	jobs := make([]Job, 0)
+	for rows.Next() {
+		var job Job
+		if err := rows.Scan(&job.Id, &job.Name, &job.Schedule, &job.Payload, &job.NextRun, &job.Attempts); err != nil {
+			log.Println("[scheduler] Failed to read due job:", err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	rows.Close()
+
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		locked, err := s.lock(job.Id, now)
+		if err != nil {
+			log.Println("[scheduler] Failed to lock job:", err)
+			continue
+		}
+		// Another instance was faster
+		if !locked {
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.execute(ctx, job)
+		}()
+	}
+}
+
+// lock atomically acquires the lock of a job, returning false if it is already locked.
+func (s *Scheduler) lock(id int, now time.Time) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE scheduler_jobs SET locked_until = $1, locked_by = $2
+		WHERE id = $3 AND (locked_until IS NULL OR locked_until < $4)
+	`, now.Add(lockLease), s.instance, id, now)
+	if err != nil {
+		return false, err
+	}
+
+	nrOfRows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return nrOfRows == 1, nil
+}
+
+// renewLock periodically extends the lease of a lock held by this instance, until the given context is cancelled.
+func (s *Scheduler) renewLock(ctx context.Context, id int) {
+	ticker := time.NewTicker(leaseRenewal)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.renew(id, time.Now().UTC()); err != nil {
+				log.Println("[scheduler] Failed to renew lock:", err)
+			}
+		}
+	}
+}
+
+// renew extends the lease of a lock from the given time on, given that it is held by this instance.
+func (s *Scheduler) renew(id int, now time.Time) error {
+	_, err := s.db.Exec("UPDATE scheduler_jobs SET locked_until = $1 WHERE id = $2 AND locked_by = $3",
+		now.Add(lockLease), id, s.instance)
+	return err
+}
+
+// pruneRuns removes the runs that are older than the retention period.
+func (s *Scheduler) pruneRuns() {
+	if _, err := s.db.Exec("DELETE FROM scheduler_runs WHERE started_at < $1", time.Now().UTC().Add(-runRetention)); err != nil {
+		log.Println("[scheduler] Failed to prune runs:", err)
+	}
+}
+
+// execute runs a single locked job, records the run, and afterwards either re-schedules or removes the job.
+func (s *Scheduler) execute(ctx context.Context, job Job) {
+	s.mu.Lock()
+	fn, ok := s.handlers[job.Name]
+	schedule := s.schedules[job.Name]
+	s.mu.Unlock()
+
+	// A job without handler was likely persisted by a different version of the application, so it is left alone
+	if !ok {
+		log.Printf("[scheduler] No handler registered for job %s", job.Name)
+		s.unlock(job.Id)
+		return
+	}
+
+	started := time.Now().UTC()
+	res, err := s.db.Exec(`
+		INSERT INTO scheduler_runs (job_id, job_name, started_at, status)
+		SELECT $1, $2, $3, 'running'
+	`, job.Id, job.Name, started)
+	if err != nil {
+		log.Println("[scheduler] Failed to record run:", err)
+	}
+	var runId int64
+	if res != nil {
+		runId, _ = res.LastInsertId()
+	}
+
+	// The lock is renewed for as long as the job is running, so it is never run concurrently with itself
+	renewCtx, stopRenewal := context.WithCancel(ctx)
+	go s.renewLock(renewCtx, job.Id)
+	runErr := s.call(ctx, fn, job.Payload)
+	stopRenewal()
+
+	status := "succeeded"
+	var errMsg *string
+	if runErr != nil {
+		status = "failed"
+		msg := runErr.Error()
+		errMsg = &msg
+		log.Printf("[scheduler] Job %s failed: %s", job.Name, msg)
+	}
+	if _, err := s.db.Exec("UPDATE scheduler_runs SET finished_at = $1, status = $2, error = $3 WHERE id = $4",
+		time.Now().UTC(), status, errMsg, runId); err != nil {
+		log.Println("[scheduler] Failed to record run:", err)
+	}
+
+	if job.Schedule != "" {
+		// Missed activations are not run repeatedly, the next run is simply computed from now on
+		if schedule == nil {
+			schedule, err = ParseSchedule(job.Schedule)
+			if err != nil {
+				log.Println("[scheduler] Failed to parse schedule:", err)
+				s.unlock(job.Id)
+				return
+			}
+		}
+		_, err = s.db.Exec("UPDATE scheduler_jobs SET next_run = $1, locked_until = NULL, locked_by = NULL WHERE id = $2",
+			s.nextRun(schedule, time.Now()), job.Id)
+	} else if runErr != nil && job.Attempts+1 < maxAttempts {
+		_, err = s.db.Exec(`
+			UPDATE scheduler_jobs SET next_run = $1, attempts = attempts + 1, locked_until = NULL, locked_by = NULL
+			WHERE id = $2
+		`, time.Now().UTC().Add(retryBackoff*time.Duration(job.Attempts+1)), job.Id)
+	} else {
+		_, err = s.db.Exec("DELETE FROM scheduler_jobs WHERE id = $1", job.Id)
+	}
+	if err != nil {
+		log.Println("[scheduler] Failed to update job:", err)
+	}
+}
+
+// call runs a JobFunc, converting a panic into an error, so a faulty job can't take down the whole application.
+func (s *Scheduler) call(ctx context.Context, fn JobFunc, payload string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return fn(ctx, payload)
+}
+
+// unlock releases the lock of a job without changing anything else.
+func (s *Scheduler) unlock(id int) {
+	if _, err := s.db.Exec("UPDATE scheduler_jobs SET locked_until = NULL, locked_by = NULL WHERE id = $1", id); err != nil {
+		log.Println("[scheduler] Failed to unlock job:", err)
+	}
+}
+
+// nextRun computes the next activation of a schedule in the configured timezone, which is then stored as UTC.
+func (s *Scheduler) nextRun(schedule Schedule, after time.Time) time.Time {
+	next := schedule.Next(after.In(s.location))
+	// A cron expression that can't be matched is simply never run
+	if next.IsZero() {
+		return time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return next.UTC()
+}
@@ -0,0 +1,171 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// openTestDB opens an in-memory database with the tables of the scheduler. As every connection would otherwise get
+// its own in-memory database, the schedulers share a single connection, just like instances share a database file.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	New(db, time.UTC).Setup()
+	return db
+}
+
+// lockedUntil queries the lease of the given job, which is nil if it isn't locked.
+func lockedUntil(t *testing.T, s *Scheduler, id int) *time.Time {
+	t.Helper()
+	jobs, err := s.GetJobs()
+	if err != nil {
+		t.Fatalf("GetJobs: %v", err)
+	}
+	for _, job := range jobs {
+		if job.Id == id {
+			return job.LockedUntil
+		}
+	}
+	t.Fatalf("job %d not found", id)
+	return nil
+}
+
+// TestLockContention checks that of several instances competing for the same job, only a single one acquires its
+// lock, while the others only get it once the lease expired.
+func TestLockContention(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	instances := make([]*Scheduler, 10)
+	for i := range instances {
+		instances[i] = New(db, time.UTC)
+	}
+	id, err := instances[0].ScheduleOnce("job", "", now)
+	if err != nil {
+		t.Fatalf("ScheduleOnce: %v", err)
+	}
+
+	var acquired atomic.Int32
+	var wg sync.WaitGroup
+	for _, s := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			locked, err := s.lock(id, now)
+			if err != nil {
+				t.Errorf("lock: %v", err)
+			}
+			if locked {
+				acquired.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := acquired.Load(); got != 1 {
+		t.Fatalf("%d instances acquired the lock, want 1", got)
+	}
+
+	// The lock isn't reentrant either, so a job is never run concurrently with itself
+	for _, s := range instances {
+		if locked, err := s.lock(id, now.Add(lockLease-time.Second)); err != nil || locked {
+			t.Errorf("lock during the lease = %t, %v, want false", locked, err)
+		}
+	}
+
+	if locked, err := instances[1].lock(id, now.Add(lockLease+time.Second)); err != nil || !locked {
+		t.Errorf("lock after the lease = %t, %v, want true", locked, err)
+	}
+}
+
+// TestRenewLock checks that only the instance holding the lock extends its lease, which then keeps the others out.
+func TestRenewLock(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	owner, other := New(db, time.UTC), New(db, time.UTC)
+	id, err := owner.ScheduleOnce("job", "", now)
+	if err != nil {
+		t.Fatalf("ScheduleOnce: %v", err)
+	}
+	if locked, err := owner.lock(id, now); err != nil || !locked {
+		t.Fatalf("lock = %t, %v, want true", locked, err)
+	}
+
+	renewal := now.Add(leaseRenewal)
+	if err := other.renew(id, renewal); err != nil {
+		t.Fatalf("renew: %v", err)
+	}
+	if got := lockedUntil(t, owner, id); got == nil || !got.Equal(now.Add(lockLease)) {
+		t.Errorf("lease after renewal by another instance = %v, want %s", got, now.Add(lockLease))
+	}
+
+	if err := owner.renew(id, renewal); err != nil {
+		t.Fatalf("renew: %v", err)
+	}
+	if got := lockedUntil(t, owner, id); got == nil || !got.Equal(renewal.Add(lockLease)) {
+		t.Errorf("lease after renewal by the owner = %v, want %s", got, renewal.Add(lockLease))
+	}
+
+	if locked, err := other.lock(id, now.Add(lockLease+time.Second)); err != nil || locked {
+		t.Errorf("lock within the renewed lease = %t, %v, want false", locked, err)
+	}
+
+	owner.unlock(id)
+	if locked, err := other.lock(id, now); err != nil || !locked {
+		t.Errorf("lock after unlock = %t, %v, want true", locked, err)
+	}
+}
+
+// TestRunDueJobsOnce checks that a due job is run exactly once, even if several instances poll at the same time.
+func TestRunDueJobsOnce(t *testing.T) {
+	db := openTestDB(t)
+
+	var runs atomic.Int32
+	instances := make([]*Scheduler, 5)
+	for i := range instances {
+		instances[i] = New(db, time.UTC)
+		instances[i].Handle("job", func(context.Context, string) error {
+			runs.Add(1)
+			return nil
+		})
+	}
+	if _, err := instances[0].ScheduleOnce("job", "payload", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("ScheduleOnce: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runDueJobs(context.Background())
+		}()
+	}
+	wg.Wait()
+	for _, s := range instances {
+		s.wg.Wait()
+	}
+
+	if got := runs.Load(); got != 1 {
+		t.Errorf("job ran %d times, want 1", got)
+	}
+	jobs, err := instances[0].GetJobs()
+	if err != nil {
+		t.Fatalf("GetJobs: %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("%d jobs left after a successful one-shot run, want 0", len(jobs))
+	}
+}
@@ -2,9 +2,19 @@
 
 package security
 
+import "time"
+
 // AdminData encapsulates the name and password for the single admin account.
 // Currently, it is not necessary to have proper user management with admin rights.
 type AdminData struct {
 	Username string
 	Password string
 }
+
+// SlotClaim encapsulates the information contained in a signed claim link, which allows a volunteer to book a freed
+// timeslot in one click.
+type SlotClaim struct {
+	Email string
+	Start time.Time
+	End   time.Time
+}
@@ -5,6 +5,7 @@ package security
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,6 +13,9 @@ import (
 
 var accessSecret = []byte(os.Getenv("ACCESS_SECRET"))
 var refreshSecret = []byte(os.Getenv("REFRESH_SECRET"))
+var claimSecret = []byte(os.Getenv("CLAIM_SECRET"))
+var checkInSecret = []byte(os.Getenv("CHECKIN_SECRET"))
+var kioskSecret = []byte(os.Getenv("KIOSK_SECRET"))
 
 // CreateAccessToken creates an access token with very short expiry time (15 min).
 // It is supposed to be sent via header during request to authenticate admin permissions.
@@ -78,3 +82,324 @@ func ValidateRefreshToken(tokenStr string) (*jwt.Token, error) {
 
 	return token, nil
 }
+
+// CreateClaimToken creates a token for a SlotClaim, which expires at the end of the claimed timeslot, so a timeslot
+// that is already running can still be claimed for its remainder.
+// It is supposed to be embedded into the claim link of a notification email, allowing the recipient to book the
+// timeslot without having to enter their information again.
+func CreateClaimToken(claim SlotClaim) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":   claim.Email,
+		"start": claim.Start.Unix(),
+		"end":   claim.End.Unix(),
+		"exp":   claim.End.Unix(),
+		"iat":   time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(claimSecret)
+}
+
+// ValidateClaimToken validates a claim token and extracts the contained SlotClaim.
+func ValidateClaimToken(tokenStr string) (*SlotClaim, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return claimSecret, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid claim token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("invalid claim token")
+	}
+
+	email, err := claims.GetSubject()
+	if err != nil || email == "" {
+		return nil, fmt.Errorf("invalid claim token")
+	}
+	// JSON numbers are always decoded as float64
+	start, okStart := claims["start"].(float64)
+	end, okEnd := claims["end"].(float64)
+	if !okStart || !okEnd {
+		return nil, fmt.Errorf("invalid claim token")
+	}
+
+	return &SlotClaim{
+		Email: email,
+		Start: time.Unix(int64(start), 0).UTC(),
+		End:   time.Unix(int64(end), 0).UTC(),
+	}, nil
+}
+
+// CreateCheckInToken creates a token for checking in to the entry with the given id, which expires at the given time,
+// usually the end of the entry. It is supposed to be embedded into the check-in link of an entry.
+func CreateCheckInToken(entryId int, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"sub": strconv.Itoa(entryId),
+		"exp": expiresAt.Unix(),
+		"iat": time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(checkInSecret)
+}
+
+// ValidateCheckInToken validates a check-in token and extracts the contained entry id.
+func ValidateCheckInToken(tokenStr string) (int, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return checkInSecret, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !token.Valid {
+		return 0, fmt.Errorf("invalid check-in token")
+	}
+
+	subject, err := token.Claims.GetSubject()
+	if err != nil {
+		return 0, fmt.Errorf("invalid check-in token")
+	}
+
+	entryId, err := strconv.Atoi(subject)
+	if err != nil {
+		return 0, fmt.Errorf("invalid check-in token")
+	}
+
+	return entryId, nil
+}
+
+// CreateKioskToken creates a read-only token for the kiosk with the given name, which expires after a year.
+// It is supposed to be embedded into the URL of the kiosk screen, as it has to run unattended without logging in.
+func CreateKioskToken(name string) (string, error) {
+	claims := jwt.MapClaims{
+		"sub": name,
+		"exp": time.Now().AddDate(1, 0, 0).Unix(),
+		"iat": time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(kioskSecret)
+}
+
+// ValidateKioskToken validates a kiosk token and extracts the contained kiosk name.
+func ValidateKioskToken(tokenStr string) (string, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return kioskSecret, nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if !token.Valid {
+		return "", fmt.Errorf("invalid kiosk token")
+	}
+
+	name, err := token.Claims.GetSubject()
+	if err != nil || name == "" {
+		return "", fmt.Errorf("invalid kiosk token")
+	}
+
+	return name, nil
+}
+
+// CreateWaitlistOfferToken creates a token for claiming the timeslot offered to the waitlist entry with the given id,
+// which expires together with the offer. It is signed like the claim tokens, but can't be confused with them.
+func CreateWaitlistOfferToken(waitlistId int, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"sub": strconv.Itoa(waitlistId),
+		"typ": "waitlist",
+		"exp": expiresAt.Unix(),
+		"iat": time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(claimSecret)
+}
+
+// ValidateWaitlistOfferToken validates a waitlist offer token and extracts the contained waitlist entry id.
+func ValidateWaitlistOfferToken(tokenStr string) (int, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return claimSecret, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !token.Valid {
+		return 0, fmt.Errorf("invalid waitlist offer token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != "waitlist" {
+		return 0, fmt.Errorf("invalid waitlist offer token")
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil {
+		return 0, fmt.Errorf("invalid waitlist offer token")
+	}
+
+	waitlistId, err := strconv.Atoi(subject)
+	if err != nil {
+		return 0, fmt.Errorf("invalid waitlist offer token")
+	}
+
+	return waitlistId, nil
+}
+
+// CreateTransferToken creates a token for the owner of an entry to confirm or decline the transfer proposal with the
+// given id, which expires once the entry starts. It is signed like the claim tokens, but can't be confused with them.
+func CreateTransferToken(proposalId int, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"sub": strconv.Itoa(proposalId),
+		"typ": "transfer",
+		"exp": expiresAt.Unix(),
+		"iat": time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(claimSecret)
+}
+
+// ValidateTransferToken validates a transfer token and extracts the contained transfer proposal id.
+func ValidateTransferToken(tokenStr string) (int, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return claimSecret, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !token.Valid {
+		return 0, fmt.Errorf("invalid transfer token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != "transfer" {
+		return 0, fmt.Errorf("invalid transfer token")
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil {
+		return 0, fmt.Errorf("invalid transfer token")
+	}
+
+	proposalId, err := strconv.Atoi(subject)
+	if err != nil {
+		return 0, fmt.Errorf("invalid transfer token")
+	}
+
+	return proposalId, nil
+}
+
+// CreateGroupToken creates a token for accessing the group with the given id in the given role, i.e., either "invite"
+// for members claiming its entries or "coordinator" for the group contact, which expires once the group block ends.
+// It is signed like the claim tokens, but can't be confused with them or a token of another role.
+func CreateGroupToken(groupId int, role string, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"sub": strconv.Itoa(groupId),
+		"typ": "group-" + role,
+		"exp": expiresAt.Unix(),
+		"iat": time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(claimSecret)
+}
+
+// ValidateGroupToken validates a group token of the given role and extracts the contained group id.
+func ValidateGroupToken(tokenStr, role string) (int, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return claimSecret, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !token.Valid {
+		return 0, fmt.Errorf("invalid group token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != "group-"+role {
+		return 0, fmt.Errorf("invalid group token")
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil {
+		return 0, fmt.Errorf("invalid group token")
+	}
+
+	groupId, err := strconv.Atoi(subject)
+	if err != nil {
+		return 0, fmt.Errorf("invalid group token")
+	}
+
+	return groupId, nil
+}
+
+// CreateStreamToken creates a token for subscribing to the event stream with admin permissions, which expires after
+// a minute. As EventSource can't send headers, it is embedded into the URL, so it must neither outlive its use nor
+// grant anything else. It is signed like the claim tokens, but can't be confused with them.
+func CreateStreamToken() (string, error) {
+	claims := jwt.MapClaims{
+		"typ": "stream",
+		"exp": time.Now().Add(time.Minute).Unix(),
+		"iat": time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(claimSecret)
+}
+
+// ValidateStreamToken validates a stream token.
+func ValidateStreamToken(tokenStr string) error {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return claimSecret, nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !token.Valid || !ok || claims["typ"] != "stream" {
+		return fmt.Errorf("invalid stream token")
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@
  */
 
 import { AxiosError } from "axios";
+import { type TFunction } from "i18next";
 import {
     createContext,
     type PropsWithChildren,
@@ -15,15 +16,51 @@ import {
 import { useTranslation } from "react-i18next";
 
 import { useApi } from "@/api/ApiProvider";
+import { useAuth } from "@/api/AuthProvider";
 import { useToast } from "@/components/Toast/ToastProvider";
 import type {
     ApiData,
     CalendarEntryDto,
     CalendarEntryExtDto,
+    ConflictDto,
     ContextAction,
+    DisplacementDto,
+    GroupDetailsDto,
+    GroupDto,
+    IntervalDto,
+    ProblemDto,
     Series,
+    SeriesResponseDto,
 } from "@/types";
 import { startOfWeek } from "@/util/date";
+import { problemMessage } from "@/util/problem";
+
+/**
+ * Formats an interval of the backend, e.g. "02.11.2026 10:00-11:00".
+ */
+function formatInterval({ Start, End }: IntervalDto): string {
+    const [year, month, day] = Start.slice(0, 10).split("-");
+    return `${day}.${month}.${year} ${Start.slice(11, 16)}-${End.slice(11, 16)}`;
+}
+
+/**
+ * Lists the conflicting intervals of a request together with their free alternatives, if the backend
+ * explained the conflict.
+ */
+function describeConflicts(conflicts: ConflictDto[] | undefined, t: TFunction): string {
+    if (conflicts == null || conflicts.length === 0) {
+        return "";
+    }
+    const described = conflicts.map(({ Requested, Alternative }) =>
+        Alternative != null
+            ? t("calendar.context.conflict-alternative", {
+                  requested: formatInterval(Requested),
+                  alternative: formatInterval(Alternative),
+              })
+            : formatInterval(Requested),
+    );
+    return `: ${described.join(", ")}`;
+}
 
 enum CalendarEntryActions {
     GET_START = "GET_START",
@@ -32,6 +69,8 @@ enum CalendarEntryActions {
     POST_SERIES_SUCCESS = "POST_SERIES_SUCCESS",
     DELETE_SUCCESS = "DELETE_SUCCESS",
     DELETE_SERIES_SUCCESS = "DELETE_SERIES_SUCCESS",
+    EVENT_UPSERT = "EVENT_UPSERT",
+    EVENT_DELETE = "EVENT_DELETE",
     QUERY_ERROR = "QUERY_ERROR",
     CLEAR_ERROR = "CLEAR_ERROR",
 }
@@ -108,12 +147,16 @@ function calendarEntryReducer(
             if (state.data == null) {
                 return state;
             }
-            const { id: seriesId } = action.params!;
+            const { id: seriesId, all } = action.params!;
+            // Participants can't cancel past occurrences, so those remain
+            const now = new Date();
 
             const newStateData = { ...state.data };
             Object.entries(state.data).forEach(([date, part]) => {
                 newStateData[date] = Object.fromEntries(
-                    Object.entries(part).filter(([, dto]) => dto.SeriesId != seriesId),
+                    Object.entries(part).filter(
+                        ([, dto]) => dto.SeriesId != seriesId || (!all && dto.startDate <= now),
+                    ),
                 );
             });
 
@@ -123,6 +166,32 @@ function calendarEntryReducer(
                 error: undefined,
             };
         }
+        case CalendarEntryActions.EVENT_UPSERT: {
+            const { date, overwrite } = action.params!;
+            const data = action.payload as CalendarEntryExtDto;
+            // Only weeks that were already queried are kept up to date, the others are queried anyway
+            if (state.data?.[date] == null) {
+                return state;
+            }
+            // Our own new entries are already known with all their information, which must not be
+            // replaced by the public information of the event
+            if (!overwrite && state.data[date][data.Id] != null) {
+                return state;
+            }
+            return {
+                ...state,
+                data: { ...state.data, [date]: { ...state.data[date], [data.Id]: data } },
+            };
+        }
+        case CalendarEntryActions.EVENT_DELETE: {
+            const { id, date } = action.params!;
+            if (state.data?.[date] == null) {
+                return state;
+            }
+            const newState = { ...state.data[date] };
+            delete newState[id];
+            return { ...state, data: { ...state.data, [date]: newState } };
+        }
         case CalendarEntryActions.QUERY_ERROR:
             return {
                 ...state,
@@ -139,10 +208,30 @@ function calendarEntryReducer(
 type CalendarEntryContextType = {
     state: CalendarEntryState;
     getAllCalendarEntries: (date: string) => Promise<void>;
-    postCalendarEntry: (entry: CalendarEntryDto, date: string) => Promise<boolean>;
-    postCalendarSeries: (entry: CalendarEntryDto, series: Series) => Promise<boolean>;
-    deleteCalendarEntry: (id: number, email: string, date: string) => Promise<void>;
-    deleteCalendarSeries: (id: number, email: string) => Promise<void>;
+    postCalendarEntry: (
+        entry: CalendarEntryDto,
+        date: string,
+        override?: boolean,
+    ) => Promise<boolean>;
+    postCalendarSeries: (
+        entry: CalendarEntryDto,
+        series: Series,
+        override?: boolean,
+        skipConflicts?: boolean,
+    ) => Promise<boolean>;
+    previewCalendarOverride: (
+        entry: CalendarEntryDto,
+        series?: Series,
+    ) => Promise<DisplacementDto[] | undefined>;
+    postCalendarGroup: (group: GroupDto) => Promise<boolean>;
+    deleteCalendarEntry: (
+        id: number,
+        email: string,
+        date: string,
+        reason?: string,
+    ) => Promise<void>;
+    deleteCalendarSeries: (id: number, email: string, reason?: string) => Promise<void>;
+    subscribeCalendarEvents: (date: string) => () => void;
     clearError: () => void;
 };
 
@@ -157,6 +246,9 @@ const initialState: CalendarEntryState = {
 export function CalendarEntryProvider({ children }: PropsWithChildren) {
     const [state, dispatch] = useReducer(calendarEntryReducer, initialState);
     const api = useApi();
+    const {
+        state: { data: token },
+    } = useAuth();
     const { showToast } = useToast();
     const { t } = useTranslation();
 
@@ -183,10 +275,12 @@ export function CalendarEntryProvider({ children }: PropsWithChildren) {
     );
 
     const postCalendarEntry = useCallback(
-        async (entry: CalendarEntryDto, date: string) => {
+        async (entry: CalendarEntryDto, date: string, override?: boolean) => {
             try {
+                // Overriding admin events displace the entries of participants instead of conflicting
+                const params = override ? { override: "confirm" } : undefined;
                 const data = await api
-                    .post<CalendarEntryDto>("/calendar/entries", entry)
+                    .post<CalendarEntryDto>("/calendar/entries", entry, { params })
                     .then((res) => res.data);
                 dispatch({
                     type: CalendarEntryActions.POST_SUCCESS,
@@ -197,8 +291,22 @@ export function CalendarEntryProvider({ children }: PropsWithChildren) {
                 return true;
             } catch (err) {
                 dispatch({ type: CalendarEntryActions.QUERY_ERROR, error: err });
-                if (err instanceof AxiosError && err.status === 409) {
-                    showToast("error", t("calendar.context.error-postCalendarEntry-conflict"));
+                const problem = (err as AxiosError<ProblemDto>).response?.data;
+                // Besides overlaps, e.g. a series without any free occurrence explains its conflicts as well
+                if (err instanceof AxiosError && problem?.conflicts?.length) {
+                    showToast(
+                        "error",
+                        t("calendar.context.error-postCalendarEntry-conflict") +
+                            describeConflicts(problem.conflicts, t),
+                    );
+                } else if (
+                    err instanceof AxiosError &&
+                    (err.status === 400 || err.status === 409)
+                ) {
+                    showToast(
+                        "error",
+                        `${t("calendar.context.error-postCalendarEntry")}: ${problemMessage(err, t)}`,
+                    );
                 } else {
                     showToast("error", t("calendar.context.error-postCalendarEntry"));
                 }
@@ -209,14 +317,105 @@ export function CalendarEntryProvider({ children }: PropsWithChildren) {
     );
 
     const postCalendarSeries = useCallback(
-        async (entry: CalendarEntryDto, series: Series) => {
+        async (
+            entry: CalendarEntryDto,
+            series: Series,
+            override?: boolean,
+            skipConflicts?: boolean,
+        ) => {
+            try {
+                let params: Record<string, string> | undefined = undefined;
+                if (override) {
+                    params = { override: "confirm" };
+                } else if (skipConflicts) {
+                    // The occupied occurrences are skipped instead of failing the whole series
+                    params = { skipConflicts: "true" };
+                }
+                const res = await api.post<CalendarEntryDto[] | SeriesResponseDto>(
+                    "/calendar/series",
+                    { Entry: entry, Series: series },
+                    { params },
+                );
+                const data = Array.isArray(res.data) ? res.data : res.data.Booked;
+                const skipped = Array.isArray(res.data) ? [] : res.data.Skipped;
+                dispatch({
+                    type: CalendarEntryActions.POST_SERIES_SUCCESS,
+                    payload: data.map((dto) => {
+                        const extDto = mapDtoToExtDto(dto);
+                        return [
+                            startOfWeek(extDto.startDate).toISOString().split("T")[0],
+                            extDto,
+                        ] as [string, CalendarEntryExtDto];
+                    }),
+                });
+                if (skipped.length > 0) {
+                    showToast(
+                        "info",
+                        t("calendar.context.success-postCalendarSeries-skipped") +
+                            describeConflicts(skipped, t),
+                    );
+                } else {
+                    showToast("success", t("calendar.context.success-postCalendarSeries"), 5000);
+                }
+                return true;
+            } catch (err) {
+                dispatch({ type: CalendarEntryActions.QUERY_ERROR, error: err });
+                const problem = (err as AxiosError<ProblemDto>).response?.data;
+                // Besides overlaps, e.g. a series without any free occurrence explains its conflicts as well
+                if (err instanceof AxiosError && problem?.conflicts?.length) {
+                    showToast(
+                        "error",
+                        t("calendar.context.error-postCalendarSeries-conflict") +
+                            describeConflicts(problem.conflicts, t),
+                    );
+                } else if (
+                    err instanceof AxiosError &&
+                    (err.status === 400 || err.status === 409)
+                ) {
+                    showToast(
+                        "error",
+                        `${t("calendar.context.error-postCalendarSeries")}: ${problemMessage(err, t)}`,
+                    );
+                } else {
+                    showToast("error", t("calendar.context.error-postCalendarSeries"));
+                }
+                return false;
+            }
+        },
+        [api, showToast, t],
+    );
+
+    const previewCalendarOverride = useCallback(
+        async (entry: CalendarEntryDto, series?: Series) => {
+            try {
+                const params = { override: "preview" };
+                const res =
+                    series != null
+                        ? await api.post<DisplacementDto[]>(
+                              "/calendar/series",
+                              { Entry: entry, Series: series },
+                              { params },
+                          )
+                        : await api.post<DisplacementDto[]>("/calendar/entries", entry, { params });
+                return res.data;
+            } catch {
+                showToast("error", t("calendar.context.error-previewCalendarOverride"));
+                return undefined;
+            }
+        },
+        [api, showToast, t],
+    );
+
+    const postCalendarGroup = useCallback(
+        async (group: GroupDto) => {
             try {
                 const data = await api
-                    .post<CalendarEntryDto[]>("/calendar/series", { Entry: entry, Series: series })
+                    .post<GroupDetailsDto>("/calendar/groups", group)
                     .then((res) => res.data);
+                // The entries of a group are added the same way as those of a series
                 dispatch({
                     type: CalendarEntryActions.POST_SERIES_SUCCESS,
-                    payload: data.map((dto) => {
+                    payload: data.Entries.map((dto) => {
                         const extDto = mapDtoToExtDto(dto);
                         return [
                             startOfWeek(extDto.startDate).toISOString().split("T")[0],
@@ -224,14 +423,19 @@ export function CalendarEntryProvider({ children }: PropsWithChildren) {
                         ] as [string, CalendarEntryExtDto];
                     }),
                 });
-                showToast("success", t("calendar.context.success-postCalendarSeries"), 5000);
+                showToast("success", t("calendar.context.success-postCalendarGroup"), 5000);
                 return true;
             } catch (err) {
                 dispatch({ type: CalendarEntryActions.QUERY_ERROR, error: err });
                 if (err instanceof AxiosError && err.status === 409) {
-                    showToast("error", t("calendar.context.error-postCalendarSeries-conflict"));
+                    showToast("error", t("calendar.context.error-postCalendarGroup-conflict"));
+                } else if (err instanceof AxiosError && err.status === 400) {
+                    showToast(
+                        "error",
+                        `${t("calendar.context.error-postCalendarGroup")}: ${problemMessage(err, t)}`,
+                    );
                 } else {
-                    showToast("error", t("calendar.context.error-postCalendarSeries"));
+                    showToast("error", t("calendar.context.error-postCalendarGroup"));
                 }
                 return false;
             }
@@ -240,10 +444,13 @@ export function CalendarEntryProvider({ children }: PropsWithChildren) {
     );
 
     const deleteCalendarEntry = useCallback(
-        async (id: number, email: string, date: string) => {
+        async (id: number, email: string, date: string, reason?: string) => {
             try {
+                // A reason is only required for cancellations on short notice
                 await api
-                    .delete(`/calendar/entries/${id}`, { params: { email } })
+                    .delete(`/calendar/entries/${id}`, {
+                        params: { email, reason: reason || undefined },
+                    })
                     .then((res) => res.data);
                 dispatch({
                     type: CalendarEntryActions.DELETE_SUCCESS,
@@ -252,29 +459,107 @@ export function CalendarEntryProvider({ children }: PropsWithChildren) {
                 showToast("success", t("calendar.context.success-deleteCalendarEntry"), 5000);
             } catch (err) {
                 dispatch({ type: CalendarEntryActions.QUERY_ERROR, error: err });
-                showToast("error", t("calendar.context.error-deleteCalendarEntry"));
+                if (
+                    err instanceof AxiosError &&
+                    (err.status === 400 || err.status === 403)
+                ) {
+                    showToast(
+                        "error",
+                        `${t("calendar.context.error-deleteCalendarEntry")}: ${problemMessage(err, t)}`,
+                    );
+                } else {
+                    showToast("error", t("calendar.context.error-deleteCalendarEntry"));
+                }
             }
         },
         [api, showToast, t],
     );
 
     const deleteCalendarSeries = useCallback(
-        async (id: number, email: string) => {
+        async (id: number, email: string, reason?: string) => {
             try {
                 await api
-                    .delete(`/calendar/series/${id}`, { params: { email } })
+                    .delete(`/calendar/series/${id}`, {
+                        params: { email, reason: reason || undefined },
+                    })
                     .then((res) => res.data);
                 dispatch({
                     type: CalendarEntryActions.DELETE_SERIES_SUCCESS,
-                    params: { id },
+                    params: { id, all: token != null },
                 });
                 showToast("success", t("calendar.context.success-deleteCalendarSeries"), 5000);
             } catch (err) {
                 dispatch({ type: CalendarEntryActions.QUERY_ERROR, error: err });
-                showToast("error", t("calendar.context.error-deleteCalendarSeries"));
+                if (
+                    err instanceof AxiosError &&
+                    (err.status === 400 || err.status === 403)
+                ) {
+                    showToast(
+                        "error",
+                        `${t("calendar.context.error-deleteCalendarSeries")}: ${problemMessage(err, t)}`,
+                    );
+                } else {
+                    showToast("error", t("calendar.context.error-deleteCalendarSeries"));
+                }
             }
         },
-        [api, showToast, t],
+        [api, showToast, t, token],
+    );
+
+    // Keeps the given week up to date with the bookings of others, returning the unsubscribe function
+    const subscribeCalendarEvents = useCallback(
+        (date: string) => {
+            let source: EventSource | undefined;
+            let closed = false;
+
+            const connect = async () => {
+                const params = new URLSearchParams({ start: date });
+                // EventSource can't send headers, so a short-lived stream token is part of the URL
+                // instead of the access token
+                if (token) {
+                    try {
+                        const response = await api.post<string>("/admin/stream-token");
+                        params.set("token", response.data);
+                    } catch {
+                        // Without a stream token, the public stream has to suffice
+                    }
+                }
+                if (closed) {
+                    return;
+                }
+
+                source = new EventSource(
+                    `${import.meta.env.VITE_API_BASE_URL}/calendar/events?${params}`,
+                );
+                const upsert = (overwrite: boolean) => (e: MessageEvent<string>) =>
+                    dispatch({
+                        type: CalendarEntryActions.EVENT_UPSERT,
+                        payload: mapDtoToExtDto(JSON.parse(e.data)),
+                        params: { date, overwrite },
+                    });
+                source.addEventListener("created", upsert(false));
+                source.addEventListener("updated", upsert(true));
+                source.addEventListener("deleted", (e: MessageEvent<string>) =>
+                    dispatch({
+                        type: CalendarEntryActions.EVENT_DELETE,
+                        params: { id: (JSON.parse(e.data) as CalendarEntryDto).Id, date },
+                    }),
+                );
+                // The stream token expires quickly, so a rejected reconnect needs a new one
+                source.addEventListener("error", () => {
+                    if (source?.readyState === EventSource.CLOSED && !closed) {
+                        setTimeout(connect, 5000);
+                    }
+                });
+            };
+            connect();
+
+            return () => {
+                closed = true;
+                source?.close();
+            };
+        },
+        [api, token],
     );
 
     const clearError = useCallback(() => dispatch({ type: CalendarEntryActions.CLEAR_ERROR }), []);
@@ -285,8 +570,11 @@ export function CalendarEntryProvider({ children }: PropsWithChildren) {
             getAllCalendarEntries,
             postCalendarEntry,
             postCalendarSeries,
+            previewCalendarOverride,
+            postCalendarGroup,
             deleteCalendarEntry,
             deleteCalendarSeries,
+            subscribeCalendarEvents,
             clearError,
         }),
         [
@@ -294,8 +582,11 @@ export function CalendarEntryProvider({ children }: PropsWithChildren) {
             getAllCalendarEntries,
             postCalendarEntry,
             postCalendarSeries,
+            previewCalendarOverride,
+            postCalendarGroup,
             deleteCalendarEntry,
             deleteCalendarSeries,
+            subscribeCalendarEvents,
             clearError,
         ],
     );
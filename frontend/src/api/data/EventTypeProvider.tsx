@@ -0,0 +1,69 @@
+/**
+ * This context handles the event types of the admin events fetched from the backend, which define
+ * how those are presented, and provides functionality to access them.
+ */
+
+import {
+    createContext,
+    type PropsWithChildren,
+    useCallback,
+    useContext,
+    useEffect,
+    useMemo,
+    useState,
+} from "react";
+import { useTranslation } from "react-i18next";
+
+import { useApi } from "@/api/ApiProvider";
+import { useToast } from "@/components/Toast/ToastProvider";
+import type { EventTypeDto } from "@/types";
+
+type EventTypeContextType = {
+    eventTypes: EventTypeDto[];
+    getEventType: (id?: number) => EventTypeDto | undefined;
+    getEventTypeName: (id?: number) => string;
+};
+
+const EventTypeContext = createContext<EventTypeContextType | undefined>(undefined);
+
+export function EventTypeProvider({ children }: PropsWithChildren) {
+    const [eventTypes, setEventTypes] = useState<EventTypeDto[]>([]);
+    const api = useApi();
+    const { showToast } = useToast();
+    const { t, i18n } = useTranslation();
+
+    // The event types rarely change, so fetching them once suffices
+    useEffect(() => {
+        api.get<EventTypeDto[]>("/calendar/event-types")
+            .then((res) => setEventTypes(res.data))
+            .catch(() => showToast("error", t("calendar.context.error-getEventTypes")));
+    }, [api, showToast, t]);
+
+    const getEventType = useCallback(
+        (id?: number) => eventTypes.find((eventType) => eventType.Id === id),
+        [eventTypes],
+    );
+
+    const getEventTypeName = useCallback(
+        (id?: number) => {
+            const eventType = getEventType(id);
+            return eventType?.Translations[i18n.language] || eventType?.Name || "";
+        },
+        [getEventType, i18n.language],
+    );
+
+    const value = useMemo(
+        () => ({ eventTypes, getEventType, getEventTypeName }),
+        [eventTypes, getEventType, getEventTypeName],
+    );
+
+    return <EventTypeContext.Provider value={value}>{children}</EventTypeContext.Provider>;
+}
+
+export function useEventTypes() {
+    const context = useContext(EventTypeContext);
+    if (!context) {
+        throw new Error("useEventTypes must be used within an EventTypeProvider");
+    }
+    return context;
+}
@@ -1,8 +1,9 @@
 import type { PropsWithChildren } from "react";
 
 import { CalendarEntryProvider } from "@/api/data/CalendarEntryProvider";
+import { EventTypeProvider } from "@/api/data/EventTypeProvider";
 
-const providers = [CalendarEntryProvider];
+const providers = [EventTypeProvider, CalendarEntryProvider];
 
 export function DataProviders({ children }: PropsWithChildren) {
     return providers.reduceRight((acc, Provider) => <Provider>{acc}</Provider>, children);
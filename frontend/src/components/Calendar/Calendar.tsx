@@ -2,6 +2,7 @@ import { AnimatePresence, motion } from "framer-motion";
 import { ArrowLeft, ArrowRight, Plus } from "lucide-react";
 import { useEffect, useMemo, useRef, useState } from "react";
 import { useTranslation } from "react-i18next";
+import { useSearchParams } from "react-router-dom";
 
 import { useApiCalendarEntry } from "@/api/data/CalendarEntryProvider";
 import CalendarSlotNew from "@/components/Calendar/CalendarSlotNew";
@@ -31,6 +32,14 @@ const variants = {
     }),
 };
 
+/**
+ * Formats the time of a displaced entry for the confirmation of an override, e.g. "04.11.2026 10:00-11:00".
+ */
+function formatDisplacedTime(entry: CalendarEntryDto): string {
+    const [year, month, day] = entry.Start.slice(0, 10).split("-");
+    return `${day}.${month}.${year} ${entry.Start.slice(11, 16)}-${entry.End.slice(11, 16)}`;
+}
+
 /**
  * This component provides a calendar spanning a week. It allows for both the creation and
  * viewing of calendar entries.
@@ -38,18 +47,36 @@ const variants = {
  * Due to the requirements, 1 hour slots are both sufficient for the domain and ideal for UI purposes.
  */
 export default function WeeklyCalendar() {
-    const [currentWeekStart, setCurrentWeekStart] = useState<Date>(startOfWeek(new Date()));
+    // Links, e.g. from emails, may point directly to a specific timeslot via "?date=YYYY-MM-DD&hour=H"
+    const [searchParams] = useSearchParams();
+    const linkedDate = searchParams.get("date");
+    const linkedHour = searchParams.get("hour");
+    const linkedDatetime =
+        linkedDate && linkedHour ? { date: linkedDate, time: Number(linkedHour) } : undefined;
+
+    const [currentWeekStart, setCurrentWeekStart] = useState<Date>(
+        startOfWeek(linkedDate ? new Date(linkedDate) : new Date()),
+    );
     const [direction, setDirection] = useState(0); // 1 = next, -1 = prev
-    const [newEntryModal, setNewEntryModal] = useState(false);
-    const [newEntryDatetime, setNewEntryDatetime] = useState<{ date: string; time: number }>();
+    const [newEntryModal, setNewEntryModal] = useState(linkedDatetime != null);
+    const [newEntryDatetime, setNewEntryDatetime] = useState<{ date: string; time: number }>(
+        linkedDatetime,
+    );
     const tableRef = useRef<HTMLDivElement>(null);
     const tableScrollRef = useRef<{ scrollTop: number; scrollLeft: number }>({
         scrollTop: 0,
         scrollLeft: 0,
     });
 
-    const { state, getAllCalendarEntries, postCalendarEntry, postCalendarSeries } =
-        useApiCalendarEntry();
+    const {
+        state,
+        getAllCalendarEntries,
+        postCalendarEntry,
+        postCalendarSeries,
+        previewCalendarOverride,
+        postCalendarGroup,
+        subscribeCalendarEvents,
+    } = useApiCalendarEntry();
     const { showLoading, hideLoading } = useLoading();
     const { t } = useTranslation();
 
@@ -68,16 +95,59 @@ export default function WeeklyCalendar() {
         setCurrentWeekStart(addDays(currentWeekStart, -7));
     };
 
-    const onSubmitNew = async (entry: CalendarEntryDto, series?: Series) => {
+    const onSubmitNew = async (
+        entry: CalendarEntryDto,
+        series?: Series,
+        groupName?: string,
+        skipConflicts?: boolean,
+    ) => {
+        if (groupName != null) {
+            const group = {
+                Name: groupName,
+                FirstName: entry.FirstName,
+                LastName: entry.LastName ?? "",
+                Email: entry.Email ?? "",
+                Start: entry.Start,
+                End: entry.End,
+            };
+            if (await postCalendarGroup(group)) {
+                setNewEntryModal(false);
+                return true;
+            }
+            return false;
+        }
+
+        // Admin events displace the entries of participants, after the admin confirmed who is affected
+        const override = entry.EventTypeId != null;
+        if (override) {
+            const displacements = await previewCalendarOverride(entry, series);
+            if (displacements == null) {
+                return false;
+            }
+            if (displacements.length > 0) {
+                const affected = displacements
+                    .map(
+                        ({ Entry }) =>
+                            `${Entry.FirstName} ${Entry.LastName ?? ""}: ${formatDisplacedTime(Entry)}`,
+                    )
+                    .join("\n");
+                if (!window.confirm(t("calendar.override.confirm", { affected }))) {
+                    return false;
+                }
+            }
+        }
+
         if (series != null) {
-            if (await postCalendarSeries(entry, series)) {
+            if (await postCalendarSeries(entry, series, override, skipConflicts)) {
                 setNewEntryModal(false);
                 return true;
             }
             return false;
         }
 
-        if (await postCalendarEntry(entry, currentWeekStart.toISOString().split("T")[0])) {
+        if (
+            await postCalendarEntry(entry, currentWeekStart.toISOString().split("T")[0], override)
+        ) {
             setNewEntryModal(false);
             return true;
         }
@@ -95,6 +165,12 @@ export default function WeeklyCalendar() {
         }
     }, [currentWeekStart, getAllCalendarEntries, hideLoading, showLoading, state]);
 
+    // Bookings of others are received live, so the shown week doesn't go stale
+    useEffect(
+        () => subscribeCalendarEvents(currentWeekStart.toISOString().split("T")[0]),
+        [currentWeekStart, subscribeCalendarEvents],
+    );
+
     // When switching weeks, apply the latest scroll position, to keep the screen/table at the same position
     // Otherwise, this leads to an irritating user experience
     useEffect(() => {
@@ -1,10 +1,15 @@
 import { AnimatePresence, motion } from "framer-motion";
-import { Trash, X } from "lucide-react";
-import { useState } from "react";
+import { ArrowRightLeft, Trash, X } from "lucide-react";
+import { type FormEvent, useEffect, useState } from "react";
 import { useTranslation } from "react-i18next";
 
+import { useApi } from "@/api/ApiProvider";
 import { useAuth } from "@/api/AuthProvider";
-import type { CalendarEntryExtDto } from "@/types";
+import { useEventTypes } from "@/api/data/EventTypeProvider";
+import { useLoading } from "@/components/LoadingProvider";
+import { useToast } from "@/components/Toast/ToastProvider";
+import type { CalendarEntryExtDto, TransferDto } from "@/types";
+import { problemMessage } from "@/util/problem";
 
 function formatIsoDateString(isoDate: string): string {
     const parts = isoDate.split("T")[0].split("-");
@@ -14,20 +19,68 @@ function formatIsoDateString(isoDate: string): string {
 type CalendarSlotDetailsProps = {
     onClose: () => void;
     event?: CalendarEntryExtDto;
-    onDelete: (id: number, email: string, isSeries?: boolean) => void;
+    onDelete: (id: number, email: string, reason: string, isSeries?: boolean) => void;
 };
 
 /**
  * This component shows a modal with full information for a calendar entry, more so if admin, in
- * addition to the option to delete it.
+ * addition to the option to delete it or to seek a replacement for it.
  */
 function CalendarSlotDetails({ onClose, event, onDelete }: CalendarSlotDetailsProps) {
     const [inputValue, setInputValue] = useState("");
+    const [reason, setReason] = useState("");
+    const [transfer, setTransfer] = useState<TransferDto>();
 
     const {
         state: { data: isAdmin },
     } = useAuth();
+    const { getEventTypeName } = useEventTypes();
     const { t } = useTranslation();
+    const api = useApi();
+    const { showToast } = useToast();
+    const { showLoading, hideLoading } = useLoading();
+
+    const isFuture = !!event && event.startDate.getTime() > new Date().getTime();
+
+    // Whether the entry seeks a replacement is only relevant for the opened entry
+    useEffect(() => {
+        setTransfer(undefined);
+        if (!event || event.EventTypeId || !isFuture) {
+            return;
+        }
+
+        api.get<TransferDto>(`/calendar/entries/${event.Id}/transfer`)
+            .then((res) => setTransfer(res.data))
+            .catch(() => setTransfer(undefined));
+    }, [event]);
+
+    const toggleTransfer = async () => {
+        if (!event) {
+            return;
+        }
+
+        showLoading();
+        try {
+            if (transfer) {
+                await api.delete(`/calendar/entries/${event.Id}/transfer`, {
+                    params: { email: inputValue },
+                });
+                setTransfer(undefined);
+                showToast("success", t("calendar.transfer.success-delete"), 5000);
+            } else {
+                const res = await api.post<TransferDto>(
+                    `/calendar/entries/${event.Id}/transfer`,
+                    undefined,
+                    { params: { email: inputValue } },
+                );
+                setTransfer(res.data);
+                showToast("success", t("calendar.transfer.success-post"), 5000);
+            }
+        } catch (error) {
+            showToast("error", `${t("calendar.transfer.error-post")}: ${problemMessage(error, t)}`);
+        }
+        hideLoading();
+    };
 
     return (
         <AnimatePresence>
@@ -64,25 +117,39 @@ function CalendarSlotDetails({ onClose, event, onDelete }: CalendarSlotDetailsPr
                             </div>
                         </h2>
 
-                        {event.SeriesId && !event.AdminEvent && (
+                        {event.SeriesId && !event.EventTypeId && (
                             <div className="mb-2">{t("calendar.page.part-of-series")}</div>
                         )}
 
+                        {event.GroupName && (
+                            <div className="mb-2">
+                                {t("calendar.page.part-of-group", { name: event.GroupName })}
+                            </div>
+                        )}
+
+                        {transfer && (
+                            <div className="mb-2 inline-block bg-yellow-200 px-2 py-1 text-sm">
+                                {t("calendar.transfer.seeking")}
+                            </div>
+                        )}
+
                         <div className="text-gray-700">
                             <div>
-                                {event.AdminEvent
-                                    ? t(`calendar.modal-new.admin-event-${event.AdminEvent}`)
-                                    : `${event.FirstName} ${event.LastName ?? ""}`}
+                                {event.EventTypeId
+                                    ? getEventTypeName(event.EventTypeId)
+                                    : event.GroupName && !event.LastName
+                                      ? event.GroupName
+                                      : `${event.FirstName} ${event.LastName ?? ""}`}
                             </div>
                             {event.Email && <div>{event.Email}</div>}
                         </div>
 
                         {event.endDate.getTime() < new Date().getTime() || // don't show delete option for past entries
                         // don't show delete option if it is an admin event entry and you are not admin
-                        (event.AdminEvent && !isAdmin) ? null : (
+                        (event.EventTypeId && !isAdmin) ? null : (
                             <div className="mt-4 flex flex-col gap-2 opacity-50 focus-within:opacity-100 hover:opacity-100">
                                 {/* Enter the email used for creating the entry */}
-                                {event.AdminEvent ? null : (
+                                {event.EventTypeId ? null : (
                                     <input
                                         type="text"
                                         value={inputValue}
@@ -91,10 +158,20 @@ function CalendarSlotDetails({ onClose, event, onDelete }: CalendarSlotDetailsPr
                                         placeholder={t("calendar.page.email-placeholder")}
                                     />
                                 )}
+                                {/* Cancellations on short notice have to be explained to the coordinators */}
+                                {event.EventTypeId || isAdmin ? null : (
+                                    <input
+                                        type="text"
+                                        value={reason}
+                                        onChange={(e) => setReason(e.target.value)}
+                                        className="flex-1 border border-gray-300 p-2 focus:ring-2 focus:ring-blue-500 focus:outline-none"
+                                        placeholder={t("calendar.page.reason-placeholder")}
+                                    />
+                                )}
                                 <div className="flex gap-2">
                                     {/* Delete the entry... */}
                                     <button
-                                        onClick={() => onDelete(event.Id, inputValue)}
+                                        onClick={() => onDelete(event.Id, inputValue, reason)}
                                         className="flex-1 cursor-pointer bg-red-500 px-4 py-2 text-white hover:bg-red-600 active:bg-red-700"
                                     >
                                         <Trash
@@ -104,11 +181,27 @@ function CalendarSlotDetails({ onClose, event, onDelete }: CalendarSlotDetailsPr
                                         />
                                         {t("calendar.page.delete")}
                                     </button>
+                                    {/* ...or seek a replacement, so the timeslot stays covered... */}
+                                    {!event.EventTypeId && isFuture && (
+                                        <button
+                                            onClick={toggleTransfer}
+                                            className="flex-1 cursor-pointer bg-yellow-500 px-4 py-2 text-white hover:bg-yellow-600 active:bg-yellow-700"
+                                        >
+                                            <ArrowRightLeft
+                                                className="mr-1 inline align-text-bottom"
+                                                height="20"
+                                                width="20"
+                                            />
+                                            {transfer
+                                                ? t("calendar.transfer.withdraw")
+                                                : t("calendar.transfer.offer")}
+                                        </button>
+                                    )}
                                     {/* ...or the entire series, if it is part of a series */}
                                     {event.SeriesId && (
                                         <button
                                             onClick={() =>
-                                                onDelete(event!.SeriesId!, inputValue, true)
+                                                onDelete(event!.SeriesId!, inputValue, reason, true)
                                             }
                                             className="flex-1 cursor-pointer bg-red-500 px-4 py-2 text-white hover:bg-red-600 active:bg-red-700"
                                         >
@@ -123,6 +216,17 @@ function CalendarSlotDetails({ onClose, event, onDelete }: CalendarSlotDetailsPr
                                 </div>
                             </div>
                         )}
+
+                        {/* Occupied future timeslots can be queued for, in case they are freed */}
+                        {/* An entry seeking a replacement can be taken over, otherwise one can queue */}
+                        {!isAdmin &&
+                            !event.EventTypeId &&
+                            isFuture &&
+                            (transfer ? (
+                                <TransferProposalInput transfer={transfer} />
+                            ) : (
+                                <WaitlistInput event={event} />
+                            ))}
                     </motion.div>
                 </motion.div>
             )}
@@ -130,4 +234,157 @@ function CalendarSlotDetails({ onClose, event, onDelete }: CalendarSlotDetailsPr
     );
 }
 
+/**
+ * This component consists of a small form for queueing on the waitlist of an occupied timeslot. Once
+ * the timeslot is freed, it is offered to the users on the waitlist in order.
+ */
+function WaitlistInput({ event }: { event: CalendarEntryExtDto }) {
+    const [firstName, setFirstName] = useState("");
+    const [lastName, setLastName] = useState("");
+    const [email, setEmail] = useState("");
+
+    const { t } = useTranslation();
+    const api = useApi();
+    const { showToast } = useToast();
+    const { showLoading, hideLoading } = useLoading();
+
+    const handleSubmit = async (e: FormEvent) => {
+        e.preventDefault();
+
+        showLoading();
+        try {
+            await api.post("/calendar/waitlist", {
+                FirstName: firstName,
+                LastName: lastName,
+                Email: email,
+                Start: event.Start,
+                End: event.End,
+            });
+            setFirstName("");
+            setLastName("");
+            setEmail("");
+            showToast("success", t("calendar.waitlist.success-post"), 5000);
+        } catch (error) {
+            showToast("error", `${t("calendar.waitlist.error-post")}: ${problemMessage(error, t)}`);
+        }
+        hideLoading();
+    };
+
+    return (
+        <form onSubmit={handleSubmit} className="mt-6 flex flex-col gap-2 border-t pt-4">
+            <h3 className="font-semibold">{t("calendar.waitlist.heading")}</h3>
+            <p className="text-sm text-gray-700">{t("calendar.waitlist.paragraph")}</p>
+            <div className="flex gap-2">
+                <input
+                    type="text"
+                    value={firstName}
+                    onChange={(e) => setFirstName(e.target.value)}
+                    className="w-full border border-gray-300 p-2 focus:ring-2 focus:ring-blue-500 focus:outline-none"
+                    placeholder={t("calendar.waitlist.firstname")}
+                    required
+                />
+                <input
+                    type="text"
+                    value={lastName}
+                    onChange={(e) => setLastName(e.target.value)}
+                    className="w-full border border-gray-300 p-2 focus:ring-2 focus:ring-blue-500 focus:outline-none"
+                    placeholder={t("calendar.waitlist.lastname")}
+                    required
+                />
+            </div>
+            <input
+                type="email"
+                value={email}
+                onChange={(e) => setEmail(e.target.value)}
+                className="border border-gray-300 p-2 focus:ring-2 focus:ring-blue-500 focus:outline-none"
+                placeholder={t("calendar.waitlist.email")}
+                required
+            />
+            <button
+                type="submit"
+                className="cursor-pointer bg-blue-500 px-4 py-2 text-white hover:bg-blue-600 active:bg-blue-700"
+            >
+                {t("calendar.waitlist.submit")}
+            </button>
+        </form>
+    );
+}
+
 export default CalendarSlotDetails;
+
+/**
+ * This component consists of a small form for proposing to take over an entry, whose owner seeks a
+ * replacement. The owner has to confirm the proposal, before the entry is actually transferred.
+ */
+function TransferProposalInput({ transfer }: { transfer: TransferDto }) {
+    const [firstName, setFirstName] = useState("");
+    const [lastName, setLastName] = useState("");
+    const [email, setEmail] = useState("");
+
+    const { t } = useTranslation();
+    const api = useApi();
+    const { showToast } = useToast();
+    const { showLoading, hideLoading } = useLoading();
+
+    const handleSubmit = async (e: FormEvent) => {
+        e.preventDefault();
+
+        showLoading();
+        try {
+            await api.post(`/calendar/transfers/${transfer.Id}/proposals`, {
+                FirstName: firstName,
+                LastName: lastName,
+                Email: email,
+            });
+            setFirstName("");
+            setLastName("");
+            setEmail("");
+            showToast("success", t("calendar.transfer.success-proposal"), 5000);
+        } catch (error) {
+            showToast(
+                "error",
+                `${t("calendar.transfer.error-proposal")}: ${problemMessage(error, t)}`,
+            );
+        }
+        hideLoading();
+    };
+
+    return (
+        <form onSubmit={handleSubmit} className="mt-6 flex flex-col gap-2 border-t pt-4">
+            <h3 className="font-semibold">{t("calendar.transfer.heading")}</h3>
+            <p className="text-sm text-gray-700">{t("calendar.transfer.paragraph")}</p>
+            <div className="flex gap-2">
+                <input
+                    type="text"
+                    value={firstName}
+                    onChange={(e) => setFirstName(e.target.value)}
+                    className="w-full border border-gray-300 p-2 focus:ring-2 focus:ring-blue-500 focus:outline-none"
+                    placeholder={t("calendar.waitlist.firstname")}
+                    required
+                />
+                <input
+                    type="text"
+                    value={lastName}
+                    onChange={(e) => setLastName(e.target.value)}
+                    className="w-full border border-gray-300 p-2 focus:ring-2 focus:ring-blue-500 focus:outline-none"
+                    placeholder={t("calendar.waitlist.lastname")}
+                    required
+                />
+            </div>
+            <input
+                type="email"
+                value={email}
+                onChange={(e) => setEmail(e.target.value)}
+                className="border border-gray-300 p-2 focus:ring-2 focus:ring-blue-500 focus:outline-none"
+                placeholder={t("calendar.waitlist.email")}
+                required
+            />
+            <button
+                type="submit"
+                className="cursor-pointer bg-blue-500 px-4 py-2 text-white hover:bg-blue-600 active:bg-blue-700"
+            >
+                {t("calendar.transfer.submit")}
+            </button>
+        </form>
+    );
+}
@@ -5,6 +5,7 @@ import { type ChangeEvent, type FormEvent, useEffect, useState } from "react";
 import { useTranslation } from "react-i18next";
 
 import { useAuth } from "@/api/AuthProvider";
+import { useEventTypes } from "@/api/data/EventTypeProvider";
 import type { CalendarEntryDto, Series } from "@/types";
 
 const recurrenceToDayjs: Record<string, dayjs.ManipulateType> = {
@@ -68,7 +69,12 @@ type CalendarSlotNewProps = {
     /** Selected date and time when directly clicking on an open time slot */
     initDatetime?: { date: string; time: number };
     onClose: () => void;
-    onSubmit: (entry: CalendarEntryDto, series?: Series) => Promise<boolean>;
+    onSubmit: (
+        entry: CalendarEntryDto,
+        series?: Series,
+        groupName?: string,
+        skipConflicts?: boolean,
+    ) => Promise<boolean>;
 };
 
 /**
@@ -80,10 +86,14 @@ function CalendarSlotNew({ mobile, open, initDatetime, onClose, onSubmit }: Cale
         firstName: localStorage.getItem("pray_calendar-new-firstname") ?? "",
         lastName: localStorage.getItem("pray_calendar-new-lastname") ?? "",
         email: localStorage.getItem("pray_calendar-new-email") ?? "",
+        reminders: localStorage.getItem("pray_calendar-new-reminders") === "true",
         date: "",
         startHour: "0",
         endHour: "0",
         series: false,
+        skipConflicts: false,
+        group: false,
+        groupName: "",
         adminEvent: "",
         // "daily" | "weekly" | "monthly"
         recurrence: "weekly",
@@ -98,6 +108,7 @@ function CalendarSlotNew({ mobile, open, initDatetime, onClose, onSubmit }: Cale
     const {
         state: { data: isAdmin },
     } = useAuth();
+    const { eventTypes, getEventTypeName } = useEventTypes();
     const { t } = useTranslation();
 
     const handleChange = (e: ChangeEvent<HTMLInputElement | HTMLSelectElement>) => {
@@ -202,6 +213,7 @@ function CalendarSlotNew({ mobile, open, initDatetime, onClose, onSubmit }: Cale
         localStorage.setItem("pray_calendar-new-firstname", formData.firstName);
         localStorage.setItem("pray_calendar-new-lastname", formData.lastName);
         localStorage.setItem("pray_calendar-new-email", formData.email!);
+        localStorage.setItem("pray_calendar-new-reminders", String(formData.reminders));
 
         const dto: CalendarEntryDto = {
             Id: -1,
@@ -213,7 +225,8 @@ function CalendarSlotNew({ mobile, open, initDatetime, onClose, onSubmit }: Cale
             Start: new Date(start.getTime() - start.getTimezoneOffset() * 60 * 1000).toISOString(),
             End: new Date(end.getTime() - end.getTimezoneOffset() * 60 * 1000).toISOString(),
             SeriesId: -1,
-            AdminEvent: formData.adminEvent || undefined,
+            EventTypeId: isAdminEvent ? Number(formData.adminEvent) : undefined,
+            Reminders: !isAdminEvent && formData.reminders,
         };
         const series: Series | undefined = formData.series
             ? {
@@ -221,15 +234,20 @@ function CalendarSlotNew({ mobile, open, initDatetime, onClose, onSubmit }: Cale
                   Repetitions: formData.repetitions,
               }
             : undefined;
-        if (await onSubmit(dto, series)) {
+        const groupName = formData.group ? formData.groupName : undefined;
+        if (await onSubmit(dto, series, groupName, formData.series && formData.skipConflicts)) {
             setFormData({
                 firstName: formData.firstName,
                 lastName: formData.lastName,
                 email: formData.email,
+                reminders: formData.reminders,
                 date: "",
                 startHour: "0",
                 endHour: "0",
                 series: false,
+                skipConflicts: false,
+                group: false,
+                groupName: "",
                 adminEvent: "",
                 recurrence: "weekly",
                 repetitions: 1,
@@ -254,12 +272,16 @@ function CalendarSlotNew({ mobile, open, initDatetime, onClose, onSubmit }: Cale
             firstName: localStorage.getItem("pray_calendar-new-firstname") ?? "",
             lastName: localStorage.getItem("pray_calendar-new-lastname") ?? "",
             email: localStorage.getItem("pray_calendar-new-email") ?? "",
+            reminders: localStorage.getItem("pray_calendar-new-reminders") === "true",
             date: initDatetime?.date ?? "",
             startHour: initDatetime?.time.toString() ?? "0",
             endHour:
                 (initDatetime?.time != null ? (initDatetime.time + 1).toString() : undefined) ??
                 "0",
             series: false,
+            skipConflicts: false,
+            group: false,
+            groupName: "",
             adminEvent: "",
             recurrence: "weekly",
             repetitions: 1,
@@ -392,6 +414,55 @@ function CalendarSlotNew({ mobile, open, initDatetime, onClose, onSubmit }: Cale
                             </div>
                         </div>
 
+                        {!isAdminEvent && (
+                            <div className="mt-3">
+                                <label className="flex w-fit items-center gap-2">
+                                    <input
+                                        type="checkbox"
+                                        name="reminders"
+                                        checked={formData.reminders}
+                                        onChange={handleChange}
+                                    />
+                                    <span className="font-medium">
+                                        {t("calendar.modal-new.reminders")}
+                                    </span>
+                                </label>
+                            </div>
+                        )}
+
+                        {/* A group block is split into hours, which the group members claim themselves */}
+                        {!isAdminEvent && !formData.series && (
+                            <div className="mt-3">
+                                <label className="flex w-fit items-center gap-2">
+                                    <input
+                                        type="checkbox"
+                                        name="group"
+                                        checked={formData.group}
+                                        onChange={handleChange}
+                                    />
+                                    <span className="font-medium">
+                                        {t("calendar.modal-new.group")}
+                                    </span>
+                                </label>
+
+                                {formData.group && (
+                                    <div className="my-1">
+                                        <label className="block text-sm font-medium">
+                                            {t("calendar.modal-new.group-name")}
+                                        </label>
+                                        <input
+                                            type="text"
+                                            name="groupName"
+                                            value={formData.groupName}
+                                            onChange={handleChange}
+                                            className="mt-1 w-full border p-2 focus:ring-2 focus:ring-blue-500"
+                                            required
+                                        />
+                                    </div>
+                                )}
+                            </div>
+                        )}
+
                         <div className="mt-3">
                             <label className="flex w-fit items-center gap-2">
                                 <input
@@ -399,6 +470,7 @@ function CalendarSlotNew({ mobile, open, initDatetime, onClose, onSubmit }: Cale
                                     name="series"
                                     checked={formData.series}
                                     onChange={handleChange}
+                                    disabled={formData.group}
                                 />
                                 <span className="font-medium">
                                     {t("calendar.modal-new.series")}
@@ -478,6 +550,20 @@ function CalendarSlotNew({ mobile, open, initDatetime, onClose, onSubmit }: Cale
                                             </div>
                                         </div>
                                     </div>
+
+                                    {!isAdminEvent && (
+                                        <label className="flex w-fit items-center gap-2">
+                                            <input
+                                                type="checkbox"
+                                                name="skipConflicts"
+                                                checked={formData.skipConflicts}
+                                                onChange={handleChange}
+                                            />
+                                            <span className="text-sm font-medium">
+                                                {t("calendar.modal-new.skip-conflicts")}
+                                            </span>
+                                        </label>
+                                    )}
                                 </div>
                             )}
                         </div>
@@ -495,19 +581,11 @@ function CalendarSlotNew({ mobile, open, initDatetime, onClose, onSubmit }: Cale
                                         className="border p-2"
                                     >
                                         <option value=""></option>
-                                        {/* It is currently sufficient to hard code the options */}
-                                        <option value="mass">
-                                            {t("calendar.modal-new.admin-event-mass")}
-                                        </option>
-                                        <option value="praise">
-                                            {t("calendar.modal-new.admin-event-praise")}
-                                        </option>
-                                        <option value="event">
-                                            {t("calendar.modal-new.admin-event-event")}
-                                        </option>
-                                        <option value="blocker">
-                                            {t("calendar.modal-new.admin-event-blocker")}
-                                        </option>
+                                        {eventTypes.map((eventType) => (
+                                            <option key={eventType.Id} value={eventType.Id}>
+                                                {getEventTypeName(eventType.Id)}
+                                            </option>
+                                        ))}
                                     </select>
                                 </div>
                             </div>
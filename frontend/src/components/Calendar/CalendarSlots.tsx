@@ -1,7 +1,9 @@
+import { icons } from "lucide-react";
 import React, { useEffect, useState } from "react";
-import { useTranslation } from "react-i18next";
 
+import { useAuth } from "@/api/AuthProvider";
 import { useApiCalendarEntry } from "@/api/data/CalendarEntryProvider";
+import { useEventTypes } from "@/api/data/EventTypeProvider";
 import CalendarSlotDetails from "@/components/Calendar/CalendarSlotDetails";
 import { useLoading } from "@/components/LoadingProvider";
 import type { CalendarEntryExtDto } from "@/types";
@@ -11,14 +13,6 @@ for (let h = 0; h < 24; h++) {
     slots.push({ hour: h });
 }
 
-// Due to the small scale of the app, the event types are hard coded
-const eventColorCss: Record<string, string> = {
-    mass: "bg-red-500 hover:bg-red-600 active:bg-red-600",
-    praise: "bg-purple-500 hover:bg-purple-600 active:bg-purple-700",
-    event: "bg-green-400 hover:bg-green-500 active:bg-green-600",
-    blocker: "bg-slate-400 hover:bg-slate-500 active:bg-slate-600",
-};
-
 type CalendarSlotsProps = {
     startOfWeek: Date;
     days: Date[];
@@ -36,7 +30,10 @@ function CalendarSlots({ startOfWeek, days, onSlotClick }: CalendarSlotsProps) {
 
     const { state, deleteCalendarEntry, deleteCalendarSeries } = useApiCalendarEntry();
     const { showLoading, hideLoading } = useLoading();
-    const { t } = useTranslation();
+    const {
+        state: { data: isAdmin },
+    } = useAuth();
+    const { getEventType, getEventTypeName } = useEventTypes();
 
     useEffect(() => {
         const searchDate = startOfWeek.toISOString().split("T")[0];
@@ -59,37 +56,59 @@ function CalendarSlots({ startOfWeek, days, onSlotClick }: CalendarSlotsProps) {
                         const slotDate = new Date(day);
                         slotDate.setHours(hour, 0, 0, 0);
 
-                        const event = events.find((e) => {
+                        const slotEvents = events.filter((e) => {
                             const st = e.startDate.getTime();
                             return st >= slotDate.getTime() && st < slotDate.getTime() + 900000;
                         });
+                        // Admin events that don't block bookings may overlap the entries of
+                        // participants, which take precedence
+                        const event =
+                            slotEvents.find(
+                                (e) => getEventType(e.EventTypeId)?.BlocksBookings !== false,
+                            ) ?? slotEvents[0];
 
                         let eventDiv = null;
                         if (event) {
+                            const eventType = getEventType(event.EventTypeId);
+                            const Icon = eventType && icons[eventType.Icon as keyof typeof icons];
+
                             let color;
-                            if (event.AdminEvent) {
-                                color = eventColorCss[event.AdminEvent] ?? "bg-black";
+                            let style: React.CSSProperties = {};
+                            if (event.EventTypeId) {
+                                color = "hover:brightness-90 active:brightness-75";
+                                style = { backgroundColor: eventType?.Color ?? "black" };
+                                // Clicking a non-blocking admin event books the slot instead
+                                if (eventType?.BlocksBookings === false && !isAdmin) {
+                                    color = "pointer-events-none opacity-75";
+                                }
                             } else if (event.SeriesId != null) {
                                 color = "bg-orange-500 hover:bg-orange-600 active:bg-orange-700";
                             } else {
                                 color = "bg-blue-500 hover:bg-blue-600 active:bg-blue-700";
                             }
 
-                            // We presuppose that only our approved events can and will be entered, as they have corresponding strings
-                            const eventName = event.AdminEvent
-                                ? t(`calendar.modal-new.admin-event-${event.AdminEvent}`)
+                            // Group entries are shown under the group name, the members only to the admin
+                            let eventName = event.EventTypeId
+                                ? getEventTypeName(event.EventTypeId)
                                 : `${event.FirstName} ${event.LastName ?? ""}`;
+                            if (event.GroupName) {
+                                eventName = event.LastName
+                                    ? `${event.GroupName}: ${eventName}`
+                                    : event.GroupName;
+                            }
 
                             if (event.slots === 1) {
                                 // If we only cover a single slot, we need a compact design
                                 eventDiv = (
                                     <div
                                         className={`absolute inset-1 z-10 flex cursor-pointer items-center p-2 text-left text-xs text-white shadow ${color}`}
+                                        style={style}
                                         onClick={(e) => {
                                             e.stopPropagation();
                                             setSelectedEvent(event);
                                         }}
                                     >
+                                        {Icon && <Icon className="mr-1 size-3 shrink-0" />}
                                         {eventName}
                                         {event.Email ? " - " : ""}
                                         {event.Email ?? ""}
@@ -100,7 +119,10 @@ function CalendarSlots({ startOfWeek, days, onSlotClick }: CalendarSlotsProps) {
                                 eventDiv = (
                                     <div
                                         className={`absolute inset-1 z-10 flex cursor-pointer flex-col p-2 text-left text-xs text-white shadow ${color}`}
-                                        style={{ height: `calc(${event.slots}00% - 0.5rem)` }}
+                                        style={{
+                                            ...style,
+                                            height: `calc(${event.slots}00% - 0.5rem)`,
+                                        }}
                                         onClick={(e) => {
                                             e.stopPropagation();
                                             setSelectedEvent(event);
@@ -111,7 +133,10 @@ function CalendarSlots({ startOfWeek, days, onSlotClick }: CalendarSlotsProps) {
                                             {" - "}
                                             {event.End.slice(11, 16)}
                                         </div>
-                                        <div>{eventName}</div>
+                                        <div className="flex items-center">
+                                            {Icon && <Icon className="mr-1 size-3 shrink-0" />}
+                                            {eventName}
+                                        </div>
                                         <div>{event.Email ?? ""}</div>
                                     </div>
                                 );
@@ -159,15 +184,16 @@ function CalendarSlots({ startOfWeek, days, onSlotClick }: CalendarSlotsProps) {
             <CalendarSlotDetails
                 event={selectedEvent}
                 onClose={() => setSelectedEvent(undefined)}
-                onDelete={async (id, email, isSeries) => {
+                onDelete={async (id, email, reason, isSeries) => {
                     showLoading(true);
                     if (isSeries) {
-                        await deleteCalendarSeries(id, email);
+                        await deleteCalendarSeries(id, email, reason);
                     } else {
                         await deleteCalendarEntry(
                             id,
                             email,
                             startOfWeek.toISOString().split("T")[0],
+                            reason,
                         );
                     }
                     hideLoading();
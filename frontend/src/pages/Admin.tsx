@@ -1,4 +1,3 @@
-import type { AxiosError } from "axios";
 import { type ChangeEvent, type FormEvent, useState } from "react";
 import { useTranslation } from "react-i18next";
 import { useNavigate } from "react-router-dom";
@@ -8,6 +7,7 @@ import { useAuth } from "@/api/AuthProvider";
 import { useLoading } from "@/components/LoadingProvider";
 import { useToast } from "@/components/Toast/ToastProvider";
 import { downloadAsFile } from "@/util/file";
+import { problemMessage } from "@/util/problem";
 
 /**
  * This component provides the admin with various, not necessarily related admin functions.
@@ -73,10 +73,7 @@ function DeleteUser() {
             });
             showToast("success", t("admin.userdata.success-delete"), 5000);
         } catch (error) {
-            showToast(
-                "error",
-                `${t("admin.userdata.error-delete")}: ${(error as AxiosError).response?.data}`,
-            );
+            showToast("error", `${t("admin.userdata.error-delete")}: ${problemMessage(error, t)}`);
         }
         hideLoading();
     };
@@ -179,7 +176,7 @@ function QueryEmails() {
         } catch (error) {
             showToast(
                 "error",
-                `${t("admin.query-emails.error-query")}: ${(error as AxiosError).response?.data}`,
+                `${t("admin.query-emails.error-query")}: ${problemMessage(error, t)}`,
             );
         }
         hideLoading();
@@ -276,7 +273,7 @@ function QueryVolunteerEmails() {
         } catch (error) {
             showToast(
                 "error",
-                `${t("admin.query-volunteer-emails.error-query")}: ${(error as AxiosError).response?.data}`,
+                `${t("admin.query-volunteer-emails.error-query")}: ${problemMessage(error, t)}`,
             );
         }
         hideLoading();
@@ -328,10 +325,7 @@ function DeleteVolunteer() {
             setEmail("");
             showToast("success", t("admin.volunteer.success-delete"), 5000);
         } catch (error) {
-            showToast(
-                "error",
-                `${t("admin.volunteer.error-delete")}: ${(error as AxiosError).response?.data}`,
-            );
+            showToast("error", `${t("admin.volunteer.error-delete")}: ${problemMessage(error, t)}`);
         }
         hideLoading();
     };
@@ -1,14 +1,23 @@
-import type { AxiosError } from "axios";
-import { type FormEvent, useState } from "react";
+import { type FormEvent, useEffect, useState } from "react";
 import { useTranslation } from "react-i18next";
-import { NavLink } from "react-router-dom";
+import { NavLink, useLocation } from "react-router-dom";
 
 import { useApi } from "@/api/ApiProvider";
 import { useLoading } from "@/components/LoadingProvider";
 import { useToast } from "@/components/Toast/ToastProvider";
+import { problemMessage } from "@/util/problem";
 
 function Home() {
     const { t } = useTranslation();
+    const location = useLocation();
+
+    // The QR codes on the posters link directly to the volunteer registration, which must be scrolled
+    // to after rendering
+    useEffect(() => {
+        if (location.hash) {
+            document.getElementById(location.hash.slice(1))?.scrollIntoView();
+        }
+    }, [location.hash]);
 
     return (
         <main className="text-container">
@@ -32,7 +41,9 @@ function Home() {
             <p>{t("home.paragraph-worship")}</p>
 
             <>
-                <h2 className="mt-8 mb-2 text-2xl font-semibold">{t("home.volunteer.heading")}</h2>
+                <h2 id="volunteer" className="mt-8 mb-2 text-2xl font-semibold">
+                    {t("home.volunteer.heading")}
+                </h2>
                 <p>{t("home.volunteer.paragraph1")}</p>
                 <p>{t("home.volunteer.paragraph2")}</p>
                 <VolunteerInput />
@@ -114,10 +125,7 @@ function VolunteerInput() {
             setEmail("");
             showToast("success", t("home.volunteer.success-post"), 5000);
         } catch (error) {
-            showToast(
-                "error",
-                `${t("home.volunteer.error-post")}: ${(error as AxiosError).response?.data}`,
-            );
+            showToast("error", `${t("home.volunteer.error-post")}: ${problemMessage(error, t)}`);
         }
         hideLoading();
     };
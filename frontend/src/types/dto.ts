@@ -11,7 +11,20 @@ export type CalendarEntryDto = {
     Start: string;
     End: string;
     SeriesId?: number;
-    AdminEvent?: string;
+    EventTypeId?: number;
+    Reminders?: boolean;
+    GroupId?: number;
+    GroupName?: string;
+};
+
+export type EventTypeDto = {
+    Id: number;
+    Name: string;
+    Color: string;
+    Icon: string;
+    BlocksBookings: boolean;
+    CountsAsCoverage: boolean;
+    Translations: Record<string, string>;
 };
 
 export type CalendarEntryExtDto = CalendarEntryDto & {
@@ -20,6 +33,39 @@ export type CalendarEntryExtDto = CalendarEntryDto & {
     slots: number;
 };
 
+export type IntervalDto = {
+    Start: string;
+    End: string;
+};
+
+export type DisplacementDto = {
+    Entry: CalendarEntryDto;
+    Remains: IntervalDto[];
+};
+
+export type ConflictDto = {
+    Requested: IntervalDto;
+    Entries: CalendarEntryDto[];
+    Alternative?: IntervalDto;
+};
+
+export type ProblemDto = {
+    type: string;
+    title: string;
+    status: number;
+    detail?: string;
+    instance?: string;
+    code: string;
+    conflicts?: ConflictDto[];
+    errors?: FieldErrorDto[];
+};
+
+export type FieldErrorDto = {
+    field: string;
+    code: string;
+    detail: string;
+};
+
 export type Series = {
     Interval: string;
     Repetitions: number;
@@ -29,3 +75,42 @@ export type SeriesRequest = {
     Series: Series;
     Entry: CalendarEntryDto;
 };
+
+export type SeriesResponseDto = {
+    Booked: CalendarEntryDto[];
+    Skipped: ConflictDto[];
+};
+
+export type GroupDto = {
+    Id?: number;
+    Name: string;
+    FirstName: string;
+    LastName: string;
+    Email: string;
+    Start: string;
+    End: string;
+};
+
+export type GroupDetailsDto = {
+    Group: GroupDto;
+    InviteLink: string;
+    Entries: CalendarEntryDto[];
+};
+
+export type TransferDto = {
+    Id: number;
+    EntryId: number;
+    CreatedAt: string;
+    Entry: CalendarEntryDto;
+};
+
+export type TransferProposalDto = {
+    Id?: number;
+    TransferId?: number;
+    FirstName: string;
+    LastName: string;
+    Email: string;
+    Reminders?: boolean;
+    SwapEntryId?: number;
+    Status?: string;
+};
@@ -0,0 +1,33 @@
+import type { AxiosError } from "axios";
+import type { TFunction } from "i18next";
+
+import type { FieldErrorDto, ProblemDto } from "@/types";
+
+/**
+ * Localises the problem response of a failed request via its stable code, falling back to the detail
+ * provided by the backend for unknown codes. An invalid request lists all its field errors
+ * instead.
+ */
+export function problemMessage(error: unknown, t: TFunction): string {
+    const problem = (error as AxiosError<ProblemDto>).response?.data;
+    if (problem?.code == null) {
+        return "";
+    }
+    if (problem.errors != null && problem.errors.length > 0) {
+        return problem.errors
+            .map((fieldError) => fieldErrorMessage(fieldError, t))
+            .join(", ");
+    }
+    return t(`problem.${problem.code}`, { defaultValue: problem.detail ?? problem.title });
+}
+
+/**
+ * Localises a single field error, whose field is named by its last segment, e.g. "Start" for
+ * "Entry.Start".
+ */
+function fieldErrorMessage(fieldError: FieldErrorDto, t: TFunction): string {
+    const field = fieldError.field.split(".").pop() ?? fieldError.field;
+    const label = t(`problem.fields.${field}`, { defaultValue: field });
+    const message = t(`problem.${fieldError.code}`, { defaultValue: fieldError.detail });
+    return `${label}: ${message}`;
+}